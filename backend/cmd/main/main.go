@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"github.com/k1v4/Pinger/backend/internal/config"
 	v1 "github.com/k1v4/Pinger/backend/internal/controller/http/v1"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/metrics"
 	"github.com/k1v4/Pinger/backend/internal/usecase"
 	"github.com/k1v4/Pinger/backend/internal/usecase/repository"
+	"github.com/k1v4/Pinger/backend/internal/usecase/webapi"
 	"github.com/k1v4/Pinger/backend/pkg/DB/postgres"
 	"github.com/k1v4/Pinger/backend/pkg/httpserver"
 	"github.com/k1v4/Pinger/backend/pkg/logger"
@@ -16,10 +19,15 @@ import (
 	"os/signal"
 	"strconv"
 	"syscall"
+	"time"
 )
 
+const _pruneInterval = time.Hour
+
 func main() {
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	loggerBack := logger.NewLogger()
 
 	loggerBack.Info(ctx, "starting backend")
@@ -30,6 +38,19 @@ func main() {
 		return
 	}
 
+	staticKeys := []usecase.StaticApiKey{
+		{Name: "ADMIN_API_KEY", Key: cfg.AdminApiKey, Scopes: []string{entity.ScopeAdmin}},
+		{Name: "INGEST_API_KEY", Key: cfg.IngestApiKey, Scopes: []string{entity.ScopeIngest}},
+	}
+	if err := usecase.CheckStaticKeys(staticKeys...); err != nil {
+		loggerBack.Error(ctx, fmt.Sprintf("app - Run - usecase.CheckStaticKeys: %s", err))
+		return
+	}
+	if err := usecase.CheckAdminPassword(cfg.AdminPassword); err != nil {
+		loggerBack.Error(ctx, fmt.Sprintf("app - Run - usecase.CheckAdminPassword: %s", err))
+		return
+	}
+
 	url := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
 		cfg.DBConfig.UserName,
 		cfg.DBConfig.Password,
@@ -46,18 +67,133 @@ func main() {
 
 	loggerBack.Info(ctx, "connected to database successfully")
 
+	eventRepo := repository.NewEventRepo(pg)
+	streamUseCase := usecase.NewStream()
+
+	go func() {
+		err := eventRepo.Listen(ctx, streamUseCase.Broadcast)
+		if err != nil {
+			loggerBack.Error(ctx, fmt.Sprintf("app - Run - eventRepo.Listen: %s", err))
+		}
+	}()
+
+	backMetrics := metrics.New()
+
+	deleted, unsubscribe := streamUseCase.Subscribe(entity.EventFilter{Types: []string{entity.EventContainerDeleted}})
+	defer unsubscribe()
+
+	go backMetrics.ForgetDeleted(deleted)
+
+	silenceUseCase := usecase.NewSilences(repository.NewSilenceRepo(pg))
+
 	containerUseCase := usecase.New(
 		repository.NewContainerRepo(pg),
+		eventRepo,
+		backMetrics,
+		silenceUseCase,
+		loggerBack,
+	)
+
+	statsUseCase := usecase.NewStats(repository.NewStatsRepo(pg))
+	containerEventUseCase := usecase.NewContainerEvents(repository.NewContainerEventRepo(pg), eventRepo, backMetrics, loggerBack)
+
+	apiKeyUseCase := usecase.NewApiKeys(repository.NewApiKeyRepo(pg), staticKeys...)
+
+	userRepo := repository.NewUserRepo(pg)
+	userUseCase := usecase.NewUsers(userRepo, cfg.SessionTTL)
+
+	if cfg.AdminPassword != "" {
+		err = userUseCase.EnsureAdmin(ctx, cfg.AdminLogin, cfg.AdminPassword)
+		if err != nil {
+			loggerBack.Error(ctx, fmt.Sprintf("app - Run - userUseCase.EnsureAdmin: %s", err))
+		}
+	}
+
+	// вход через провайдера включается с OIDC_ISSUER, без него остаётся вход по паролю
+	var oidcUseCase usecase.Oidc
+	if cfg.OidcIssuer != "" {
+		oidcUseCase, err = newOidc(ctx, cfg, userRepo, loggerBack)
+		if err != nil {
+			loggerBack.Error(ctx, fmt.Sprintf("app - Run - newOidc: %s", err))
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(_pruneInterval)
+		defer ticker.Stop()
+
+		for {
+			_, err := statsUseCase.Prune(ctx, time.Now().Add(-cfg.StatsRetention))
+			if err != nil && ctx.Err() == nil {
+				loggerBack.Error(ctx, fmt.Sprintf("app - Run - statsUseCase.Prune: %s", err))
+			}
+
+			_, err = userUseCase.PruneSessions(ctx, time.Now())
+			if err != nil && ctx.Err() == nil {
+				loggerBack.Error(ctx, fmt.Sprintf("app - Run - userUseCase.PruneSessions: %s", err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	backMetrics.Register(
+		metrics.NewContainerCollector(containerUseCase),
+		metrics.NewPoolCollector(pg.Pool),
 	)
 
 	handler := echo.New()
+	handler.Use(backMetrics.Middleware())
 	handler.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"http://localhost:3000", "http://10.255.196.171:3000"},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
+		AllowOrigins:     cfg.CorsOrigins,
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, "X-CSRF-Token"},
+		AllowCredentials: true,
 	}))
-	v1.NewRouter(handler, loggerBack, containerUseCase)
+	err = v1.NewRouter(handler, loggerBack, containerUseCase, streamUseCase, statsUseCase, containerEventUseCase,
+		apiKeyUseCase, userUseCase, oidcUseCase, silenceUseCase, backMetrics.Handler(), v1.AuthConfig{
+			AnonymousRead:    cfg.AnonymousRead,
+			SecureCookie:     cfg.SecureCookie,
+			OidcPostLoginUrl: cfg.OidcPostLoginUrl,
+			AgentCertIngest:  cfg.TLSClientCAFile != "",
+			AllowOrigins:     cfg.CorsOrigins,
+		})
+	if err != nil {
+		loggerBack.Error(ctx, fmt.Sprintf("app - Run - v1.NewRouter: %s", err))
+		return
+	}
 
-	httpServer := httpserver.New(handler, httpserver.Port(strconv.Itoa(cfg.RestServerPort)))
+	serverOpts := []httpserver.Option{
+		httpserver.Port(strconv.Itoa(cfg.RestServerPort)),
+		httpserver.OnShutdown(streamUseCase.Close),
+		httpserver.ErrorHandler(func(err error) {
+			loggerBack.Error(ctx, fmt.Sprintf("app - Run - httpServer: %s", err))
+		}),
+	}
+
+	if cfg.TLSCertFile != "" {
+		minVersion, err := httpserver.TLSVersion(cfg.TLSMinVersion)
+		if err != nil {
+			loggerBack.Error(ctx, fmt.Sprintf("app - Run - httpserver.TLSVersion: %s", err))
+			return
+		}
+
+		serverOpts = append(serverOpts,
+			httpserver.TLS(cfg.TLSCertFile, cfg.TLSKeyFile),
+			httpserver.MinTLSVersion(minVersion),
+		)
+		if cfg.TLSClientCAFile != "" {
+			serverOpts = append(serverOpts, httpserver.ClientCA(cfg.TLSClientCAFile))
+		}
+	} else if cfg.TLSClientCAFile != "" {
+		loggerBack.Error(ctx, "app - Run - TLS_CLIENT_CA_FILE is set without TLS_CERT_FILE")
+		return
+	}
+
+	httpServer := httpserver.New(handler, serverOpts...)
 
 	// signal for graceful shutdown
 	interrupt := make(chan os.Signal, 1)
@@ -76,3 +212,27 @@ func main() {
 		loggerBack.Error(ctx, fmt.Sprintf("app - Run - httpServer.Shutdown: %s", err))
 	}
 }
+
+// newOidc собирает вход через провайдера. Недоступный на старте провайдер не выключает вход:
+// discovery повторяется при первом GET /v1/auth/oidc/login.
+func newOidc(ctx context.Context, cfg *config.Config, r usecase.UserRepo, l logger.Logger) (usecase.Oidc, error) {
+	provider := webapi.NewOidc(webapi.OidcConfig{
+		Issuer:       cfg.OidcIssuer,
+		ClientId:     cfg.OidcClientId,
+		ClientSecret: cfg.OidcClientSecret,
+		RedirectUrl:  cfg.OidcRedirectUrl,
+		Scopes:       cfg.OidcScopes,
+		LoginClaim:   cfg.OidcLoginClaim,
+		GroupsClaim:  cfg.OidcGroupsClaim,
+	})
+	if err := provider.Discover(ctx); err != nil {
+		l.Error(ctx, fmt.Sprintf("app - Run - newOidc: %s, retrying on login", err))
+	}
+
+	oidc, err := usecase.NewOidc(provider, r, cfg.SessionTTL, cfg.OidcGroupRoles, cfg.OidcDefaultRole)
+	if err != nil {
+		return nil, err
+	}
+
+	return oidc, nil
+}
@@ -3,12 +3,40 @@ package config
 import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/k1v4/Pinger/backend/pkg/DB/postgres"
+	"time"
 )
 
 type Config struct {
 	postgres.DBConfig
 
-	RestServerPort int `env:"REST_SERVER_PORT" env-description:"rest server port" env-default:"8080"`
+	RestServerPort int           `env:"REST_SERVER_PORT" env-description:"rest server port" env-default:"8080"`
+	StatsRetention time.Duration `env:"STATS_RETENTION" env-description:"how long container stats are kept" env-default:"168h"`
+	CorsOrigins    []string      `env:"CORS_ORIGINS" env-description:"dashboard origins allowed to call the api and open the websocket stream" env-default:"http://localhost:3000,http://10.255.196.171:3000"`
+
+	TLSCertFile     string `env:"TLS_CERT_FILE" env-description:"server certificate, the server speaks plain http if empty"`
+	TLSKeyFile      string `env:"TLS_KEY_FILE" env-description:"server certificate key"`
+	TLSClientCAFile string `env:"TLS_CLIENT_CA_FILE" env-description:"ca of pinger client certificates, only pingers with a certificate can ingest if set"`
+	TLSMinVersion   string `env:"TLS_MIN_VERSION" env-description:"minimal tls version: 1.2 or 1.3" env-default:"1.2"`
+
+	AdminApiKey   string `env:"ADMIN_API_KEY" env-description:"static api key with the admin scope"`
+	IngestApiKey  string `env:"INGEST_API_KEY" env-description:"static api key with the ingest scope for the pinger"`
+	AnonymousRead bool   `env:"ANONYMOUS_READ" env-description:"allow requests without an api key to read" env-default:"false"`
+
+	AdminLogin    string        `env:"ADMIN_LOGIN" env-description:"dashboard admin created on start if missing" env-default:"admin"`
+	AdminPassword string        `env:"ADMIN_PASSWORD" env-description:"password of the dashboard admin, no admin is created if empty"`
+	SessionTTL    time.Duration `env:"SESSION_TTL" env-description:"dashboard session lifetime" env-default:"12h"`
+	SecureCookie  bool          `env:"SECURE_COOKIE" env-description:"send the session cookie over https only" env-default:"false"`
+
+	OidcIssuer       string            `env:"OIDC_ISSUER" env-description:"issuer url of the openid connect provider, single sign-on is off if empty"`
+	OidcClientId     string            `env:"OIDC_CLIENT_ID" env-description:"client id registered at the provider"`
+	OidcClientSecret string            `env:"OIDC_CLIENT_SECRET" env-description:"client secret, empty for a public client"`
+	OidcRedirectUrl  string            `env:"OIDC_REDIRECT_URL" env-description:"callback url registered at the provider" env-default:"http://localhost:8080/v1/auth/oidc/callback"`
+	OidcScopes       []string          `env:"OIDC_SCOPES" env-description:"scopes requested from the provider" env-default:"openid,profile,email"`
+	OidcLoginClaim   string            `env:"OIDC_LOGIN_CLAIM" env-description:"id token claim used as the pinger login" env-default:"preferred_username"`
+	OidcGroupsClaim  string            `env:"OIDC_GROUPS_CLAIM" env-description:"id token claim with the user groups" env-default:"groups"`
+	OidcGroupRoles   map[string]string `env:"OIDC_GROUP_ROLES" env-description:"provider groups mapped to pinger roles as group:role pairs, the highest role wins"`
+	OidcDefaultRole  string            `env:"OIDC_DEFAULT_ROLE" env-description:"role of users without a mapped group, they cannot sign in if empty"`
+	OidcPostLoginUrl string            `env:"OIDC_POST_LOGIN_URL" env-description:"where the browser goes after signing in with the provider" env-default:"http://localhost:3000/"`
 }
 
 func MustLoadConfig() *Config {
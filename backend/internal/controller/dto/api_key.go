@@ -0,0 +1,18 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+)
+
+type CreateApiKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type CreateApiKeyResponse struct {
+	ApiKey entity.ApiKey `json:"api_key"`
+	Key    string        `json:"key"`
+}
@@ -7,20 +7,24 @@ type NewContainerResponse struct {
 }
 
 type DtoPingContainer struct {
-	PingTime       int       `json:"ping_time"`
-	IsSuccessful   bool      `json:"is_successful"`
-	LastSuccessful time.Time `json:"last_successful"`
+	PingTime       int               `json:"ping_time"`
+	IsSuccessful   bool              `json:"is_successful"`
+	LastSuccessful *time.Time        `json:"last_successful"`
+	Name           string            `json:"name"`
+	Image          string            `json:"image"`
+	Labels         map[string]string `json:"labels"`
 }
 
 type UpdateContainerRequest struct {
-	PingTime       int       `json:"ping_time"`
-	LastSuccessful time.Time `json:"last_successful"`
+	PingTime       int        `json:"ping_time"`
+	IsSuccessful   *bool      `json:"is_successful"`
+	LastSuccessful *time.Time `json:"last_successful"`
 }
 
 type AddContainerRequest struct {
-	Ip             string    `json:"ip"`
-	PingTime       int       `json:"ping_time"`
-	LastSuccessful time.Time `json:"last_successful"`
+	Ip             string     `json:"ip"`
+	PingTime       int        `json:"ping_time"`
+	LastSuccessful *time.Time `json:"last_successful"`
 }
 
 type DeleteContainerResponse struct {
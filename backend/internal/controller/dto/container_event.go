@@ -0,0 +1,15 @@
+package dto
+
+import "time"
+
+type AddContainerEventRequest struct {
+	Type          string    `json:"type"`
+	ContainerId   string    `json:"container_id"`
+	ContainerName string    `json:"container_name"`
+	Image         string    `json:"image"`
+	DockerHost    string    `json:"docker_host"`
+	ExitCode      *int      `json:"exit_code"`
+	RestartCount  int       `json:"restart_count"`
+	Message       string    `json:"message"`
+	Time          time.Time `json:"time"`
+}
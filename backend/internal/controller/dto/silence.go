@@ -0,0 +1,9 @@
+package dto
+
+import "time"
+
+type AddSilenceRequest struct {
+	Ip      string    `json:"ip"`
+	Comment string    `json:"comment"`
+	Until   time.Time `json:"until"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+import "time"
+
+type AddStatsRequest struct {
+	Time           time.Time `json:"time"`
+	CPUPercent     float64   `json:"cpu_percent"`
+	MemoryUsage    uint64    `json:"memory_usage"`
+	MemoryLimit    uint64    `json:"memory_limit"`
+	NetRxRate      float64   `json:"net_rx_bytes_per_second"`
+	NetTxRate      float64   `json:"net_tx_bytes_per_second"`
+	BlockReadRate  float64   `json:"block_read_bytes_per_second"`
+	BlockWriteRate float64   `json:"block_write_bytes_per_second"`
+}
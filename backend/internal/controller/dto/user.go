@@ -0,0 +1,30 @@
+package dto
+
+import "github.com/k1v4/Pinger/backend/internal/entity"
+
+type LoginRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+// SessionResponse - пользователь сессии и CSRF-токен для заголовка X-CSRF-Token.
+type SessionResponse struct {
+	User      entity.User `json:"user"`
+	CsrfToken string      `json:"csrf_token"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type CreateUserRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+type UpdateUserRequest struct {
+	Role     string `json:"role"`
+	Password string `json:"password"`
+}
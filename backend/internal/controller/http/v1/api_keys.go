@@ -0,0 +1,75 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/k1v4/Pinger/backend/internal/controller/dto"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+type apiKeyRoutes struct {
+	k usecase.ApiKeys
+	l logger.Logger
+}
+
+func newApiKeyRoutes(handler *echo.Group, k usecase.ApiKeys, l logger.Logger) {
+	r := &apiKeyRoutes{k, l}
+
+	// группа роутов для /v1/keys, только для ключей с admin
+	h := handler.Group("/keys", requireScope(entity.ScopeAdmin, l))
+	{
+		// GET /v1/keys
+		h.GET("", r.ApiKeys)
+
+		// POST /v1/keys
+		h.POST("", r.CreateApiKey)
+
+		// DELETE /v1/keys/{id}
+		h.DELETE("/:id", r.RevokeApiKey)
+	}
+}
+
+func (ar *apiKeyRoutes) ApiKeys(c echo.Context) error {
+	keys, err := ar.k.ApiKeys(c.Request().Context())
+	if err != nil {
+		return errorResponse(c, ar.l, fmt.Errorf("http-v1-ApiKeys: %w", err))
+	}
+
+	return c.JSON(http.StatusOK, keys)
+}
+
+func (ar *apiKeyRoutes) CreateApiKey(c echo.Context) error {
+	u := new(dto.CreateApiKeyRequest)
+	if err := c.Bind(u); err != nil {
+		return errorResponse(c, ar.l, fmt.Errorf("http-v1-CreateApiKey: %w", malformedBody(err)))
+	}
+
+	key, secret, err := ar.k.CreateKey(c.Request().Context(), entity.ApiKey{
+		Name:      u.Name,
+		Scopes:    u.Scopes,
+		ExpiresAt: u.ExpiresAt,
+	})
+	if err != nil {
+		return errorResponse(c, ar.l, fmt.Errorf("http-v1-CreateApiKey: %w", err))
+	}
+
+	return c.JSON(http.StatusCreated, dto.CreateApiKeyResponse{ApiKey: key, Key: secret})
+}
+
+func (ar *apiKeyRoutes) RevokeApiKey(c echo.Context) error {
+	id, err := idParam(c)
+	if err != nil {
+		return errorResponse(c, ar.l, fmt.Errorf("http-v1-RevokeApiKey: %w", err))
+	}
+
+	key, err := ar.k.RevokeKey(c.Request().Context(), id)
+	if err != nil {
+		return errorResponse(c, ar.l, fmt.Errorf("http-v1-RevokeApiKey: %w", err))
+	}
+
+	return c.JSON(http.StatusOK, key)
+}
@@ -0,0 +1,190 @@
+package v1
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/controller/dto"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+// TestApiKeys - ключи из базы через echo в процессе: хранится только хеш, права ключа проверяет requireScope,
+// истёкший и отозванный ключ получают 401. /metrics тоже требует права read.
+func TestApiKeys(t *testing.T) {
+	repo := &memApiKeys{}
+	keys := usecase.NewApiKeys(repo, usecase.StaticApiKey{Name: "test", Key: "pk_test", Scopes: []string{entity.ScopeAdmin}})
+	events := usecase.NewContainerEvents(&memContainerEvents{}, failingPublisher{}, nopEventObserver{}, logger.NewLogger())
+
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "pinger_container_up 1")
+	})
+
+	e := echo.New()
+	if err := NewRouter(e, logger.NewLogger(), nil, nil, nil, events, keys, nil, nil, &memSilences{}, metrics, AuthConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(method, target, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, token)
+		}
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	expect := func(method, target, token, body string, status int, want string) {
+		t.Helper()
+
+		rec := do(method, target, token, body)
+		if rec.Code != status || !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("%s %s with %q: got %d %s, want %d %s", method, target, token, rec.Code, rec.Body.String(), status, want)
+		}
+	}
+
+	create := func(body string) dto.CreateApiKeyResponse {
+		t.Helper()
+
+		rec := do(http.MethodPost, "/v1/keys", "Bearer pk_test", body)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create key %s: got %d %s", body, rec.Code, rec.Body.String())
+		}
+
+		var res dto.CreateApiKeyResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+
+		return res
+	}
+
+	reader := create(`{"name": "grafana", "scopes": ["read", "read"]}`)
+	if !strings.HasPrefix(reader.Key, "pk_") || !strings.HasPrefix(reader.Key, reader.ApiKey.Prefix) ||
+		len(reader.ApiKey.Scopes) != 1 {
+		t.Errorf("created key %+v", reader)
+	}
+
+	// в базе только sha256 ключа
+	sum := sha256.Sum256([]byte(reader.Key))
+	if hash := repo.hash(reader.ApiKey.Id); hash != hex.EncodeToString(sum[:]) {
+		t.Errorf("stored hash %q", hash)
+	}
+
+	read := "Bearer " + reader.Key
+	expect(http.MethodGet, "/v1/silences", read, "", http.StatusOK, "[]")
+	expect(http.MethodPost, "/v1/silences", read, silenceBody(), http.StatusForbidden, `requires \"operate\"`)
+	expect(http.MethodGet, "/v1/keys", read, "", http.StatusForbidden, `requires \"admin\"`)
+	expect(http.MethodGet, "/v1/keys", "Bearer pk_test", "", http.StatusOK, `"name":"grafana"`)
+	expect(http.MethodGet, "/metrics", read, "", http.StatusOK, "pinger_container_up")
+	expect(http.MethodGet, "/metrics", "", "", http.StatusUnauthorized, "unauthorized")
+
+	ingest := "Bearer " + create(`{"name": "pinger", "scopes": ["ingest"]}`).Key
+	expect(http.MethodPost, "/v1/containers/10.0.0.1/events", ingest, `{"type": "started", "container_id": "`+_testContainerId+`"}`,
+		http.StatusCreated, `"type":"started"`)
+	expect(http.MethodGet, "/v1/silences", ingest, "", http.StatusForbidden, `requires \"read\"`)
+	expect(http.MethodGet, "/metrics", ingest, "", http.StatusForbidden, `requires \"read\"`)
+
+	expect(http.MethodPost, "/v1/keys", "Bearer pk_test", `{"name": "bad", "scopes": ["root"]}`, http.StatusBadRequest, "scopes")
+	expect(http.MethodPost, "/v1/keys", "Bearer pk_test", `{"name": "bad", "scopes": ["read"], "expires_at": "2020-01-01T00:00:00Z"}`,
+		http.StatusBadRequest, "expires_at")
+
+	expiring := create(`{"name": "temp", "scopes": ["read"], "expires_at": "` + time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `"}`)
+	expect(http.MethodGet, "/v1/silences", "Bearer "+expiring.Key, "", http.StatusOK, "[]")
+	repo.expire(expiring.ApiKey.Id)
+	expect(http.MethodGet, "/v1/silences", "Bearer "+expiring.Key, "", http.StatusUnauthorized, "unauthorized")
+
+	expect(http.MethodDelete, "/v1/keys/1", read, "", http.StatusForbidden, "forbidden")
+	expect(http.MethodDelete, "/v1/keys/1", "Bearer pk_test", "", http.StatusOK, `"revoked_at"`)
+	expect(http.MethodGet, "/v1/silences", read, "", http.StatusUnauthorized, "unauthorized")
+	expect(http.MethodDelete, "/v1/keys/42", "Bearer pk_test", "", http.StatusNotFound, "api_key_not_found")
+
+	expect(http.MethodGet, "/v1/silences", "", "", http.StatusUnauthorized, "unauthorized")
+	expect(http.MethodGet, "/v1/silences", "Bearer pk_unknown", "", http.StatusUnauthorized, "unauthorized")
+	expect(http.MethodGet, "/v1/silences", "Basic "+reader.Key, "", http.StatusUnauthorized, "unauthorized")
+}
+
+// memApiKeys - usecase.ApiKeyRepo в памяти.
+type memApiKeys struct {
+	mu     sync.Mutex
+	keys   []entity.ApiKey
+	hashes []string
+}
+
+func (m *memApiKeys) AddApiKey(_ context.Context, key entity.ApiKey, hash string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key.Id = int64(len(m.keys) + 1)
+	m.keys = append(m.keys, key)
+	m.hashes = append(m.hashes, hash)
+
+	return key.Id, nil
+}
+
+func (m *memApiKeys) GetApiKeys(context.Context) ([]entity.ApiKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]entity.ApiKey{}, m.keys...), nil
+}
+
+func (m *memApiKeys) GetApiKeyByHash(_ context.Context, hash string) (entity.ApiKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, h := range m.hashes {
+		if h == hash {
+			return m.keys[i], nil
+		}
+	}
+
+	return entity.ApiKey{}, usecase.ErrNoApiKey
+}
+
+func (m *memApiKeys) RevokeApiKey(_ context.Context, id int64, at time.Time) (entity.ApiKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id < 1 || id > int64(len(m.keys)) {
+		return entity.ApiKey{}, usecase.ErrNoApiKey
+	}
+
+	key := &m.keys[id-1]
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+	}
+
+	return *key, nil
+}
+
+func (m *memApiKeys) hash(id int64) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.hashes[id-1]
+}
+
+// expire переносит срок действия ключа в прошлое.
+func (m *memApiKeys) expire(id int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	past := time.Now().Add(-time.Minute)
+	m.keys[id-1].ExpiresAt = &past
+}
@@ -0,0 +1,199 @@
+package v1
+
+import (
+	"crypto/subtle"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// _principalKey - ключ в echo.Context, под которым лежит principal запроса.
+	_principalKey  = "principal"
+	_sessionCookie = "pinger_session"
+	_headerCsrf    = "X-CSRF-Token"
+)
+
+// AuthConfig - настройки входа в API.
+type AuthConfig struct {
+	// AnonymousRead даёт запросам без ключа и сессии право read.
+	AnonymousRead bool
+	// SecureCookie ставит cookie сессии с флагом Secure, нужен при работе по https.
+	SecureCookie bool
+	// OidcPostLoginUrl - куда вернуть браузер после входа через провайдера, обычно дашборд.
+	OidcPostLoginUrl string
+	// AgentCertIngest оставляет право ingest только пингерам с клиентским сертификатом.
+	AgentCertIngest bool
+	// AllowOrigins - origin дашборда из CORS, только с них браузер может открыть WebSocket-стрим.
+	AllowOrigins []string
+}
+
+// principal - кто выполняет запрос: пингер по сертификату, API-ключ, пользователь по cookie сессии или аноним.
+// Без scopes запрос не аутентифицирован.
+type principal struct {
+	name   string
+	scopes []string
+	// user, session и token заполнены у запросов по cookie сессии
+	user    *entity.User
+	session *entity.Session
+	token   string
+	// agent - пингер, подтверждённый клиентским сертификатом
+	agent bool
+	// ingestDenied - право ingest есть только у agent, см. AuthConfig.AgentCertIngest
+	ingestDenied bool
+}
+
+// authenticate определяет principal по клиентскому сертификату, заголовку Authorization: Bearer или cookie сессии.
+// Неверный ключ - ответ 401, неизвестная или истёкшая сессия считается отсутствующей.
+// Права проверяют requireScope и requireSession у роутов.
+func authenticate(k usecase.ApiKeys, u usecase.Users, l logger.Logger, auth AuthConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+
+			set := func(p principal) error {
+				p.ingestDenied = auth.AgentCertIngest && !p.agent
+				c.Set(_principalKey, p)
+
+				return next(c)
+			}
+
+			// цепочку сертификата уже проверил TLS-сервер по CA агентов
+			if state := c.Request().TLS; state != nil && len(state.VerifiedChains) > 0 {
+				return set(principal{
+					name:   "agent:" + agentName(state.PeerCertificates[0]),
+					scopes: []string{entity.ScopeIngest},
+					agent:  true,
+				})
+			}
+
+			if header := c.Request().Header.Get(echo.HeaderAuthorization); header != "" {
+				secret, ok := strings.CutPrefix(header, "Bearer ")
+				if !ok || secret == "" {
+					return unauthorized(c, l, usecase.ErrUnauthorized)
+				}
+
+				key, err := k.Authenticate(ctx, secret)
+				if err != nil {
+					return unauthorized(c, l, fmt.Errorf("http-v1-authenticate: %w", err))
+				}
+
+				return set(principal{name: "key:" + key.Name, scopes: key.Scopes})
+			}
+
+			if cookie, err := c.Cookie(_sessionCookie); err == nil && cookie.Value != "" {
+				user, session, err := u.Session(ctx, cookie.Value)
+				switch {
+				case err == nil:
+					return set(principal{
+						name:    user.Login,
+						scopes:  entity.RoleScopes(user.Role),
+						user:    &user,
+						session: &session,
+						token:   cookie.Value,
+					})
+				case !errors.Is(err, usecase.ErrUnauthorized):
+					return errorResponse(c, l, fmt.Errorf("http-v1-authenticate: %w", err))
+				}
+			}
+
+			if auth.AnonymousRead {
+				return set(principal{name: "anonymous", scopes: []string{entity.ScopeRead}})
+			}
+
+			return set(principal{})
+		}
+	}
+}
+
+// agentName - имя пингера из сертификата: CN или первое DNS-имя.
+func agentName(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" || len(cert.DNSNames) == 0 {
+		return cert.Subject.CommonName
+	}
+
+	return cert.DNSNames[0]
+}
+
+// requireScope пропускает запрос, только если у principal есть право scope.
+func requireScope(scope string, l logger.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			p := currentPrincipal(c)
+			if len(p.scopes) == 0 {
+				return unauthorized(c, l, usecase.ErrUnauthorized)
+			}
+
+			if err := checkCsrf(c, p); err != nil {
+				return errorResponse(c, l, err)
+			}
+
+			if scope == entity.ScopeIngest && p.ingestDenied {
+				return errorResponse(c, l, usecase.ErrForbidden.Field("scope", "requires a client certificate"))
+			}
+
+			if !entity.ScopesAllow(p.scopes, scope) {
+				return errorResponse(c, l, usecase.ErrForbidden.Field("scope", "requires %q", scope))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// requireSession пропускает только запросы по cookie сессии: выход, текущий пользователь, смена пароля.
+func requireSession(l logger.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			p := currentPrincipal(c)
+			if p.session == nil {
+				return unauthorized(c, l, usecase.ErrNoSession)
+			}
+
+			if err := checkCsrf(c, p); err != nil {
+				return errorResponse(c, l, err)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// checkCsrf сверяет X-CSRF-Token с токеном сессии у изменяющих запросов по cookie.
+// Запросам по API-ключу токен не нужен: браузер не подставляет заголовок Authorization сам.
+func checkCsrf(c echo.Context, p principal) error {
+	if p.session == nil {
+		return nil
+	}
+
+	switch c.Request().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+
+	token := c.Request().Header.Get(_headerCsrf)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(p.session.CsrfToken)) != 1 {
+		return usecase.ErrCsrf
+	}
+
+	return nil
+}
+
+func currentPrincipal(c echo.Context) principal {
+	p, _ := c.Get(_principalKey).(principal)
+
+	return p
+}
+
+func unauthorized(c echo.Context, l logger.Logger, err error) error {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+
+	return errorResponse(c, l, err)
+}
@@ -0,0 +1,365 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+// TestSessions - вход, CSRF, роли, смена пароля и выход через echo в процессе, с пользователями в памяти.
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+	users := usecase.NewUsers(newMemUserRepo(), time.Hour)
+
+	for _, u := range []struct{ login, role string }{
+		{"root", entity.RoleAdmin},
+		{"viewer", entity.RoleViewer},
+		{"operator", entity.RoleOperator},
+	} {
+		if _, err := users.CreateUser(ctx, entity.User{Login: u.login, Role: u.role}, u.login+"-password"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e := echo.New()
+	err := NewRouter(e, logger.NewLogger(), nil, nil, nil, nil, usecase.NewApiKeys(nil), users, nil, &memSilences{}, nil, AuthConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &testSession{t: t, e: e}
+
+	s.expect(http.MethodGet, "/v1/silences", "", http.StatusUnauthorized, "unauthorized")
+	s.expect(http.MethodPost, "/v1/auth/login", `{"login": "viewer", "password": "wrong-password"}`, http.StatusUnauthorized, "invalid_credentials")
+	s.expect(http.MethodPost, "/v1/auth/login", `{"login": "nobody", "password": "wrong-password"}`, http.StatusUnauthorized, "invalid_credentials")
+
+	s.login("viewer", "viewer-password")
+	s.expect(http.MethodGet, "/v1/auth/me", "", http.StatusOK, `"login":"viewer"`)
+	s.expect(http.MethodGet, "/v1/silences", "", http.StatusOK, "[]")
+	s.expect(http.MethodPost, "/v1/silences", silenceBody(), http.StatusForbidden, "forbidden")
+
+	s.login("operator", "operator-password")
+	csrf := s.csrf
+	s.csrf = ""
+	s.expect(http.MethodPost, "/v1/silences", silenceBody(), http.StatusForbidden, "csrf_failed")
+	s.csrf = csrf
+	s.expect(http.MethodPost, "/v1/silences", silenceBody(), http.StatusCreated, `"created_by":"operator"`)
+	s.expect(http.MethodGet, "/v1/users", "", http.StatusForbidden, "forbidden")
+
+	s.expect(http.MethodPut, "/v1/auth/password", `{"current_password": "wrong-password", "new_password": "operator-new"}`,
+		http.StatusBadRequest, "current_password")
+	s.expect(http.MethodPut, "/v1/auth/password", `{"current_password": "operator-password", "new_password": "operator-new"}`,
+		http.StatusNoContent, "")
+	s.expect(http.MethodGet, "/v1/auth/me", "", http.StatusOK, `"login":"operator"`)
+	s.expect(http.MethodPost, "/v1/auth/logout", "", http.StatusNoContent, "")
+	s.expect(http.MethodGet, "/v1/auth/me", "", http.StatusUnauthorized, "session_required")
+	s.expect(http.MethodPost, "/v1/auth/login", `{"login": "operator", "password": "operator-password"}`, http.StatusUnauthorized, "invalid_credentials")
+
+	s.login("root", "root-password")
+	s.expect(http.MethodGet, "/v1/users", "", http.StatusOK, `"login":"operator"`)
+	s.expect(http.MethodPost, "/v1/users", `{"login": "new", "password": "new-password", "role": "viewer"}`, http.StatusCreated, `"role":"viewer"`)
+	s.expect(http.MethodPost, "/v1/users", `{"login": "new", "password": "new-password", "role": "viewer"}`, http.StatusConflict, "user_exists")
+	s.expect(http.MethodPut, "/v1/users/1", `{"role": "viewer"}`, http.StatusConflict, "last_admin")
+	s.expect(http.MethodDelete, "/v1/users/4", "", http.StatusNoContent, "")
+}
+
+func silenceBody() string {
+	return `{"ip": "10.0.0.1", "until": "` + time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `"}`
+}
+
+// testSession - клиент с cookie сессии и CSRF-токеном последнего входа.
+type testSession struct {
+	t      *testing.T
+	e      *echo.Echo
+	cookie *http.Cookie
+	csrf   string
+}
+
+func (s *testSession) do(method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if s.cookie != nil {
+		req.AddCookie(s.cookie)
+	}
+	if s.csrf != "" {
+		req.Header.Set(_headerCsrf, s.csrf)
+	}
+
+	rec := httptest.NewRecorder()
+	s.e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func (s *testSession) expect(method, target, body string, status int, contains string) {
+	s.t.Helper()
+
+	rec := s.do(method, target, body)
+	if rec.Code != status || !strings.Contains(rec.Body.String(), contains) {
+		s.t.Errorf("%s %s: got %d %s, want %d with %q", method, target, rec.Code, rec.Body.String(), status, contains)
+	}
+}
+
+func (s *testSession) login(login, password string) {
+	s.t.Helper()
+
+	s.cookie, s.csrf = nil, ""
+
+	rec := s.do(http.MethodPost, "/v1/auth/login", `{"login": "`+login+`", "password": "`+password+`"}`)
+	if rec.Code != http.StatusOK {
+		s.t.Fatalf("login %s: got %d %s", login, rec.Code, rec.Body.String())
+	}
+
+	var res struct {
+		CsrfToken string `json:"csrf_token"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		s.t.Fatal(err)
+	}
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != _sessionCookie || !cookies[0].HttpOnly {
+		s.t.Fatalf("login %s: unexpected cookies %v", login, cookies)
+	}
+
+	s.cookie, s.csrf = cookies[0], res.CsrfToken
+}
+
+type memSilences struct {
+	silences []entity.Silence
+}
+
+func (m *memSilences) AddSilence(_ context.Context, silence entity.Silence) (entity.Silence, error) {
+	silence.Id = int64(len(m.silences) + 1)
+	m.silences = append(m.silences, silence)
+
+	return silence, nil
+}
+
+func (m *memSilences) Silences(context.Context) ([]entity.Silence, error) {
+	return append([]entity.Silence{}, m.silences...), nil
+}
+
+func (m *memSilences) DeleteSilence(context.Context, int64) error {
+	return nil
+}
+
+type memUser struct {
+	user    entity.User
+	hash    string
+	subject string
+}
+
+type memSession struct {
+	hash    string
+	session entity.Session
+}
+
+// memUserRepo - usecase.UserRepo в памяти.
+type memUserRepo struct {
+	mu       sync.Mutex
+	users    []memUser
+	sessions []memSession
+}
+
+func newMemUserRepo() *memUserRepo {
+	return &memUserRepo{}
+}
+
+func (m *memUserRepo) AddUser(_ context.Context, user entity.User, hash string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, u := range m.users {
+		if u.user.Login == user.Login {
+			return 0, usecase.ErrUserExists
+		}
+	}
+
+	user.Id = int64(len(m.users) + 1)
+	m.users = append(m.users, memUser{user: user, hash: hash})
+
+	return user.Id, nil
+}
+
+func (m *memUserRepo) GetUsers(context.Context) ([]entity.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	users := make([]entity.User, 0, len(m.users))
+	for _, u := range m.users {
+		if u.user.Id != 0 {
+			users = append(users, u.user)
+		}
+	}
+
+	return users, nil
+}
+
+func (m *memUserRepo) find(match func(entity.User) bool) (*memUser, error) {
+	for i := range m.users {
+		if m.users[i].user.Id != 0 && match(m.users[i].user) {
+			return &m.users[i], nil
+		}
+	}
+
+	return nil, usecase.ErrNoUser
+}
+
+func (m *memUserRepo) GetUser(_ context.Context, id int64) (entity.User, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, err := m.find(func(u entity.User) bool { return u.Id == id })
+	if err != nil {
+		return entity.User{}, "", err
+	}
+
+	return u.user, u.hash, nil
+}
+
+func (m *memUserRepo) GetUserByLogin(_ context.Context, login string) (entity.User, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, err := m.find(func(u entity.User) bool { return u.Login == login })
+	if err != nil {
+		return entity.User{}, "", err
+	}
+
+	return u.user, u.hash, nil
+}
+
+func (m *memUserRepo) UpdateUser(_ context.Context, user entity.User, hash string) (entity.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, err := m.find(func(u entity.User) bool { return u.Id == user.Id })
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if user.Role != "" {
+		u.user.Role = user.Role
+	}
+	if hash != "" {
+		u.hash = hash
+	}
+
+	return u.user, nil
+}
+
+// DeleteUser оставляет пустую запись, чтобы id следующих пользователей не повторялись.
+func (m *memUserRepo) DeleteUser(_ context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, err := m.find(func(u entity.User) bool { return u.Id == id })
+	if err != nil {
+		return err
+	}
+
+	u.user.Id = 0
+
+	return nil
+}
+
+func (m *memUserRepo) CountAdmins(context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, u := range m.users {
+		if u.user.Id != 0 && u.user.Role == entity.RoleAdmin {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (m *memUserRepo) UpsertOidcUser(_ context.Context, user entity.User, subject string) (entity.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, err := m.find(func(u entity.User) bool { return u.Login == user.Login })
+	if err == nil && m.users[u.user.Id-1].subject != subject {
+		return entity.User{}, usecase.ErrUserExists
+	}
+
+	if u, err = m.find(func(u entity.User) bool { return m.users[u.Id-1].subject == subject }); err == nil {
+		u.user.Login, u.user.Role = user.Login, user.Role
+		return u.user, nil
+	}
+
+	user.Id = int64(len(m.users) + 1)
+	m.users = append(m.users, memUser{user: user, subject: subject})
+
+	return user, nil
+}
+
+func (m *memUserRepo) AddSession(_ context.Context, hash string, session entity.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sessions = append(m.sessions, memSession{hash, session})
+
+	return nil
+}
+
+func (m *memUserRepo) GetSession(_ context.Context, hash string) (entity.User, entity.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.sessions {
+		if s.hash != hash {
+			continue
+		}
+
+		u, err := m.find(func(u entity.User) bool { return u.Id == s.session.UserId })
+		if err != nil {
+			break
+		}
+
+		return u.user, s.session, nil
+	}
+
+	return entity.User{}, entity.Session{}, usecase.ErrNoSession
+}
+
+func (m *memUserRepo) DeleteSession(_ context.Context, hash string) error {
+	return m.deleteSessions(func(s memSession) bool { return s.hash == hash })
+}
+
+func (m *memUserRepo) DeleteUserSessions(_ context.Context, userId int64, exceptHash string) error {
+	return m.deleteSessions(func(s memSession) bool { return s.session.UserId == userId && s.hash != exceptHash })
+}
+
+func (m *memUserRepo) DeleteSessionsBefore(_ context.Context, before time.Time) (int64, error) {
+	return 0, m.deleteSessions(func(s memSession) bool { return s.session.ExpiresAt.Before(before) })
+}
+
+func (m *memUserRepo) deleteSessions(match func(memSession) bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.sessions[:0]
+	for _, s := range m.sessions {
+		if !match(s) {
+			kept = append(kept, s)
+		}
+	}
+	m.sessions = kept
+
+	return nil
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/k1v4/Pinger/backend/pkg/logger"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	"net/url"
+	"strconv"
 )
 
 type conatainerRoutes struct {
@@ -18,75 +20,78 @@ type conatainerRoutes struct {
 
 func newContainerRoutes(handler *echo.Group, t usecase.Container, l logger.Logger) {
 	r := &conatainerRoutes{t, l}
+	read := requireScope(entity.ScopeRead, l)
+	ingest := requireScope(entity.ScopeIngest, l)
+	admin := requireScope(entity.ScopeAdmin, l)
 
 	// группа роутов для /v1/containers
 	h := handler.Group("/containers")
 	{
-		// GET /v1/containers
-		h.GET("/", r.AllContainers)
+		// GET /v1/containers?status=down&sort=latency&order=desc&limit=100&cursor=...
+		h.GET("/", r.AllContainers, read)
 
 		// GET /v1/containers/{ip}
-		h.GET("/:ip", r.Container)
+		h.GET("/:ip", r.Container, read)
 
-		// POST /v1/containers
-		h.POST("/:ip", r.CheckPingContainer)
+		// POST /v1/containers/{ip} - результат пинга от пингера, создаёт контейнер при первом результате
+		h.POST("/:ip", r.CheckPingContainer, ingest)
 
 		// PUT /v1/containers/{ip}
-		h.PUT("/:ip", r.UpdateContainer)
+		h.PUT("/:ip", r.UpdateContainer, admin)
 
 		// DELETE /v1/containers/{ip}
-		h.DELETE("/:ip", r.DeleteContainer)
+		h.DELETE("/:ip", r.DeleteContainer, admin)
 
 	}
 }
 
 func (tr *conatainerRoutes) CheckPingContainer(c echo.Context) error {
-	ip := c.Param("ip")
+	ip := ipParam(c)
 	ctx := c.Request().Context()
 
 	u := new(dto.DtoPingContainer)
 	if err := c.Bind(u); err != nil {
-		tr.l.Error(ctx, fmt.Sprintf("http-v1-CheckPingContainer: %s", err))
-		errorResponse(c, http.StatusBadRequest, "bad request")
-
-		return fmt.Errorf("http-v1-CheckPingContainer: %w", err)
+		return errorResponse(c, tr.l, fmt.Errorf("http-v1-CheckPingContainer: %w", malformedBody(err)))
 	}
 
+	reporter := currentPrincipal(c).name
+
 	getContainer, err := tr.t.Container(ctx, ip)
 	if err != nil {
 		if errors.Is(err, usecase.ErrNoIp) {
 			ip, err = tr.t.NewContainer(ctx, entity.Container{
 				IpAddr:         ip,
 				PingTime:       u.PingTime,
+				IsSuccessful:   u.IsSuccessful,
 				LastSuccessful: u.LastSuccessful,
+				Name:           u.Name,
+				Image:          u.Image,
+				Labels:         u.Labels,
+				ReportedBy:     reporter,
 			})
 			if err != nil {
-				tr.l.Error(ctx, fmt.Sprintf("http-v1-CheckPingContainer: %s", err))
-				errorResponse(c, http.StatusInternalServerError, "database problems")
-
-				return fmt.Errorf("http-v1-CheckPingContainer: %w", err)
+				return errorResponse(c, tr.l, fmt.Errorf("http-v1-CheckPingContainer: %w", err))
 			}
 
 			return c.JSON(http.StatusOK, dto.NewContainerResponse{Ip: ip})
 		}
 
-		tr.l.Error(ctx, fmt.Sprintf("http-v1-CheckPingContainer: %s", err))
-		errorResponse(c, http.StatusInternalServerError, "database problems")
-
-		return fmt.Errorf("http-v1-CheckPingContainer: %w", err)
+		return errorResponse(c, tr.l, fmt.Errorf("http-v1-CheckPingContainer: %w", err))
 	}
 
 	if !u.IsSuccessful {
 		container, err2 := tr.t.UpdateContainer(ctx, entity.Container{
 			IpAddr:         ip,
 			PingTime:       u.PingTime,
+			IsSuccessful:   false,
 			LastSuccessful: getContainer.LastSuccessful,
+			Name:           u.Name,
+			Image:          u.Image,
+			Labels:         u.Labels,
+			ReportedBy:     reporter,
 		})
 		if err2 != nil {
-			tr.l.Error(ctx, fmt.Sprintf("http-v1-CheckPingContainer: %s", err2))
-			errorResponse(c, http.StatusInternalServerError, "database problems")
-
-			return fmt.Errorf("http-v1-CheckPingContainer: %w", err2)
+			return errorResponse(c, tr.l, fmt.Errorf("http-v1-CheckPingContainer: %w", err2))
 		}
 
 		return c.JSON(http.StatusOK, container)
@@ -95,57 +100,82 @@ func (tr *conatainerRoutes) CheckPingContainer(c echo.Context) error {
 	updContainer, err := tr.t.UpdateContainer(ctx, entity.Container{
 		IpAddr:         ip,
 		PingTime:       u.PingTime,
+		IsSuccessful:   true,
 		LastSuccessful: u.LastSuccessful,
+		Name:           u.Name,
+		Image:          u.Image,
+		Labels:         u.Labels,
+		ReportedBy:     reporter,
 	})
 	if err != nil {
-		tr.l.Error(ctx, fmt.Sprintf("http-v1-CheckPingContainer: %s", err))
-		errorResponse(c, http.StatusInternalServerError, "database problems")
-
-		return fmt.Errorf("http-v1-CheckPingContainer: %w", err)
+		return errorResponse(c, tr.l, fmt.Errorf("http-v1-CheckPingContainer: %w", err))
 	}
 
 	return c.JSON(http.StatusOK, dto.NewContainerResponse{Ip: updContainer.IpAddr})
 }
 
 func (tr *conatainerRoutes) Container(c echo.Context) error {
-	ip := c.Param("ip")
+	ip := ipParam(c)
 	ctx := c.Request().Context()
 
 	container, err := tr.t.Container(ctx, ip)
 	if err != nil {
-		tr.l.Error(ctx, fmt.Sprintf("http-v1-Container: %s", err))
-		errorResponse(c, http.StatusInternalServerError, "database problems")
-
-		return fmt.Errorf("http-v1-Container: %w", err)
+		return errorResponse(c, tr.l, fmt.Errorf("http-v1-Container: %w", err))
 	}
 
 	return c.JSON(http.StatusOK, container)
 }
 
+// AllContainers отдаёт страницу списка контейнеров.
+// Фильтры: status=up|down, min_latency и max_latency в мс, selector=env=prod,tier!=db, image=nginx:*, search по имени.
+// Сортировка: sort=ip|latency|last_successful|status|name, order=asc|desc. Страницы: limit и cursor из next_cursor.
 func (tr *conatainerRoutes) AllContainers(c echo.Context) error {
 	ctx := c.Request().Context()
 
-	containers, err := tr.t.AllContainers(ctx)
+	query := entity.ContainerQuery{
+		Status:     c.QueryParam("status"),
+		Image:      c.QueryParam("image"),
+		Name:       c.QueryParam("search"),
+		Sort:       c.QueryParam("sort"),
+		Descending: c.QueryParam("order") == "desc",
+		Cursor:     c.QueryParam("cursor"),
+	}
+
+	var err error
+	if query.Labels, err = entity.ParseLabelSelector(c.QueryParam("selector")); err != nil {
+		return errorResponse(c, tr.l, fmt.Errorf("http-v1-AllContainers: %w", usecase.ErrBadQuery.Field("selector", "%s", err)))
+	}
+
+	if query.MinLatency, err = intQuery(c, "min_latency"); err != nil {
+		return errorResponse(c, tr.l, fmt.Errorf("http-v1-AllContainers: %w", err))
+	}
+	if query.MaxLatency, err = intQuery(c, "max_latency"); err != nil {
+		return errorResponse(c, tr.l, fmt.Errorf("http-v1-AllContainers: %w", err))
+	}
+
+	limit, err := intQuery(c, "limit")
 	if err != nil {
-		tr.l.Error(ctx, fmt.Sprintf("http-v1-AllContainers: %s", err))
-		errorResponse(c, http.StatusInternalServerError, "database problems")
+		return errorResponse(c, tr.l, fmt.Errorf("http-v1-AllContainers: %w", err))
+	}
+	if limit != nil {
+		query.Limit = *limit
+	}
 
-		return fmt.Errorf("http-v1-AllContainers: %w", err)
+	page, err := tr.t.ListContainers(ctx, query)
+	if err != nil {
+		return errorResponse(c, tr.l, fmt.Errorf("http-v1-AllContainers: %w", err))
 	}
 
-	return c.JSON(http.StatusOK, containers)
+	return c.JSON(http.StatusOK, page)
 }
 
 func (tr *conatainerRoutes) NewContainer(c echo.Context) error {
-	ip := c.Param("ip")
+	ip := ipParam(c)
 	ctx := c.Request().Context()
 
 	u := new(dto.AddContainerRequest)
 	if err := c.Bind(u); err != nil {
-		tr.l.Error(ctx, fmt.Sprintf("http-v1-NewContainer: %s", err))
-		errorResponse(c, http.StatusBadRequest, "bad request")
-
-		return fmt.Errorf("http-v1-NewContainer: %w", err)
+		return errorResponse(c, tr.l, fmt.Errorf("http-v1-NewContainer: %w", malformedBody(err)))
 	}
 
 	ip, err := tr.t.NewContainer(ctx, entity.Container{
@@ -154,39 +184,31 @@ func (tr *conatainerRoutes) NewContainer(c echo.Context) error {
 		LastSuccessful: u.LastSuccessful,
 	})
 	if err != nil {
-		tr.l.Error(ctx, fmt.Sprintf("http-v1-NewContainer: %s", err))
-		errorResponse(c, http.StatusInternalServerError, "database problems")
-
-		return fmt.Errorf("http-v1-NewContainer: %w", err)
+		return errorResponse(c, tr.l, fmt.Errorf("http-v1-NewContainer: %w", err))
 	}
 
 	return c.JSON(http.StatusOK, dto.NewContainerResponse{Ip: ip})
 }
 
 func (tr *conatainerRoutes) UpdateContainer(c echo.Context) error {
-	ip := c.Param("ip")
+	ip := ipParam(c)
 	ctx := c.Request().Context()
 
 	u := new(dto.UpdateContainerRequest)
 	if err := c.Bind(u); err != nil {
-		tr.l.Error(ctx, fmt.Sprintf("http-v1-UpdateContainer: %s", err))
-		errorResponse(c, http.StatusBadRequest, "bad request")
-
-		return fmt.Errorf("http-v1-UpdateContainer: %w", err)
+		return errorResponse(c, tr.l, fmt.Errorf("http-v1-UpdateContainer: %w", malformedBody(err)))
 	}
 
 	container := entity.Container{
 		IpAddr:         ip,
 		PingTime:       u.PingTime,
+		IsSuccessful:   u.IsSuccessful == nil || *u.IsSuccessful,
 		LastSuccessful: u.LastSuccessful,
 	}
 
 	container, err := tr.t.UpdateContainer(ctx, container)
 	if err != nil {
-		tr.l.Error(ctx, fmt.Sprintf("http-v1-UpdateContainer: %s", err))
-		errorResponse(c, http.StatusInternalServerError, "database problems")
-
-		return fmt.Errorf("http-v1-UpdateContainer: %w", err)
+		return errorResponse(c, tr.l, fmt.Errorf("http-v1-UpdateContainer: %w", err))
 	}
 
 	return c.JSON(http.StatusOK, container)
@@ -194,15 +216,37 @@ func (tr *conatainerRoutes) UpdateContainer(c echo.Context) error {
 
 func (tr *conatainerRoutes) DeleteContainer(c echo.Context) error {
 	ctx := c.Request().Context()
-	ip := c.Param("ip")
+	ip := ipParam(c)
 
 	err := tr.t.DeleteContainer(ctx, ip)
 	if err != nil {
-		tr.l.Error(ctx, fmt.Sprintf("http-v1-DeleteContainer: %s", err))
-		errorResponse(c, http.StatusInternalServerError, "database problems")
-
-		return fmt.Errorf("http-v1-DeleteContainer: %w", err)
+		return errorResponse(c, tr.l, fmt.Errorf("http-v1-DeleteContainer: %w", err))
 	}
 
 	return c.JSON(http.StatusOK, dto.DeleteContainerResponse{IsSuccess: true})
 }
+
+// intQuery - необязательный целочисленный параметр запроса, nil если его нет.
+func intQuery(c echo.Context, name string) (*int, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, usecase.ErrBadQuery.Field(name, "must be an integer")
+	}
+
+	return &n, nil
+}
+
+// ipParam - ip из пути, IPv6 может прийти с экранированными ':'.
+func ipParam(c echo.Context) string {
+	ip, err := url.PathUnescape(c.Param("ip"))
+	if err != nil {
+		return c.Param("ip")
+	}
+
+	return ip
+}
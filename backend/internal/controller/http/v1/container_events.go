@@ -0,0 +1,112 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/controller/dto"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+type containerEventRoutes struct {
+	e usecase.ContainerEvents
+	l logger.Logger
+}
+
+func newContainerEventRoutes(handler *echo.Group, e usecase.ContainerEvents, l logger.Logger) {
+	r := &containerEventRoutes{e, l}
+
+	// POST /v1/containers/{ip или id}/events - по id, пока ip контейнера неизвестен
+	handler.POST("/containers/:ip/events", r.AddEvent, requireScope(entity.ScopeIngest, l))
+
+	// GET /v1/containers/{ip или id}/events?type=died&order=asc&limit=100&cursor=...
+	handler.GET("/containers/:ip/events", r.Events, requireScope(entity.ScopeRead, l))
+}
+
+func (cr *containerEventRoutes) AddEvent(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	u := new(dto.AddContainerEventRequest)
+	if err := c.Bind(u); err != nil {
+		return errorResponse(c, cr.l, fmt.Errorf("http-v1-AddEvent: %w", malformedBody(err)))
+	}
+
+	event := entity.ContainerEvent{
+		Type:          u.Type,
+		ContainerId:   u.ContainerId,
+		ContainerName: u.ContainerName,
+		Image:         u.Image,
+		DockerHost:    u.DockerHost,
+		ExitCode:      u.ExitCode,
+		RestartCount:  u.RestartCount,
+		Message:       u.Message,
+		Time:          u.Time,
+		ReportedBy:    currentPrincipal(c).name,
+	}
+
+	// created приходит до подключения контейнера к сетям, такое событие хранится без ip
+	if id := ipParam(c); isIp(id) {
+		event.IpAddr = id
+	} else if id != u.ContainerId {
+		return errorResponse(c, cr.l, fmt.Errorf("http-v1-AddEvent: %w",
+			usecase.ErrValidation.Field("container_id", "must match the container id in the path")))
+	}
+
+	event, err := cr.e.AddEvent(ctx, event)
+	if err != nil {
+		return errorResponse(c, cr.l, fmt.Errorf("http-v1-AddEvent: %w", err))
+	}
+
+	return c.JSON(http.StatusCreated, event)
+}
+
+// Events отдаёт хронологию контейнера по ip или id (префиксу id).
+// Параметры: type=died,restarted, from и to в RFC 3339, order=asc|desc, limit, cursor из next_cursor.
+func (cr *containerEventRoutes) Events(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	query := entity.ContainerEventQuery{
+		Types:     splitQuery(c.QueryParam("type")),
+		Ascending: c.QueryParam("order") == "asc",
+		Cursor:    c.QueryParam("cursor"),
+	}
+
+	if id := ipParam(c); isIp(id) {
+		query.IpAddr = id
+	} else {
+		query.ContainerId = id
+	}
+
+	var err error
+	if query.From, err = timeQuery(c, "from", time.Time{}); err != nil {
+		return errorResponse(c, cr.l, fmt.Errorf("http-v1-Events: %w", err))
+	}
+	if query.To, err = timeQuery(c, "to", time.Time{}); err != nil {
+		return errorResponse(c, cr.l, fmt.Errorf("http-v1-Events: %w", err))
+	}
+
+	limit, err := intQuery(c, "limit")
+	if err != nil {
+		return errorResponse(c, cr.l, fmt.Errorf("http-v1-Events: %w", err))
+	}
+	if limit != nil {
+		query.Limit = *limit
+	}
+
+	page, err := cr.e.Events(ctx, query)
+	if err != nil {
+		return errorResponse(c, cr.l, fmt.Errorf("http-v1-Events: %w", err))
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// isIp отличает ip в пути от id контейнера: в id нет ни точек, ни двоеточий.
+func isIp(s string) bool {
+	return strings.ContainsAny(s, ".:")
+}
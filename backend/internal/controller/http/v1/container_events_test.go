@@ -0,0 +1,116 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+const _testContainerId = "4f2a9c0d1e7b6a5f4f2a9c0d1e7b6a5f4f2a9c0d1e7b6a5f4f2a9c0d1e7b6a5f"
+
+// TestContainerEvents - событие без ip сохраняется по id контейнера и видно в хронологии по id,
+// а ошибка рассылки в стрим после записи не превращается в ошибку ответа.
+func TestContainerEvents(t *testing.T) {
+	keys := usecase.NewApiKeys(nil, usecase.StaticApiKey{Name: "test", Key: "pk_test", Scopes: []string{entity.ScopeAdmin}})
+	events := usecase.NewContainerEvents(&memContainerEvents{}, failingPublisher{}, nopEventObserver{}, logger.NewLogger())
+
+	e := echo.New()
+	if err := NewRouter(e, logger.NewLogger(), nil, nil, nil, events, keys, nil, nil, nil, nil, AuthConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(method, target, body string, status int, want string) {
+		t.Helper()
+
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer pk_test")
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		if rec.Code != status || !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("%s %s: got %d %s, want %d %s", method, target, rec.Code, rec.Body.String(), status, want)
+		}
+	}
+
+	created := `{"type": "created", "container_id": "` + _testContainerId + `", "container_name": "web"}`
+	started := `{"type": "started", "container_id": "` + _testContainerId + `"}`
+
+	do(http.MethodPost, "/v1/containers/"+_testContainerId+"/events", created, http.StatusCreated, `"reported_by":"key:test"`)
+	do(http.MethodPost, "/v1/containers/2001:db8::1/events", started, http.StatusCreated, `"ip":"2001:db8::1"`)
+
+	// id в пути должен совпадать с id в теле
+	do(http.MethodPost, "/v1/containers/abcdef012345/events", created, http.StatusBadRequest, "container_id")
+
+	do(http.MethodGet, "/v1/containers/4f2a9c0d/events?order=asc", "", http.StatusOK,
+		`"type":"created","container_id":"`+_testContainerId)
+	do(http.MethodGet, "/v1/containers/4f2a9c0d/events?order=asc", "", http.StatusOK, `"ip":"2001:db8::1","type":"started"`)
+	do(http.MethodGet, "/v1/containers/2001:db8::1/events", "", http.StatusOK, `"type":"started"`)
+	// created пришло до ip, но видно и в хронологии по ip
+	do(http.MethodGet, "/v1/containers/2001:db8::1/events", "", http.StatusOK, `"type":"created"`)
+	do(http.MethodGet, "/v1/containers/2001:db8::2/events", "", http.StatusOK, `"events":[]`)
+}
+
+// memContainerEvents хранит события в памяти, выборка - по ip (с событиями тех же контейнеров без ip)
+// или префиксу id, без пагинации.
+type memContainerEvents struct {
+	mu     sync.Mutex
+	events []entity.ContainerEvent
+}
+
+func (m *memContainerEvents) AddEvent(_ context.Context, event entity.ContainerEvent) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	event.Id = int64(len(m.events) + 1)
+	m.events = append(m.events, event)
+
+	return event.Id, nil
+}
+
+func (m *memContainerEvents) GetEvents(_ context.Context, query entity.ContainerEventQuery) (entity.ContainerEventPage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// как в ContainerEventRepo: по ip отдаются и события тех же контейнеров без ip
+	ids := make(map[string]bool)
+	for _, event := range m.events {
+		if query.IpAddr != "" && event.IpAddr == query.IpAddr {
+			ids[event.ContainerId] = true
+		}
+	}
+
+	page := entity.ContainerEventPage{Events: []entity.ContainerEvent{}}
+	for _, event := range m.events {
+		if query.IpAddr != "" && event.IpAddr != query.IpAddr && !ids[event.ContainerId] {
+			continue
+		}
+		if query.IpAddr == "" && !strings.HasPrefix(event.ContainerId, query.ContainerId) {
+			continue
+		}
+
+		page.Events = append(page.Events, event)
+	}
+
+	return page, nil
+}
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(context.Context, entity.Event) error {
+	return errors.New("notify: connection reset")
+}
+
+type nopEventObserver struct{}
+
+func (nopEventObserver) ObserveContainerEvent(entity.ContainerEvent) {}
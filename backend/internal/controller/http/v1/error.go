@@ -1,11 +1,96 @@
 package v1
 
-import "github.com/labstack/echo/v4"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
 
 type response struct {
-	Error string `json:"error" example:"message"`
+	Error     string               `json:"error" example:"message"`
+	Code      string               `json:"code" example:"bad_ip"`
+	Details   []usecase.FieldError `json:"details,omitempty"`
+	RequestId string               `json:"request_id,omitempty"`
+}
+
+// _errMalformedBody - тело запроса не разбирается в ожидаемый JSON.
+var _errMalformedBody = &usecase.Error{Kind: usecase.KindValidation, Code: "malformed_body", Message: "malformed request body"}
+
+// errorResponse отвечает ошибкой err и возвращает её, чтобы она попала в лог запроса.
+// Код ответа, code и details берутся из *usecase.Error, остальные ошибки
+// пишутся в лог, а клиент получает 500 без подробностей.
+func errorResponse(c echo.Context, l logger.Logger, err error) error {
+	res := response{RequestId: requestId(c)}
+	status := http.StatusInternalServerError
+
+	var ue *usecase.Error
+	if errors.As(err, &ue) {
+		status = errorStatus(ue.Kind)
+		res.Error, res.Code, res.Details = ue.Message, ue.Code, ue.Fields
+	} else {
+		l.Error(c.Request().Context(), err.Error())
+		res.Error, res.Code = "internal error", "internal"
+	}
+
+	_ = c.JSON(status, res)
+
+	return err
+}
+
+func errorStatus(kind usecase.ErrorKind) int {
+	switch kind {
+	case usecase.KindValidation:
+		return http.StatusBadRequest
+	case usecase.KindNotFound:
+		return http.StatusNotFound
+	case usecase.KindConflict:
+		return http.StatusConflict
+	case usecase.KindUnauthorized:
+		return http.StatusUnauthorized
+	case usecase.KindForbidden:
+		return http.StatusForbidden
+	case usecase.KindUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// malformedBody - ошибка разбора тела от c.Bind.
+func malformedBody(err error) error {
+	msg := err.Error()
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		msg = fmt.Sprint(he.Message)
+	}
+
+	return _errMalformedBody.Field("body", "%s", msg)
+}
+
+// httpErrorHandler отвечает в том же формате на ошибки самого echo: неизвестный путь или метод, паника.
+func httpErrorHandler(l logger.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		var he *echo.HTTPError
+		if !errors.As(err, &he) {
+			_ = errorResponse(c, l, err)
+			return
+		}
+
+		code := strings.ToLower(strings.ReplaceAll(http.StatusText(he.Code), " ", "_"))
+		_ = c.JSON(he.Code, response{Error: fmt.Sprint(he.Message), Code: code, RequestId: requestId(c)})
+	}
 }
 
-func errorResponse(c echo.Context, code int, msg string) error {
-	return c.JSON(code, response{Error: msg})
+func requestId(c echo.Context) string {
+	return c.Response().Header().Get(echo.HeaderXRequestID)
 }
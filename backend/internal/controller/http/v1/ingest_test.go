@@ -0,0 +1,139 @@
+package v1
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+// agentCert выпускает CA агентов и клиентский сертификат пингера с CN name.
+func agentCert(t *testing.T, name string) (*x509.CertPool, tls.Certificate) {
+	t.Helper()
+
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return key
+	}
+
+	caKey, key := newKey(), newKey()
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "pinger-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	leaf := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	caDer, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, leaf, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	return pool, tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// TestAgentIngest - с AgentCertIngest результаты принимаются только от пингеров с клиентским сертификатом:
+// ключи с ingest и admin получают 403, а отправитель события - agent:<CN> из сертификата.
+func TestAgentIngest(t *testing.T) {
+	keys := usecase.NewApiKeys(nil,
+		usecase.StaticApiKey{Name: "ingest", Key: "pk_ingest", Scopes: []string{entity.ScopeIngest}},
+		usecase.StaticApiKey{Name: "admin", Key: "pk_admin", Scopes: []string{entity.ScopeAdmin}},
+	)
+	events := usecase.NewContainerEvents(&memContainerEvents{}, failingPublisher{}, nopEventObserver{}, logger.NewLogger())
+
+	e := echo.New()
+	if err := NewRouter(e, logger.NewLogger(), nil, nil, nil, events, keys, nil, nil, nil, nil, AuthConfig{AgentCertIngest: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	pool, cert := agentCert(t, "pinger-1")
+
+	srv := httptest.NewUnstartedServer(e)
+	srv.TLS = &tls.Config{ClientCAs: pool, ClientAuth: tls.VerifyClientCertIfGiven}
+	srv.StartTLS()
+	defer srv.Close()
+
+	anonymous := srv.Client()
+
+	transport := anonymous.Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	agent := &http.Client{Transport: transport}
+
+	post := func(client *http.Client, token string, status int, want string) {
+		t.Helper()
+
+		body := `{"type": "started", "container_id": "` + _testContainerId + `"}`
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/v1/containers/10.0.0.1/events", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+
+		got, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res.StatusCode != status || !strings.Contains(string(got), want) {
+			t.Errorf("token %q: got %d %s, want %d %s", token, res.StatusCode, got, status, want)
+		}
+	}
+
+	post(anonymous, "pk_ingest", http.StatusForbidden, "requires a client certificate")
+	post(anonymous, "pk_admin", http.StatusForbidden, "requires a client certificate")
+	post(anonymous, "", http.StatusUnauthorized, "unauthorized")
+
+	// сертификат важнее ключа в заголовке
+	post(agent, "", http.StatusCreated, `"reported_by":"agent:pinger-1"`)
+	post(agent, "pk_ingest", http.StatusCreated, `"reported_by":"agent:pinger-1"`)
+}
@@ -0,0 +1,107 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// _oidcCookie хранит state, nonce и PKCE verifier начатого входа до возврата с провайдера.
+	_oidcCookie  = "pinger_oidc"
+	_oidcPath    = "/v1/auth/oidc"
+	_oidcFlowTTL = 10 * time.Minute
+)
+
+type oidcRoutes struct {
+	o            usecase.Oidc
+	l            logger.Logger
+	secureCookie bool
+	postLoginUrl string
+}
+
+func newOidcRoutes(handler *echo.Group, o usecase.Oidc, l logger.Logger, auth AuthConfig) {
+	r := &oidcRoutes{o, l, auth.SecureCookie, auth.OidcPostLoginUrl}
+
+	// группа роутов для /v1/auth/oidc
+	h := handler.Group("/auth/oidc")
+	{
+		// GET /v1/auth/oidc/login - перенаправляет на страницу входа провайдера
+		h.GET("/login", r.Login)
+
+		// GET /v1/auth/oidc/callback - возврат с провайдера, открывает сессию
+		h.GET("/callback", r.Callback)
+	}
+}
+
+func (or *oidcRoutes) Login(c echo.Context) error {
+	if or.o == nil {
+		return errorResponse(c, or.l, usecase.ErrOidcDisabled)
+	}
+
+	flow, url, err := or.o.Begin(c.Request().Context())
+	if err != nil {
+		return errorResponse(c, or.l, fmt.Errorf("http-v1-OidcLogin: %w", err))
+	}
+
+	or.setFlowCookie(c, strings.Join([]string{flow.State, flow.Nonce, flow.Verifier}, "."), int(_oidcFlowTTL.Seconds()))
+
+	return c.Redirect(http.StatusFound, url)
+}
+
+// Callback завершает вход, начатый Login в этом же браузере, и перенаправляет на дашборд.
+func (or *oidcRoutes) Callback(c echo.Context) error {
+	if or.o == nil {
+		return errorResponse(c, or.l, usecase.ErrOidcDisabled)
+	}
+
+	// начатый вход одноразовый, при любом исходе
+	or.setFlowCookie(c, "", -1)
+
+	if reason := c.QueryParam("error"); reason != "" {
+		if desc := c.QueryParam("error_description"); desc != "" {
+			reason += ": " + desc
+		}
+
+		return errorResponse(c, or.l, usecase.ErrOidcFailed.Field("error", "%s", reason))
+	}
+
+	var flow entity.OidcFlow
+
+	cookie, err := c.Cookie(_oidcCookie)
+	if err == nil {
+		parts := strings.Split(cookie.Value, ".")
+		if len(parts) == 3 {
+			flow = entity.OidcFlow{State: parts[0], Nonce: parts[1], Verifier: parts[2]}
+		}
+	}
+
+	_, session, token, err := or.o.Login(c.Request().Context(), flow, c.QueryParam("state"), c.QueryParam("code"))
+	if err != nil {
+		return errorResponse(c, or.l, fmt.Errorf("http-v1-OidcCallback: %w", err))
+	}
+
+	setSessionCookie(c, token, session.ExpiresAt, or.secureCookie)
+
+	return c.Redirect(http.StatusFound, or.postLoginUrl)
+}
+
+// setFlowCookie ставит cookie начатого входа на maxAge секунд, с отрицательным maxAge - удаляет её.
+// SameSite=Lax: браузер отправит cookie при переходе с провайдера на callback.
+func (or *oidcRoutes) setFlowCookie(c echo.Context, value string, maxAge int) {
+	c.SetCookie(&http.Cookie{
+		Name:     _oidcCookie,
+		Value:    value,
+		Path:     _oidcPath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   or.secureCookie,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
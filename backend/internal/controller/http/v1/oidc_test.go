@@ -0,0 +1,465 @@
+package v1
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/internal/usecase/webapi"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	_testClientId     = "pinger"
+	_testClientSecret = "pinger-secret"
+	_testRedirectUrl  = "http://pinger.test/v1/auth/oidc/callback"
+	_testPostLoginUrl = "http://pinger.test/"
+)
+
+// TestOidc - вход через провайдера: discovery, PKCE, проверка ID-токена по JWKS и роли по группам
+// против провайдера в процессе.
+func TestOidc(t *testing.T) {
+	idp := newMockIdp(t)
+
+	provider := idp.provider()
+	if err := provider.Discover(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := newMemUserRepo()
+	users := usecase.NewUsers(repo, time.Hour)
+	if _, err := users.CreateUser(context.Background(), entity.User{Login: "local", Role: entity.RoleViewer}, "local-password"); err != nil {
+		t.Fatal(err)
+	}
+
+	oidc, err := usecase.NewOidc(provider, repo, time.Hour, map[string]string{
+		"pinger-ops":    entity.RoleOperator,
+		"pinger-admins": entity.RoleAdmin,
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	err = NewRouter(e, logger.NewLogger(), nil, nil, nil, nil, usecase.NewApiKeys(nil), users, oidc, &memSilences{}, nil,
+		AuthConfig{OidcPostLoginUrl: _testPostLoginUrl})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &oidcBrowser{t: t, e: e, idp: idp}
+
+	idp.user("alice-sub", "alice", "staff", "pinger-ops")
+	b.signIn(http.StatusFound, _testPostLoginUrl)
+	b.expectMe(`"login":"alice"`, `"role":"operator"`)
+
+	// роль и логин берутся у провайдера при каждом входе, пользователь тот же
+	idp.user("alice-sub", "alice.smith", "pinger-ops", "pinger-admins")
+	b.signIn(http.StatusFound, _testPostLoginUrl)
+	b.expectMe(`"id":2`, `"login":"alice.smith"`, `"role":"admin"`)
+
+	idp.user("bob-sub", "bob", "staff")
+	b.signIn(http.StatusForbidden, "oidc_no_role")
+
+	idp.user("local-sub", "local", "pinger-ops")
+	b.signIn(http.StatusConflict, "user_exists")
+
+	for name, tamper := range map[string]func(*mockIdp, *oidcBrowser){
+		"audience": func(idp *mockIdp, _ *oidcBrowser) { idp.claims["aud"] = "other-client" },
+		"issuer":   func(idp *mockIdp, _ *oidcBrowser) { idp.claims["iss"] = "https://evil.test" },
+		"expired":  func(idp *mockIdp, _ *oidcBrowser) { idp.claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"nonce":    func(idp *mockIdp, _ *oidcBrowser) { idp.claims["nonce"] = "replayed" },
+		"key":      func(idp *mockIdp, _ *oidcBrowser) { idp.signKey = newTestKey(t) },
+		"verifier": func(_ *mockIdp, b *oidcBrowser) { b.tamperVerifier = true },
+	} {
+		t.Run(name, func(t *testing.T) {
+			b := &oidcBrowser{t: t, e: e, idp: idp}
+
+			idp.user("alice-sub", "alice", "pinger-ops")
+			tamper(idp, b)
+
+			b.signIn(http.StatusUnauthorized, "oidc_failed")
+		})
+	}
+
+	// state не от этого браузера
+	idp.user("alice-sub", "alice", "pinger-ops")
+	b.session = nil
+	rec := b.do("/v1/auth/oidc/callback?code=any&state=forged", nil)
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "oidc_failed") {
+		t.Errorf("forged state: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = b.do("/v1/auth/oidc/callback?error=access_denied&error_description=user+cancelled", nil)
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "user cancelled") {
+		t.Errorf("provider error: got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestOidcLazyDiscovery - провайдер, недоступный на старте, подхватывается при входе.
+func TestOidcLazyDiscovery(t *testing.T) {
+	idp := newMockIdp(t)
+	idp.down.Store(true)
+
+	provider := idp.provider()
+	if err := provider.Discover(context.Background()); err == nil {
+		t.Fatal("discovery of a down provider succeeded")
+	}
+
+	oidc, err := usecase.NewOidc(provider, newMemUserRepo(), time.Hour, map[string]string{"pinger-ops": entity.RoleOperator}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	err = NewRouter(e, logger.NewLogger(), nil, nil, nil, nil, usecase.NewApiKeys(nil), nil, oidc, &memSilences{}, nil,
+		AuthConfig{OidcPostLoginUrl: _testPostLoginUrl})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &oidcBrowser{t: t, e: e, idp: idp}
+
+	rec := b.do("/v1/auth/oidc/login", nil)
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "oidc_unavailable") {
+		t.Errorf("provider down: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	idp.down.Store(false)
+	idp.user("alice-sub", "alice", "pinger-ops")
+	b.signIn(http.StatusFound, _testPostLoginUrl)
+}
+
+func TestOidcDisabled(t *testing.T) {
+	e := echo.New()
+	if err := NewRouter(e, logger.NewLogger(), nil, nil, nil, nil, nil, nil, nil, nil, nil, AuthConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &oidcBrowser{t: t, e: e}
+
+	rec := b.do("/v1/auth/oidc/login", nil)
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "oidc_disabled") {
+		t.Errorf("got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// oidcBrowser проходит вход как браузер: Pinger -> провайдер -> callback, с cookie между шагами.
+type oidcBrowser struct {
+	t       *testing.T
+	e       *echo.Echo
+	idp     *mockIdp
+	session *http.Cookie
+	// tamperVerifier подменяет PKCE verifier в cookie начатого входа
+	tamperVerifier bool
+}
+
+func (b *oidcBrowser) do(target string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	if b.session != nil {
+		req.AddCookie(b.session)
+	}
+
+	rec := httptest.NewRecorder()
+	b.e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// signIn проходит вход и проверяет ответ callback: код status и Location или тело с want.
+func (b *oidcBrowser) signIn(status int, want string) {
+	b.t.Helper()
+
+	b.session = nil
+
+	rec := b.do("/v1/auth/oidc/login", nil)
+	if rec.Code != http.StatusFound {
+		b.t.Fatalf("login: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	flow := cookieNamed(rec, _oidcCookie)
+	if flow == nil || !flow.HttpOnly || flow.Path != _oidcPath {
+		b.t.Fatalf("login: unexpected cookies %v", rec.Result().Cookies())
+	}
+
+	if b.tamperVerifier {
+		parts := strings.Split(flow.Value, ".")
+		flow.Value = parts[0] + "." + parts[1] + ".forged-verifier-forged-verifier-forged-verifier"
+	}
+
+	callback := b.idp.authorize(b.t, rec.Header().Get(echo.HeaderLocation))
+
+	rec = b.do(callback, flow)
+	if rec.Code != status {
+		b.t.Fatalf("callback: got %d %s, want %d", rec.Code, rec.Body.String(), status)
+	}
+
+	if status != http.StatusFound {
+		if !strings.Contains(rec.Body.String(), want) {
+			b.t.Errorf("callback: got %s, want %q", rec.Body.String(), want)
+		}
+
+		return
+	}
+
+	if location := rec.Header().Get(echo.HeaderLocation); location != want {
+		b.t.Errorf("callback: redirected to %q, want %q", location, want)
+	}
+
+	if cleared := cookieNamed(rec, _oidcCookie); cleared == nil || cleared.MaxAge >= 0 {
+		b.t.Errorf("callback: flow cookie is not cleared")
+	}
+
+	b.session = cookieNamed(rec, _sessionCookie)
+	if b.session == nil || !b.session.HttpOnly {
+		b.t.Fatalf("callback: no session cookie in %v", rec.Result().Cookies())
+	}
+}
+
+func (b *oidcBrowser) expectMe(want ...string) {
+	b.t.Helper()
+
+	rec := b.do("/v1/auth/me", nil)
+	for _, w := range want {
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), w) {
+			b.t.Errorf("me: got %d %s, want %q", rec.Code, rec.Body.String(), w)
+		}
+	}
+}
+
+func cookieNamed(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+type mockGrant struct {
+	challenge string
+	nonce     string
+}
+
+// mockIdp - OpenID Connect провайдер: discovery, JWKS, страница входа без формы и token endpoint.
+// Пользователь задаётся user, claims ID-токена можно испортить до входа.
+type mockIdp struct {
+	t   *testing.T
+	srv *httptest.Server
+	key *rsa.PrivateKey
+	// signKey подписывает ID-токены, в JWKS опубликован только key
+	signKey *rsa.PrivateKey
+	claims  map[string]any
+
+	// down - discovery отвечает 503, как провайдер, который ещё не поднялся
+	down atomic.Bool
+
+	mu     sync.Mutex
+	grants map[string]mockGrant
+}
+
+func newMockIdp(t *testing.T) *mockIdp {
+	idp := &mockIdp{t: t, key: newTestKey(t), grants: map[string]mockGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("GET /jwks", idp.jwks)
+	mux.HandleFunc("GET /authorize", idp.authorizeEndpoint)
+	mux.HandleFunc("POST /token", idp.token)
+
+	idp.srv = httptest.NewServer(mux)
+	t.Cleanup(idp.srv.Close)
+
+	return idp
+}
+
+// provider - клиент Pinger у этого провайдера.
+func (idp *mockIdp) provider() *webapi.OidcWebAPI {
+	return webapi.NewOidc(webapi.OidcConfig{
+		Issuer:       idp.srv.URL,
+		ClientId:     _testClientId,
+		ClientSecret: _testClientSecret,
+		RedirectUrl:  _testRedirectUrl,
+		LoginClaim:   "preferred_username",
+		GroupsClaim:  "groups",
+	})
+}
+
+func newTestKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+// user задаёт пользователя следующего входа и сбрасывает порчу токена.
+func (idp *mockIdp) user(subject, login string, groups ...string) {
+	idp.signKey = idp.key
+	idp.claims = map[string]any{
+		"iss":                idp.srv.URL,
+		"aud":                _testClientId,
+		"sub":                subject,
+		"iat":                time.Now().Unix(),
+		"exp":                time.Now().Add(time.Minute).Unix(),
+		"preferred_username": login,
+		"groups":             groups,
+	}
+}
+
+// authorize открывает страницу входа, как браузер, и возвращает путь callback, куда провайдер перенаправил.
+func (idp *mockIdp) authorize(t *testing.T, location string) string {
+	t.Helper()
+
+	if !strings.HasPrefix(location, idp.srv.URL+"/authorize?") {
+		t.Fatalf("login redirected to %q", location)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	resp, err := client.Get(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: got %d", resp.StatusCode)
+	}
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return callback.RequestURI()
+}
+
+func (idp *mockIdp) discovery(w http.ResponseWriter, _ *http.Request) {
+	if idp.down.Load() {
+		http.Error(w, "starting", http.StatusServiceUnavailable)
+		return
+	}
+
+	writeJson(w, http.StatusOK, map[string]any{
+		"issuer":                                idp.srv.URL,
+		"authorization_endpoint":                idp.srv.URL + "/authorize",
+		"token_endpoint":                        idp.srv.URL + "/token",
+		"jwks_uri":                              idp.srv.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (idp *mockIdp) jwks(w http.ResponseWriter, _ *http.Request) {
+	writeJson(w, http.StatusOK, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &idp.key.PublicKey, KeyID: "test", Algorithm: string(jose.RS256), Use: "sig"},
+	}})
+}
+
+func (idp *mockIdp) authorizeEndpoint(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	if q.Get("response_type") != "code" || q.Get("client_id") != _testClientId || q.Get("redirect_uri") != _testRedirectUrl ||
+		q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" || q.Get("nonce") == "" ||
+		!strings.Contains(q.Get("scope"), "openid") {
+		idp.t.Errorf("authorize: unexpected request %s", r.URL.RawQuery)
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+
+		return
+	}
+
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	code := base64.RawURLEncoding.EncodeToString(b)
+
+	idp.mu.Lock()
+	idp.grants[code] = mockGrant{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	idp.mu.Unlock()
+
+	callback := url.Values{"code": {code}, "state": {q.Get("state")}, "iss": {idp.srv.URL}}
+	http.Redirect(w, r, _testRedirectUrl+"?"+callback.Encode(), http.StatusFound)
+}
+
+func (idp *mockIdp) token(w http.ResponseWriter, r *http.Request) {
+	if id, secret, ok := r.BasicAuth(); !ok || id != _testClientId || secret != _testClientSecret {
+		writeJson(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	idp.mu.Lock()
+	grant, ok := idp.grants[r.PostFormValue("code")]
+	delete(idp.grants, r.PostFormValue("code"))
+	idp.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := map[string]any{"nonce": grant.nonce}
+	for k, v := range idp.claims {
+		claims[k] = v
+	}
+
+	writeJson(w, http.StatusOK, map[string]any{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idp.sign(claims),
+	})
+}
+
+// sign вызывается из обработчика провайдера, поэтому ошибки через Errorf, а не Fatal.
+func (idp *mockIdp) sign(claims map[string]any) string {
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.RS256,
+		Key:       jose.JSONWebKey{Key: idp.signKey, KeyID: "test"},
+	}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		idp.t.Errorf("sign: %s", err)
+		return ""
+	}
+
+	payload, _ := json.Marshal(claims)
+
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		idp.t.Errorf("sign: %s", err)
+		return ""
+	}
+
+	raw, _ := jws.CompactSerialize()
+
+	return raw
+}
+
+func writeJson(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
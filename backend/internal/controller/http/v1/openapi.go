@@ -0,0 +1,159 @@
+package v1
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+// _openapiSpec - спецификация v1 API. Совпадение с роутами и DTO проверяет openapi_test.go.
+//
+//go:embed openapi.yaml
+var _openapiSpec []byte
+
+//go:embed docs.html
+var _docsPage []byte
+
+// loadSpec разбирает и проверяет спецификацию v1 API.
+func loadSpec() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(_openapiSpec)
+	if err != nil {
+		return nil, fmt.Errorf("openapi - loadSpec: %w", err)
+	}
+
+	if err = doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("openapi - loadSpec: %w", err)
+	}
+
+	return doc, nil
+}
+
+func newOpenapiRoutes(handler *echo.Echo, doc *openapi3.T) {
+	// GET /openapi.json
+	handler.GET("/openapi.json", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, doc)
+	})
+
+	// GET /docs - Swagger UI по /openapi.json
+	handler.GET("/docs", func(c echo.Context) error {
+		return c.HTMLBlob(http.StatusOK, _docsPage)
+	})
+}
+
+// validateRequests проверяет параметры и тело запроса по спецификации до вызова обработчика.
+// Запросы к путям, которых нет в спецификации, пропускаются: на них ответит сам echo.
+func validateRequests(doc *openapi3.T, l logger.Logger) (echo.MiddlewareFunc, error) {
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("openapi - validateRequests: %w", err)
+	}
+
+	options := &openapi3filter.Options{
+		MultiError:          true,
+		SkipSettingDefaults: true,
+		// ключ уже проверен в authenticate, права - в requireScope
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			route, pathParams, err := router.FindRoute(c.Request())
+			if err != nil {
+				return next(c)
+			}
+
+			err = openapi3filter.ValidateRequest(c.Request().Context(), &openapi3filter.RequestValidationInput{
+				Request:    c.Request(),
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			})
+			if err != nil {
+				return errorResponse(c, l, fmt.Errorf("http-v1-validateRequests: %w", specError(err)))
+			}
+
+			return next(c)
+		}
+	}, nil
+}
+
+// specError переводит ошибки openapi3filter в ошибку с полями: параметры запроса - ErrBadQuery,
+// тело - ErrValidation.
+func specError(err error) error {
+	var fields []usecase.FieldError
+	body := false
+
+	var walk func(err error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case openapi3.MultiError:
+			for _, err := range e {
+				walk(err)
+			}
+		case *openapi3filter.RequestError:
+			switch {
+			case e.Parameter != nil:
+				fields = append(fields, usecase.FieldError{Field: e.Parameter.Name, Message: reason(e)})
+			case e.Err != nil:
+				walk(e.Err)
+			default:
+				body = true
+				fields = append(fields, usecase.FieldError{Field: "body", Message: e.Reason})
+			}
+		case *openapi3.SchemaError:
+			body = true
+			fields = append(fields, usecase.FieldError{Field: bodyField(e), Message: e.Reason})
+		default:
+			fields = append(fields, usecase.FieldError{Field: "request", Message: err.Error()})
+		}
+	}
+	walk(err)
+
+	res := usecase.ErrBadQuery
+	if body {
+		res = usecase.ErrValidation
+	}
+
+	for _, f := range fields {
+		res = res.Field(f.Field, "%s", f.Message)
+	}
+
+	return res
+}
+
+// bodyField - путь к полю тела через точку, "body" для тела целиком.
+func bodyField(err error) string {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		if path := schemaErr.JSONPointer(); len(path) > 0 {
+			return strings.Join(path, ".")
+		}
+	}
+
+	return "body"
+}
+
+// reason - причина без пересказа схемы и значения, которые openapi3filter добавляет в Error().
+func reason(err error) string {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		return schemaErr.Reason
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) && reqErr.Reason != "" {
+		return reqErr.Reason
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,162 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/k1v4/Pinger/backend/internal/controller/dto"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+var _echoParam = regexp.MustCompile(`:(\w+)`)
+
+// TestSpecMatchesRoutes - у каждого роута /v1 есть операция в спецификации и наоборот.
+func TestSpecMatchesRoutes(t *testing.T) {
+	doc, err := loadSpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	if err = NewRouter(e, logger.NewLogger(), nil, nil, nil, nil, nil, nil, nil, nil, nil, AuthConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var routes []string
+	for _, r := range e.Routes() {
+		// echo добавляет к группам служебные роуты echo_route_not_found
+		if strings.HasPrefix(r.Path, "/v1/") && !strings.HasPrefix(r.Method, "echo_") {
+			routes = append(routes, r.Method+" "+_echoParam.ReplaceAllString(r.Path, "{$1}"))
+		}
+	}
+
+	var operations []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			operations = append(operations, method+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(operations)
+
+	if !slices.Equal(routes, operations) {
+		t.Errorf("routes and spec differ:\nroutes: %v\nspec:   %v", routes, operations)
+	}
+}
+
+// TestSpecMatchesSchemas - свойства схем совпадают с json-полями DTO и сущностей.
+func TestSpecMatchesSchemas(t *testing.T) {
+	doc, err := loadSpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemas := map[string]any{
+		"Container":                entity.Container{},
+		"ContainerPage":            entity.ContainerPage{},
+		"PingContainer":            dto.DtoPingContainer{},
+		"UpdateContainerRequest":   dto.UpdateContainerRequest{},
+		"NewContainerResponse":     dto.NewContainerResponse{},
+		"DeleteContainerResponse":  dto.DeleteContainerResponse{},
+		"AddStatsRequest":          dto.AddStatsRequest{},
+		"ContainerStats":           entity.ContainerStats{},
+		"AddContainerEventRequest": dto.AddContainerEventRequest{},
+		"ContainerEvent":           entity.ContainerEvent{},
+		"ContainerEventPage":       entity.ContainerEventPage{},
+		"Event":                    entity.Event{},
+		"EventFilter":              entity.EventFilter{},
+		"FieldError":               usecase.FieldError{},
+		"Error":                    response{},
+		"ApiKey":                   entity.ApiKey{},
+		"CreateApiKeyRequest":      dto.CreateApiKeyRequest{},
+		"CreateApiKeyResponse":     dto.CreateApiKeyResponse{},
+		"User":                     entity.User{},
+		"LoginRequest":             dto.LoginRequest{},
+		"SessionResponse":          dto.SessionResponse{},
+		"ChangePasswordRequest":    dto.ChangePasswordRequest{},
+		"CreateUserRequest":        dto.CreateUserRequest{},
+		"UpdateUserRequest":        dto.UpdateUserRequest{},
+		"Silence":                  entity.Silence{},
+		"AddSilenceRequest":        dto.AddSilenceRequest{},
+	}
+
+	for name, v := range schemas {
+		ref, ok := doc.Components.Schemas[name]
+		if !ok {
+			t.Errorf("schema %s is missing", name)
+			continue
+		}
+
+		var props []string
+		for prop := range ref.Value.Properties {
+			props = append(props, prop)
+		}
+
+		fields := jsonFields(reflect.TypeOf(v))
+
+		sort.Strings(props)
+		sort.Strings(fields)
+
+		if !slices.Equal(props, fields) {
+			t.Errorf("schema %s: properties %v, json fields %v", name, props, fields)
+		}
+	}
+}
+
+func jsonFields(typ reflect.Type) []string {
+	var fields []string
+
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}
+
+// TestValidateRequests - запрос, не подходящий под спецификацию, не доходит до обработчика.
+func TestValidateRequests(t *testing.T) {
+	keys := usecase.NewApiKeys(nil, usecase.StaticApiKey{Name: "test", Key: "pk_test", Scopes: []string{entity.ScopeAdmin}})
+
+	e := echo.New()
+	if err := NewRouter(e, logger.NewLogger(), nil, nil, nil, nil, keys, nil, nil, nil, nil, AuthConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		method, target, body string
+		code                 string
+	}{
+		{http.MethodGet, "/v1/containers/?limit=0", "", "bad_query"},
+		{http.MethodGet, "/v1/containers/?sort=size", "", "bad_query"},
+		{http.MethodGet, "/v1/containers/10.0.0.1/stats?from=yesterday", "", "bad_query"},
+		{http.MethodPost, "/v1/containers/10.0.0.1/stats", `{"cpu_percent": -1}`, "validation_failed"},
+		{http.MethodPost, "/v1/containers/10.0.0.1/events", `{"type": "exploded", "container_id": "abcd"}`, "validation_failed"},
+		{http.MethodPost, "/v1/containers/10.0.0.1", `{"ping_time": "fast"}`, "validation_failed"},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer pk_test")
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"code":"`+tc.code+`"`) {
+			t.Errorf("%s %s: got %d %s, want 400 %s", tc.method, tc.target, rec.Code, rec.Body.String(), tc.code)
+		}
+	}
+}
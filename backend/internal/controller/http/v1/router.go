@@ -1,15 +1,43 @@
 package v1
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
 	"github.com/k1v4/Pinger/backend/internal/usecase"
 	"github.com/k1v4/Pinger/backend/pkg/logger"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
-	"net/http"
 )
 
-func NewRouter(handler *echo.Echo, l logger.Logger, t usecase.Container) {
+// NewRouter регистрирует роуты API. Права роутов: read - чтение, ingest - результаты от пингера,
+// operate - тишина оповещений, admin - изменение контейнеров, ключи и пользователи.
+// Роли пользователей дают права через entity.RoleScopes. Без провайдера o вход через OpenID Connect выключен.
+// Метрики m отдаются на /metrics с правом read, без m роута нет.
+func NewRouter(handler *echo.Echo, l logger.Logger, t usecase.Container, s usecase.Stream, st usecase.Stats,
+	e usecase.ContainerEvents, k usecase.ApiKeys, u usecase.Users, o usecase.Oidc, sl usecase.Silences, m http.Handler,
+	auth AuthConfig) error {
+	doc, err := loadSpec()
+	if err != nil {
+		return fmt.Errorf("http-v1-NewRouter: %w", err)
+	}
+
+	validate, err := validateRequests(doc, l)
+	if err != nil {
+		return fmt.Errorf("http-v1-NewRouter: %w", err)
+	}
+
+	handler.HTTPErrorHandler = httpErrorHandler(l)
+
 	// Middleware
+	handler.Use(middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+		// id запроса попадает в ответы с ошибкой и в записи логгера с ctx запроса
+		RequestIDHandler: func(c echo.Context, id string) {
+			c.SetRequest(c.Request().WithContext(context.WithValue(c.Request().Context(), logger.RequestID, id)))
+		},
+	}))
 	handler.Use(middleware.Logger())
 	handler.Use(middleware.Recover())
 
@@ -17,8 +45,24 @@ func NewRouter(handler *echo.Echo, l logger.Logger, t usecase.Container) {
 		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 	})
 
-	h := handler.Group("/v1")
+	newOpenapiRoutes(handler, doc)
+
+	if m != nil {
+		handler.GET("/metrics", echo.WrapHandler(m), authenticate(k, u, l, auth), requireScope(entity.ScopeRead, l))
+	}
+
+	h := handler.Group("/v1", authenticate(k, u, l, auth), validate)
 	{
 		newContainerRoutes(h, t, l)
+		newStreamRoutes(h, s, l, auth.AllowOrigins)
+		newStatsRoutes(h, st, l)
+		newContainerEventRoutes(h, e, l)
+		newApiKeyRoutes(h, k, l)
+		newSessionRoutes(h, u, l, auth.SecureCookie)
+		newOidcRoutes(h, o, l, auth)
+		newUserRoutes(h, u, l)
+		newSilenceRoutes(h, sl, l)
 	}
+
+	return nil
 }
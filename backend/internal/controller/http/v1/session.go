@@ -0,0 +1,101 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/controller/dto"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+type sessionRoutes struct {
+	u            usecase.Users
+	l            logger.Logger
+	secureCookie bool
+}
+
+func newSessionRoutes(handler *echo.Group, u usecase.Users, l logger.Logger, secureCookie bool) {
+	r := &sessionRoutes{u, l, secureCookie}
+
+	// группа роутов для /v1/auth
+	h := handler.Group("/auth")
+	{
+		// POST /v1/auth/login - открывает сессию и ставит cookie
+		h.POST("/login", r.Login)
+
+		// POST /v1/auth/logout
+		h.POST("/logout", r.Logout, requireSession(l))
+
+		// GET /v1/auth/me - пользователь сессии и CSRF-токен
+		h.GET("/me", r.Me, requireSession(l))
+
+		// PUT /v1/auth/password
+		h.PUT("/password", r.ChangePassword, requireSession(l))
+	}
+}
+
+func (sr *sessionRoutes) Login(c echo.Context) error {
+	u := new(dto.LoginRequest)
+	if err := c.Bind(u); err != nil {
+		return errorResponse(c, sr.l, fmt.Errorf("http-v1-Login: %w", malformedBody(err)))
+	}
+
+	user, session, token, err := sr.u.Login(c.Request().Context(), u.Login, u.Password)
+	if err != nil {
+		return errorResponse(c, sr.l, fmt.Errorf("http-v1-Login: %w", err))
+	}
+
+	setSessionCookie(c, token, session.ExpiresAt, sr.secureCookie)
+
+	return c.JSON(http.StatusOK, dto.SessionResponse{User: user, CsrfToken: session.CsrfToken})
+}
+
+func (sr *sessionRoutes) Logout(c echo.Context) error {
+	err := sr.u.Logout(c.Request().Context(), currentPrincipal(c).token)
+	if err != nil {
+		return errorResponse(c, sr.l, fmt.Errorf("http-v1-Logout: %w", err))
+	}
+
+	setSessionCookie(c, "", time.Unix(0, 0), sr.secureCookie)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (sr *sessionRoutes) Me(c echo.Context) error {
+	p := currentPrincipal(c)
+
+	return c.JSON(http.StatusOK, dto.SessionResponse{User: *p.user, CsrfToken: p.session.CsrfToken})
+}
+
+// ChangePassword меняет пароль по текущему, остальные сессии пользователя закрываются.
+func (sr *sessionRoutes) ChangePassword(c echo.Context) error {
+	u := new(dto.ChangePasswordRequest)
+	if err := c.Bind(u); err != nil {
+		return errorResponse(c, sr.l, fmt.Errorf("http-v1-ChangePassword: %w", malformedBody(err)))
+	}
+
+	p := currentPrincipal(c)
+
+	err := sr.u.ChangePassword(c.Request().Context(), p.user.Id, p.token, u.CurrentPassword, u.NewPassword)
+	if err != nil {
+		return errorResponse(c, sr.l, fmt.Errorf("http-v1-ChangePassword: %w", err))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// setSessionCookie ставит cookie сессии, с пустым token - удаляет её.
+func setSessionCookie(c echo.Context, token string, expires time.Time, secure bool) {
+	c.SetCookie(&http.Cookie{
+		Name:     _sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
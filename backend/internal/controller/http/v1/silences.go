@@ -0,0 +1,76 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/k1v4/Pinger/backend/internal/controller/dto"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+type silenceRoutes struct {
+	s usecase.Silences
+	l logger.Logger
+}
+
+func newSilenceRoutes(handler *echo.Group, s usecase.Silences, l logger.Logger) {
+	r := &silenceRoutes{s, l}
+	operate := requireScope(entity.ScopeOperate, l)
+
+	// группа роутов для /v1/silences
+	h := handler.Group("/silences")
+	{
+		// GET /v1/silences - действующие тишины
+		h.GET("", r.Silences, requireScope(entity.ScopeRead, l))
+
+		// POST /v1/silences
+		h.POST("", r.AddSilence, operate)
+
+		// DELETE /v1/silences/{id}
+		h.DELETE("/:id", r.DeleteSilence, operate)
+	}
+}
+
+func (sr *silenceRoutes) Silences(c echo.Context) error {
+	silences, err := sr.s.Silences(c.Request().Context())
+	if err != nil {
+		return errorResponse(c, sr.l, fmt.Errorf("http-v1-Silences: %w", err))
+	}
+
+	return c.JSON(http.StatusOK, silences)
+}
+
+func (sr *silenceRoutes) AddSilence(c echo.Context) error {
+	u := new(dto.AddSilenceRequest)
+	if err := c.Bind(u); err != nil {
+		return errorResponse(c, sr.l, fmt.Errorf("http-v1-AddSilence: %w", malformedBody(err)))
+	}
+
+	silence, err := sr.s.AddSilence(c.Request().Context(), entity.Silence{
+		IpAddr:    u.Ip,
+		Comment:   u.Comment,
+		CreatedBy: currentPrincipal(c).name,
+		Until:     u.Until,
+	})
+	if err != nil {
+		return errorResponse(c, sr.l, fmt.Errorf("http-v1-AddSilence: %w", err))
+	}
+
+	return c.JSON(http.StatusCreated, silence)
+}
+
+func (sr *silenceRoutes) DeleteSilence(c echo.Context) error {
+	id, err := idParam(c)
+	if err != nil {
+		return errorResponse(c, sr.l, fmt.Errorf("http-v1-DeleteSilence: %w", err))
+	}
+
+	if err = sr.s.DeleteSilence(c.Request().Context(), id); err != nil {
+		return errorResponse(c, sr.l, fmt.Errorf("http-v1-DeleteSilence: %w", err))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/controller/dto"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+const _defaultStatsWindow = time.Hour
+
+type statsRoutes struct {
+	s usecase.Stats
+	l logger.Logger
+}
+
+func newStatsRoutes(handler *echo.Group, s usecase.Stats, l logger.Logger) {
+	r := &statsRoutes{s, l}
+
+	// POST /v1/containers/{ip}/stats
+	handler.POST("/containers/:ip/stats", r.AddStats, requireScope(entity.ScopeIngest, l))
+
+	// GET /v1/containers/{ip}/stats?from=...&to=...
+	handler.GET("/containers/:ip/stats", r.Stats, requireScope(entity.ScopeRead, l))
+}
+
+func (sr *statsRoutes) AddStats(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	u := new(dto.AddStatsRequest)
+	if err := c.Bind(u); err != nil {
+		return errorResponse(c, sr.l, fmt.Errorf("http-v1-AddStats: %w", malformedBody(err)))
+	}
+
+	err := sr.s.AddStats(ctx, entity.ContainerStats{
+		IpAddr:         ipParam(c),
+		Time:           u.Time,
+		CPUPercent:     u.CPUPercent,
+		MemoryUsage:    u.MemoryUsage,
+		MemoryLimit:    u.MemoryLimit,
+		NetRxRate:      u.NetRxRate,
+		NetTxRate:      u.NetTxRate,
+		BlockReadRate:  u.BlockReadRate,
+		BlockWriteRate: u.BlockWriteRate,
+		ReportedBy:     currentPrincipal(c).name,
+	})
+	if err != nil {
+		return errorResponse(c, sr.l, fmt.Errorf("http-v1-AddStats: %w", err))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Stats отдаёт снимки за последний час, если from и to (RFC 3339) не заданы.
+func (sr *statsRoutes) Stats(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	to, err := timeQuery(c, "to", time.Now())
+	if err != nil {
+		return errorResponse(c, sr.l, fmt.Errorf("http-v1-Stats: %w", err))
+	}
+
+	from, err := timeQuery(c, "from", to.Add(-_defaultStatsWindow))
+	if err != nil {
+		return errorResponse(c, sr.l, fmt.Errorf("http-v1-Stats: %w", err))
+	}
+
+	stats, err := sr.s.Stats(ctx, ipParam(c), from, to)
+	if err != nil {
+		return errorResponse(c, sr.l, fmt.Errorf("http-v1-Stats: %w", err))
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
+
+func timeQuery(c echo.Context, name string, def time.Time) (time.Time, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return def, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, usecase.ErrBadQuery.Field(name, "must be an RFC 3339 time")
+	}
+
+	return t, nil
+}
@@ -0,0 +1,196 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	_streamHeartbeat    = 15 * time.Second
+	_streamWriteTimeout = 5 * time.Second
+)
+
+type streamRoutes struct {
+	s        usecase.Stream
+	l        logger.Logger
+	upgrader websocket.Upgrader
+}
+
+func newStreamRoutes(handler *echo.Group, s usecase.Stream, l logger.Logger, origins []string) {
+	r := &streamRoutes{
+		s: s,
+		l: l,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: allowOrigin(origins),
+		},
+	}
+
+	// GET /v1/stream?type=status_changed,incident&ip=...&status=down
+	handler.GET("/stream", r.SSE, requireScope(entity.ScopeRead, l))
+
+	// GET /v1/stream/ws
+	handler.GET("/stream/ws", r.WebSocket, requireScope(entity.ScopeRead, l))
+}
+
+// allowOrigin пропускает WebSocket без Origin (не из браузера), со страницы самого бэкенда или из списка CORS.
+// На WebSocket CORS не действует, а браузер отправляет cookie сессии с любой страницы, которая открыла стрим.
+func allowOrigin(origins []string) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get(echo.HeaderOrigin)
+		if origin == "" || slices.Contains(origins, "*") || slices.Contains(origins, origin) {
+			return true
+		}
+
+		u, err := url.Parse(origin)
+
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+}
+
+func filterFromQuery(c echo.Context) entity.EventFilter {
+	return entity.EventFilter{
+		Types:  splitQuery(c.QueryParam("type")),
+		Ips:    splitQuery(c.QueryParam("ip")),
+		Status: c.QueryParam("status"),
+	}
+}
+
+func splitQuery(v string) []string {
+	if v == "" {
+		return nil
+	}
+
+	return strings.Split(v, ",")
+}
+
+func (sr *streamRoutes) SSE(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	events, unsubscribe := sr.s.Subscribe(filterFromQuery(c))
+	defer unsubscribe()
+
+	w := c.Response()
+	rc := http.NewResponseController(w)
+
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	heartbeat := time.NewTicker(_streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var frame []byte
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			frame = []byte(": heartbeat\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("http-v1-SSE: %w", err)
+			}
+
+			frame = []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", event.Type, data))
+		}
+
+		// у сервера общий WriteTimeout, для стрима продлеваем дедлайн на каждую запись
+		_ = rc.SetWriteDeadline(time.Now().Add(_streamWriteTimeout))
+
+		if _, err := w.Write(frame); err != nil {
+			return nil
+		}
+		w.Flush()
+	}
+}
+
+func (sr *streamRoutes) WebSocket(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	conn, err := sr.upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		sr.l.Error(ctx, fmt.Sprintf("http-v1-WebSocket: %s", err))
+
+		return nil
+	}
+	defer conn.Close()
+
+	// клиент может прислать новый фильтр в виде JSON EventFilter в любой момент
+	filters := make(chan entity.EventFilter)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		defer close(filters)
+
+		for {
+			var f entity.EventFilter
+			if err := conn.ReadJSON(&f); err != nil {
+				return
+			}
+
+			select {
+			case filters <- f:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	events, unsubscribe := sr.s.Subscribe(filterFromQuery(c))
+	defer func() { unsubscribe() }()
+
+	heartbeat := time.NewTicker(_streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case f, ok := <-filters:
+			if !ok {
+				return nil
+			}
+
+			unsubscribe()
+			events, unsubscribe = sr.s.Subscribe(f)
+		case <-heartbeat.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(_streamWriteTimeout))
+			if err != nil {
+				return nil
+			}
+		case event, ok := <-events:
+			if !ok {
+				_ = conn.WriteControl(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutdown"),
+					time.Now().Add(_streamWriteTimeout),
+				)
+
+				return nil
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(_streamWriteTimeout))
+			if err = conn.WriteJSON(event); err != nil {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+// TestStreamOrigin - WebSocket-стрим открывается только со страниц из списка CORS, со страниц самого бэкенда
+// и клиентами без Origin: чужая страница с cookie сессии пользователя получает 403.
+func TestStreamOrigin(t *testing.T) {
+	keys := usecase.NewApiKeys(nil, usecase.StaticApiKey{Name: "test", Key: "pk_test", Scopes: []string{entity.ScopeRead}})
+	stream := usecase.NewStream()
+	defer stream.Close()
+
+	e := echo.New()
+	err := NewRouter(e, logger.NewLogger(), nil, stream, nil, nil, keys, nil, nil, nil, nil, AuthConfig{
+		AllowOrigins: []string{"http://localhost:3000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	wsUrl := "ws" + strings.TrimPrefix(srv.URL, "http") + "/v1/stream/ws"
+
+	for _, tc := range []struct {
+		origin string
+		status int
+	}{
+		{origin: "", status: http.StatusSwitchingProtocols},
+		{origin: "http://localhost:3000", status: http.StatusSwitchingProtocols},
+		{origin: srv.URL, status: http.StatusSwitchingProtocols},
+		{origin: "http://evil.example", status: http.StatusForbidden},
+		{origin: "http://localhost:3001", status: http.StatusForbidden},
+	} {
+		header := http.Header{echo.HeaderAuthorization: {"Bearer pk_test"}}
+		if tc.origin != "" {
+			header.Set(echo.HeaderOrigin, tc.origin)
+		}
+
+		conn, res, err := websocket.DefaultDialer.Dial(wsUrl, header)
+		if conn != nil {
+			conn.Close()
+		}
+		if res == nil {
+			t.Fatalf("origin %q: %s", tc.origin, err)
+		}
+		if res.StatusCode != tc.status {
+			t.Errorf("origin %q: got %d, want %d", tc.origin, res.StatusCode, tc.status)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/k1v4/Pinger/backend/internal/controller/dto"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+type userRoutes struct {
+	u usecase.Users
+	l logger.Logger
+}
+
+func newUserRoutes(handler *echo.Group, u usecase.Users, l logger.Logger) {
+	r := &userRoutes{u, l}
+
+	// группа роутов для /v1/users, только для admin
+	h := handler.Group("/users", requireScope(entity.ScopeAdmin, l))
+	{
+		// GET /v1/users
+		h.GET("", r.Users)
+
+		// POST /v1/users
+		h.POST("", r.CreateUser)
+
+		// PUT /v1/users/{id} - роль и пароль, пустые поля не меняются
+		h.PUT("/:id", r.UpdateUser)
+
+		// DELETE /v1/users/{id}
+		h.DELETE("/:id", r.DeleteUser)
+	}
+}
+
+func (ur *userRoutes) Users(c echo.Context) error {
+	users, err := ur.u.Users(c.Request().Context())
+	if err != nil {
+		return errorResponse(c, ur.l, fmt.Errorf("http-v1-Users: %w", err))
+	}
+
+	return c.JSON(http.StatusOK, users)
+}
+
+func (ur *userRoutes) CreateUser(c echo.Context) error {
+	u := new(dto.CreateUserRequest)
+	if err := c.Bind(u); err != nil {
+		return errorResponse(c, ur.l, fmt.Errorf("http-v1-CreateUser: %w", malformedBody(err)))
+	}
+
+	user, err := ur.u.CreateUser(c.Request().Context(), entity.User{Login: u.Login, Role: u.Role}, u.Password)
+	if err != nil {
+		return errorResponse(c, ur.l, fmt.Errorf("http-v1-CreateUser: %w", err))
+	}
+
+	return c.JSON(http.StatusCreated, user)
+}
+
+func (ur *userRoutes) UpdateUser(c echo.Context) error {
+	id, err := idParam(c)
+	if err != nil {
+		return errorResponse(c, ur.l, fmt.Errorf("http-v1-UpdateUser: %w", err))
+	}
+
+	u := new(dto.UpdateUserRequest)
+	if err = c.Bind(u); err != nil {
+		return errorResponse(c, ur.l, fmt.Errorf("http-v1-UpdateUser: %w", malformedBody(err)))
+	}
+
+	user, err := ur.u.UpdateUser(c.Request().Context(), entity.User{Id: id, Role: u.Role}, u.Password)
+	if err != nil {
+		return errorResponse(c, ur.l, fmt.Errorf("http-v1-UpdateUser: %w", err))
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+func (ur *userRoutes) DeleteUser(c echo.Context) error {
+	id, err := idParam(c)
+	if err != nil {
+		return errorResponse(c, ur.l, fmt.Errorf("http-v1-DeleteUser: %w", err))
+	}
+
+	if err = ur.u.DeleteUser(c.Request().Context(), id); err != nil {
+		return errorResponse(c, ur.l, fmt.Errorf("http-v1-DeleteUser: %w", err))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// idParam - числовой id из пути.
+func idParam(c echo.Context) (int64, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, usecase.ErrBadQuery.Field("id", "must be an integer")
+	}
+
+	return id, nil
+}
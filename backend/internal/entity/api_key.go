@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"slices"
+	"time"
+)
+
+// Права API-ключей и ролей: read - чтение, ingest - приём результатов от пингера,
+// operate - тишина оповещений, admin - всё остальное.
+const (
+	ScopeRead    = "read"
+	ScopeIngest  = "ingest"
+	ScopeOperate = "operate"
+	ScopeAdmin   = "admin"
+)
+
+// ApiKey - ключ доступа к API. В базе хранится только хеш ключа, сам ключ показывается один раз при создании.
+type ApiKey struct {
+	Id        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active - ключ не отозван и не истёк к моменту now.
+func (k ApiKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// ScopesAllow сообщает, есть ли среди scopes право scope. С admin разрешено всё.
+func ScopesAllow(scopes []string, scope string) bool {
+	return slices.Contains(scopes, scope) || slices.Contains(scopes, ScopeAdmin)
+}
@@ -1,11 +1,20 @@
 package entity
 
-import "time"
+import (
+	"net/netip"
+	"time"
+)
 
 type Container struct {
-	IpAddr         string    `json:"ip"`
-	PingTime       int       `json:"ping_time"`
-	LastSuccessful time.Time `json:"last_successful"`
+	IpAddr              string            `json:"ip"`
+	PingTime            int               `json:"ping_time"`
+	IsSuccessful        bool              `json:"is_successful"`
+	LastSuccessful      *time.Time        `json:"last_successful"` // nil, пока не было ни одного успешного пинга
+	ConsecutiveFailures int               `json:"consecutive_failures"`
+	Name                string            `json:"name"`
+	Image               string            `json:"image"`
+	Labels              map[string]string `json:"labels"`
+	ReportedBy          string            `json:"reported_by"` // кто прислал последний результат: agent:<CN>, key:<имя>
 }
 
 type PingContainer struct {
@@ -14,3 +23,14 @@ type PingContainer struct {
 	IsSuccessful   bool      `json:"is_successful"`
 	LastSuccessful time.Time `json:"last_successful"`
 }
+
+// CanonicalIp приводит адрес к виду, в котором он хранится:
+// IPv6 в сокращённой записи, IPv4-mapped IPv6 как IPv4. Адреса с зоной не принимаются.
+func CanonicalIp(s string) (string, bool) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil || addr.Zone() != "" {
+		return "", false
+	}
+
+	return addr.Unmap().String(), true
+}
@@ -0,0 +1,52 @@
+package entity
+
+import "time"
+
+// Типы событий жизненного цикла, которые присылает пингер.
+const (
+	ContainerEventCreated             = "created"
+	ContainerEventStarted             = "started"
+	ContainerEventDied                = "died"
+	ContainerEventRestarted           = "restarted"
+	ContainerEventOOMKilled           = "oom_killed"
+	ContainerEventCrashLoop           = "crash_loop"
+	ContainerEventHealthStatus        = "health_status"
+	ContainerEventNetworkConnected    = "network_connected"
+	ContainerEventNetworkDisconnected = "network_disconnected"
+	ContainerEventIPChanged           = "ip_changed"
+)
+
+// ContainerEvent - событие жизненного цикла контейнера, обнаруженное пингером по событиям Docker.
+type ContainerEvent struct {
+	Id            int64     `json:"id"`
+	IpAddr        string    `json:"ip,omitempty"` // пуст у событий, пришедших до того, как стал известен ip
+	Type          string    `json:"type"`
+	ContainerId   string    `json:"container_id"`
+	ContainerName string    `json:"container_name"`
+	Image         string    `json:"image"`
+	DockerHost    string    `json:"docker_host"`
+	ExitCode      *int      `json:"exit_code,omitempty"`
+	RestartCount  int       `json:"restart_count"`
+	Message       string    `json:"message,omitempty"`
+	Time          time.Time `json:"time"`
+	ReportedBy    string    `json:"reported_by"` // кто прислал событие: agent:<CN>, key:<имя>
+}
+
+// ContainerEventQuery - выборка хронологии контейнера по ip или id (достаточно префикса id).
+// Cursor - курсор страницы из ContainerEventPage.NextCursor, пустой - первая страница.
+type ContainerEventQuery struct {
+	IpAddr      string
+	ContainerId string
+	Types       []string
+	From        time.Time
+	To          time.Time
+	Ascending   bool
+	Cursor      string
+	Limit       int
+}
+
+// ContainerEventPage - страница хронологии, NextCursor пуст на последней странице.
+type ContainerEventPage struct {
+	Events     []ContainerEvent `json:"events"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,92 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Сортировки списка контейнеров, при равных значениях порядок задаёт ip.
+const (
+	SortIp             = "ip"
+	SortLatency        = "latency"
+	SortLastSuccessful = "last_successful"
+	SortStatus         = "status" // по возрастанию сначала down
+	SortName           = "name"
+)
+
+// Операторы селектора меток.
+const (
+	LabelEquals    = "="
+	LabelNotEquals = "!="
+	LabelExists    = "exists"
+	LabelNotExists = "!exists"
+)
+
+// ContainerQuery - выборка списка контейнеров. Нулевые поля фильтров не ограничивают выборку.
+// Cursor - курсор страницы из ContainerPage.NextCursor, действует только с теми же Sort и Descending.
+type ContainerQuery struct {
+	Status     string
+	MinLatency *int
+	MaxLatency *int
+	Labels     []LabelRequirement
+	Image      string // точное имя образа или шаблон с *, например nginx:*
+	Name       string // подстрока имени без учёта регистра
+	Sort       string
+	Descending bool
+	Cursor     string
+	Limit      int
+}
+
+// ContainerPage - страница списка. Total, Up и Down считаются по всей выборке, а не по странице.
+type ContainerPage struct {
+	Containers []Container `json:"containers"`
+	Total      int         `json:"total"`
+	Up         int         `json:"up"`
+	Down       int         `json:"down"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// LabelRequirement - одно условие селектора меток.
+type LabelRequirement struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+// ParseLabelSelector разбирает селектор в духе Kubernetes: условия через запятую,
+// "key=value", "key!=value", "key" (метка есть) и "!key" (метки нет).
+func ParseLabelSelector(s string) ([]LabelRequirement, error) {
+	var reqs []LabelRequirement
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var req LabelRequirement
+
+		switch {
+		case strings.Contains(part, "!="):
+			req.Key, req.Value, _ = strings.Cut(part, "!=")
+			req.Op = LabelNotEquals
+		case strings.Contains(part, "="):
+			req.Key, req.Value, _ = strings.Cut(part, "=")
+			req.Value = strings.TrimPrefix(req.Value, "=")
+			req.Op = LabelEquals
+		case strings.HasPrefix(part, "!"):
+			req.Key, req.Op = strings.TrimPrefix(part, "!"), LabelNotExists
+		default:
+			req.Key, req.Op = part, LabelExists
+		}
+
+		req.Key, req.Value = strings.TrimSpace(req.Key), strings.TrimSpace(req.Value)
+		if req.Key == "" {
+			return nil, fmt.Errorf("label selector %q: empty key", part)
+		}
+
+		reqs = append(reqs, req)
+	}
+
+	return reqs, nil
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	for _, tc := range []struct {
+		selector string
+		want     []LabelRequirement
+		wantErr  bool
+	}{
+		{selector: "", want: nil},
+		{selector: " , ", want: nil},
+		{selector: "env=prod", want: []LabelRequirement{{Key: "env", Op: LabelEquals, Value: "prod"}}},
+		{selector: "env==prod", want: []LabelRequirement{{Key: "env", Op: LabelEquals, Value: "prod"}}},
+		{selector: "env=", want: []LabelRequirement{{Key: "env", Op: LabelEquals}}},
+		{selector: "tier!=db", want: []LabelRequirement{{Key: "tier", Op: LabelNotEquals, Value: "db"}}},
+		{selector: "canary", want: []LabelRequirement{{Key: "canary", Op: LabelExists}}},
+		{selector: "!canary", want: []LabelRequirement{{Key: "canary", Op: LabelNotExists}}},
+		{selector: " env = prod , !canary,team, zone!=eu-1 ", want: []LabelRequirement{
+			{Key: "env", Op: LabelEquals, Value: "prod"},
+			{Key: "canary", Op: LabelNotExists},
+			{Key: "team", Op: LabelExists},
+			{Key: "zone", Op: LabelNotEquals, Value: "eu-1"},
+		}},
+		// значение может содержать "="
+		{selector: "query=a=b", want: []LabelRequirement{{Key: "query", Op: LabelEquals, Value: "a=b"}}},
+		{selector: "=prod", wantErr: true},
+		{selector: "!=prod", wantErr: true},
+		{selector: "!", wantErr: true},
+		{selector: "env=prod, !", wantErr: true},
+	} {
+		got, err := ParseLabelSelector(tc.selector)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseLabelSelector(%q) error = %v, wantErr %t", tc.selector, err, tc.wantErr)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseLabelSelector(%q) = %+v, want %+v", tc.selector, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	EventContainerUpdated = "container_updated"
+	EventContainerDeleted = "container_deleted"
+	EventStatusChanged    = "status_changed"
+	EventIncident         = "incident"
+	EventLifecycle        = "lifecycle"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Event - изменение состояния контейнера, которое рассылается подписчикам стрима.
+type Event struct {
+	Type      string    `json:"type"`
+	IpAddr    string    `json:"ip"`
+	Status    string    `json:"status"`
+	Container Container `json:"container"`
+	Time      time.Time `json:"time"`
+	// Lifecycle заполнен у событий EventLifecycle.
+	Lifecycle *ContainerEvent `json:"lifecycle,omitempty"`
+}
+
+// EventFilter - фильтр подписки, пустые поля пропускают всё.
+type EventFilter struct {
+	Types  []string `json:"types"`
+	Ips    []string `json:"ips"`
+	Status string   `json:"status"`
+}
+
+func (f EventFilter) Match(e Event) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, e.Type) {
+		return false
+	}
+
+	if len(f.Ips) > 0 && !slices.ContainsFunc(f.Ips, func(ip string) bool {
+		canonical, ok := CanonicalIp(ip)
+		return ok && canonical == e.IpAddr
+	}) {
+		return false
+	}
+
+	if f.Status != "" && f.Status != e.Status {
+		return false
+	}
+
+	return true
+}
+
+func ContainerStatus(c Container) string {
+	if c.IsSuccessful {
+		return StatusUp
+	}
+
+	return StatusDown
+}
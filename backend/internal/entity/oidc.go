@@ -0,0 +1,17 @@
+package entity
+
+// OidcIdentity - пользователь, подтверждённый ID-токеном провайдера.
+type OidcIdentity struct {
+	// Subject - постоянный id пользователя у провайдера, claim sub
+	Subject string
+	Login   string
+	Groups  []string
+}
+
+// OidcFlow - параметры начатого входа через провайдера, хранятся у браузера до возврата на callback.
+type OidcFlow struct {
+	State string
+	Nonce string
+	// Verifier - PKCE code_verifier, провайдер получает только его хеш
+	Verifier string
+}
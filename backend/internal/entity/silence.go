@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// Silence - тишина оповещений по контейнеру: пока она действует, инциденты по ip не рассылаются в стрим.
+type Silence struct {
+	Id        int64     `json:"id"`
+	IpAddr    string    `json:"ip"`
+	Comment   string    `json:"comment"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	Until     time.Time `json:"until"`
+}
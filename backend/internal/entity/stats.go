@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// ContainerStats - снимок потребления ресурсов контейнера.
+// PingTime - задержка пинга на момент снимка, чтобы всплеск задержки было видно рядом с нагрузкой.
+type ContainerStats struct {
+	IpAddr         string    `json:"ip"`
+	Time           time.Time `json:"time"`
+	CPUPercent     float64   `json:"cpu_percent"`
+	MemoryUsage    uint64    `json:"memory_usage"`
+	MemoryLimit    uint64    `json:"memory_limit"`
+	NetRxRate      float64   `json:"net_rx_bytes_per_second"`
+	NetTxRate      float64   `json:"net_tx_bytes_per_second"`
+	BlockReadRate  float64   `json:"block_read_bytes_per_second"`
+	BlockWriteRate float64   `json:"block_write_bytes_per_second"`
+	PingTime       *int      `json:"ping_time"`
+	ReportedBy     string    `json:"reported_by"` // кто прислал снимок: agent:<CN>, key:<имя>
+}
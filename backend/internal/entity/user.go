@@ -0,0 +1,45 @@
+package entity
+
+import "time"
+
+// Роли пользователей дашборда: viewer только смотрит, operator ещё и ставит тишину оповещений,
+// admin управляет ключами и пользователями.
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+var _roleScopes = map[string][]string{
+	RoleViewer:   {ScopeRead},
+	RoleOperator: {ScopeRead, ScopeOperate},
+	RoleAdmin:    {ScopeAdmin},
+}
+
+// User - пользователь дашборда. Хеш пароля наружу не отдаётся и в сущности не хранится.
+type User struct {
+	Id        int64     `json:"id"`
+	Login     string    `json:"login"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Session - сессия пользователя. В базе хранится хеш значения cookie, CsrfToken сверяется
+// с заголовком X-CSRF-Token у изменяющих запросов.
+type Session struct {
+	UserId    int64
+	CsrfToken string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// ValidRole сообщает, есть ли такая роль.
+func ValidRole(role string) bool {
+	_, ok := _roleScopes[role]
+	return ok
+}
+
+// RoleScopes - права, которые даёт роль.
+func RoleScopes(role string) []string {
+	return _roleScopes[role]
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const _collectTimeout = 5 * time.Second
+
+type containerLister interface {
+	AllContainers(ctx context.Context) ([]entity.Container, error)
+}
+
+// ContainerCollector читает состояние контейнеров из базы на каждом scrape,
+// поэтому все реплики бэкенда отдают одинаковые значения.
+type ContainerCollector struct {
+	containers containerLister
+
+	latency             *prometheus.Desc
+	up                  *prometheus.Desc
+	sinceLastSuccess    *prometheus.Desc
+	consecutiveFailures *prometheus.Desc
+	scrapeError         *prometheus.Desc
+}
+
+func NewContainerCollector(containers containerLister) *ContainerCollector {
+	labels := []string{"ip"}
+
+	return &ContainerCollector{
+		containers: containers,
+		latency: prometheus.NewDesc(
+			prometheus.BuildFQName(_namespace, "container", "last_latency_seconds"),
+			"Latency of the last ping of the container.", labels, nil,
+		),
+		up: prometheus.NewDesc(
+			prometheus.BuildFQName(_namespace, "container", "up"),
+			"Whether the last ping of the container succeeded.", labels, nil,
+		),
+		sinceLastSuccess: prometheus.NewDesc(
+			prometheus.BuildFQName(_namespace, "container", "seconds_since_last_success"),
+			"Seconds since the last successful ping of the container.", labels, nil,
+		),
+		consecutiveFailures: prometheus.NewDesc(
+			prometheus.BuildFQName(_namespace, "container", "consecutive_failures"),
+			"Failed pings of the container in a row.", labels, nil,
+		),
+		scrapeError: prometheus.NewDesc(
+			prometheus.BuildFQName(_namespace, "container", "scrape_error"),
+			"Whether reading containers from the database failed.", nil, nil,
+		),
+	}
+}
+
+func (cc *ContainerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.latency
+	ch <- cc.up
+	ch <- cc.sinceLastSuccess
+	ch <- cc.consecutiveFailures
+	ch <- cc.scrapeError
+}
+
+func (cc *ContainerCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), _collectTimeout)
+	defer cancel()
+
+	containers, err := cc.containers.AllContainers(ctx)
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(cc.scrapeError, prometheus.GaugeValue, 1)
+
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(cc.scrapeError, prometheus.GaugeValue, 0)
+
+	now := time.Now().UTC()
+
+	for _, c := range containers {
+		up := 0.0
+		if c.IsSuccessful {
+			up = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(cc.latency, prometheus.GaugeValue, float64(c.PingTime)/1000, c.IpAddr)
+		ch <- prometheus.MustNewConstMetric(cc.up, prometheus.GaugeValue, up, c.IpAddr)
+		// без успешных пингов отсчитывать не от чего, серии нет
+		if c.LastSuccessful != nil {
+			ch <- prometheus.MustNewConstMetric(cc.sinceLastSuccess, prometheus.GaugeValue, now.Sub(*c.LastSuccessful).Seconds(), c.IpAddr)
+		}
+		ch <- prometheus.MustNewConstMetric(cc.consecutiveFailures, prometheus.GaugeValue, float64(c.ConsecutiveFailures), c.IpAddr)
+	}
+}
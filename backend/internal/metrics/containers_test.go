@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type staticContainers struct {
+	containers []entity.Container
+	err        error
+}
+
+func (s staticContainers) AllContainers(context.Context) ([]entity.Container, error) {
+	return s.containers, s.err
+}
+
+func TestContainerCollector(t *testing.T) {
+	lastSuccess := time.Now().UTC().Add(-time.Hour)
+
+	collector := NewContainerCollector(staticContainers{containers: []entity.Container{
+		{IpAddr: "10.0.0.1", PingTime: 20, IsSuccessful: true, LastSuccessful: &lastSuccess},
+		// ни одного успешного пинга: серии seconds_since_last_success нет
+		{IpAddr: "10.0.0.2", IsSuccessful: false, ConsecutiveFailures: 3},
+	}})
+
+	want := `
+# HELP pinger_container_consecutive_failures Failed pings of the container in a row.
+# TYPE pinger_container_consecutive_failures gauge
+pinger_container_consecutive_failures{ip="10.0.0.1"} 0
+pinger_container_consecutive_failures{ip="10.0.0.2"} 3
+# HELP pinger_container_last_latency_seconds Latency of the last ping of the container.
+# TYPE pinger_container_last_latency_seconds gauge
+pinger_container_last_latency_seconds{ip="10.0.0.1"} 0.02
+pinger_container_last_latency_seconds{ip="10.0.0.2"} 0
+# HELP pinger_container_scrape_error Whether reading containers from the database failed.
+# TYPE pinger_container_scrape_error gauge
+pinger_container_scrape_error 0
+# HELP pinger_container_up Whether the last ping of the container succeeded.
+# TYPE pinger_container_up gauge
+pinger_container_up{ip="10.0.0.1"} 1
+pinger_container_up{ip="10.0.0.2"} 0
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(want),
+		"pinger_container_consecutive_failures", "pinger_container_last_latency_seconds",
+		"pinger_container_scrape_error", "pinger_container_up")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if n := testutil.CollectAndCount(collector, "pinger_container_seconds_since_last_success"); n != 1 {
+		t.Errorf("got %d seconds_since_last_success series, want 1", n)
+	}
+
+	failing := NewContainerCollector(staticContainers{err: errors.New("connection refused")})
+
+	want = `
+# HELP pinger_container_scrape_error Whether reading containers from the database failed.
+# TYPE pinger_container_scrape_error gauge
+pinger_container_scrape_error 1
+`
+	if err = testutil.CollectAndCompare(failing, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
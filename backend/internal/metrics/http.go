@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware считает длительность HTTP-запросов по шаблону маршрута, а не по фактическому пути,
+// чтобы ip в пути не раздувал число серий.
+func (m *Metrics) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			m.httpInFlight.Inc()
+			defer m.httpInFlight.Dec()
+
+			start := time.Now()
+			err := next(c)
+
+			code := c.Response().Status
+			if err != nil {
+				var he *echo.HTTPError
+				if errors.As(err, &he) && !c.Response().Committed {
+					code = he.Code
+				}
+			}
+
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+
+			m.httpDuration.
+				WithLabelValues(c.Request().Method, route, strconv.Itoa(code)).
+				Observe(time.Since(start).Seconds())
+
+			return err
+		}
+	}
+}
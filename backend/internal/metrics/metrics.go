@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const _namespace = "pinger"
+
+// Metrics - реестр Prometheus бэкенда и метрики, которые обновляются при приёме результатов.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	pingLatency *prometheus.HistogramVec
+	pingResults *prometheus.CounterVec
+
+	containerEvents *prometheus.CounterVec
+
+	httpDuration *prometheus.HistogramVec
+	httpInFlight prometheus.Gauge
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		pingLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: _namespace,
+			Name:      "ping_latency_seconds",
+			Help:      "Latency of successful pings received from the pinger.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"ip"}),
+		pingResults: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "ping_results_total",
+			Help:      "Ping results received from the pinger.",
+		}, []string{"ip", "result"}),
+		containerEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "container_events_total",
+			Help:      "Container lifecycle events (died, restarted, oom_killed, crash_loop, ...) received from the pinger.",
+		}, []string{"ip", "type"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: _namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of HTTP requests served by the backend.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "code"}),
+		httpInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: _namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "HTTP requests currently being served.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.pingLatency,
+		m.pingResults,
+		m.containerEvents,
+		m.httpDuration,
+		m.httpInFlight,
+	)
+
+	return m
+}
+
+// Register добавляет в реестр дополнительные коллекторы (контейнеры, пул соединений).
+func (m *Metrics) Register(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObservePing учитывает принятый результат пинга.
+// Вызывается только репликой, которая приняла результат, поэтому гистограммы не дублируются.
+func (m *Metrics) ObservePing(c entity.Container) {
+	if !c.IsSuccessful {
+		m.pingResults.WithLabelValues(c.IpAddr, "failure").Inc()
+
+		return
+	}
+
+	m.pingResults.WithLabelValues(c.IpAddr, "success").Inc()
+	m.pingLatency.WithLabelValues(c.IpAddr).Observe(float64(c.PingTime) / 1000)
+}
+
+// ObserveContainerEvent учитывает событие жизненного цикла, по счётчику строятся правила оповещений.
+func (m *Metrics) ObserveContainerEvent(e entity.ContainerEvent) {
+	m.containerEvents.WithLabelValues(e.IpAddr, e.Type).Inc()
+}
+
+// ForgetContainer удаляет серии удалённого контейнера, иначе счётчики по ip копятся бесконечно.
+func (m *Metrics) ForgetContainer(ip string) {
+	labels := prometheus.Labels{"ip": ip}
+
+	m.pingLatency.DeletePartialMatch(labels)
+	m.pingResults.DeletePartialMatch(labels)
+	m.containerEvents.DeletePartialMatch(labels)
+}
+
+// ForgetDeleted забывает контейнеры из событий container_deleted, пока канал не закроется.
+// Удаление приходит всем репликам через LISTEN/NOTIFY, поэтому серии чистит каждая.
+func (m *Metrics) ForgetDeleted(events <-chan entity.Event) {
+	for e := range events {
+		if e.Type == entity.EventContainerDeleted {
+			m.ForgetContainer(e.IpAddr)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestForgetDeleted - после container_deleted серий удалённого ip не остаётся, остальные не трогаются.
+func TestForgetDeleted(t *testing.T) {
+	m := New()
+
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		m.ObservePing(entity.Container{IpAddr: ip, PingTime: 20, IsSuccessful: true})
+		m.ObservePing(entity.Container{IpAddr: ip})
+		m.ObserveContainerEvent(entity.ContainerEvent{IpAddr: ip, Type: "died"})
+	}
+
+	events := make(chan entity.Event, 2)
+	events <- entity.Event{Type: entity.EventContainerUpdated, IpAddr: "10.0.0.2"}
+	events <- entity.Event{Type: entity.EventContainerDeleted, IpAddr: "10.0.0.1"}
+	close(events)
+
+	m.ForgetDeleted(events)
+
+	for name, want := range map[string]int{
+		"pinger_ping_latency_seconds":   1,
+		"pinger_ping_results_total":     2,
+		"pinger_container_events_total": 1,
+	} {
+		if got := testutil.CollectAndCount(m.registry, name); got != want {
+			t.Errorf("%s: %d series, want %d", name, got, want)
+		}
+	}
+}
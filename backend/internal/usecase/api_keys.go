@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+)
+
+const (
+	_apiKeyPrefix = "pk_"
+	// _apiKeyShown - сколько символов ключа после pk_ видно в списке, чтобы ключи можно было различить
+	_apiKeyShown      = 8
+	_maxApiKeyNameLen = 100
+)
+
+var _apiKeyScopes = []string{entity.ScopeRead, entity.ScopeIngest, entity.ScopeOperate, entity.ScopeAdmin}
+
+// StaticApiKey - ключ из конфигурации бэкенда. Он не хранится в базе и не отзывается через API:
+// с ним создают первые ключи и подключают пингер без ручной настройки.
+type StaticApiKey struct {
+	Name   string
+	Key    string
+	Scopes []string
+}
+
+type ApiKeyUseCase struct {
+	repo ApiKeyRepo
+	// static - ключи из конфигурации по хешу
+	static map[string]entity.ApiKey
+}
+
+// NewApiKeys создаёт usecase ключей, статические ключи с пустым Key пропускаются.
+func NewApiKeys(r ApiKeyRepo, static ...StaticApiKey) *ApiKeyUseCase {
+	aku := &ApiKeyUseCase{
+		repo:   r,
+		static: make(map[string]entity.ApiKey, len(static)),
+	}
+
+	for _, k := range static {
+		if k.Key == "" {
+			continue
+		}
+
+		aku.static[hashToken(k.Key)] = entity.ApiKey{Name: k.Name, Scopes: k.Scopes}
+	}
+
+	return aku
+}
+
+// CheckStaticKeys проверяет ключи из конфигурации до запуска: заглушка из примера .env известна всем,
+// и бэкенд с ней не запускается. Пустой ключ просто не включается.
+func CheckStaticKeys(static ...StaticApiKey) error {
+	for _, k := range static {
+		if isPlaceholder(k.Key) {
+			return fmt.Errorf("%s: placeholder value %q, set a random key", k.Name, k.Key)
+		}
+	}
+
+	return nil
+}
+
+// CreateKey создаёт ключ с именем, правами и сроком действия из key и возвращает его вместе с самим ключом.
+// Ключ больше нигде не отдаётся: в базе остаётся только его хеш.
+func (aku *ApiKeyUseCase) CreateKey(ctx context.Context, key entity.ApiKey) (entity.ApiKey, string, error) {
+	now := time.Now().UTC()
+
+	var v validator
+	v.check(key.Name != "" && len(key.Name) <= _maxApiKeyNameLen, "name", "must be 1 to %d characters", _maxApiKeyNameLen)
+	v.check(len(key.Scopes) > 0, "scopes", "must not be empty")
+	for _, scope := range key.Scopes {
+		v.check(slices.Contains(_apiKeyScopes, scope), "scopes", "unknown scope %q", scope)
+	}
+	v.check(key.ExpiresAt == nil || key.ExpiresAt.After(now), "expires_at", "must be in the future")
+	if err := v.err(); err != nil {
+		return entity.ApiKey{}, "", fmt.Errorf("ApiKeyUseCase_CreateKey: %w", err)
+	}
+
+	secret, err := newToken(_apiKeyPrefix)
+	if err != nil {
+		return entity.ApiKey{}, "", fmt.Errorf("ApiKeyUseCase_CreateKey: %w", err)
+	}
+
+	slices.Sort(key.Scopes)
+	key.Scopes = slices.Compact(key.Scopes)
+	key.Prefix = keyPrefix(secret)
+	key.CreatedAt = now
+	key.RevokedAt = nil
+
+	key.Id, err = aku.repo.AddApiKey(ctx, key, hashToken(secret))
+	if err != nil {
+		return entity.ApiKey{}, "", fmt.Errorf("ApiKeyUseCase_CreateKey: %w", err)
+	}
+
+	return key, secret, nil
+}
+
+func (aku *ApiKeyUseCase) ApiKeys(ctx context.Context) ([]entity.ApiKey, error) {
+	keys, err := aku.repo.GetApiKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ApiKeyUseCase_ApiKeys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeKey отзывает ключ. Повторный отзыв не меняет время отзыва.
+func (aku *ApiKeyUseCase) RevokeKey(ctx context.Context, id int64) (entity.ApiKey, error) {
+	key, err := aku.repo.RevokeApiKey(ctx, id, time.Now().UTC())
+	if err != nil {
+		return entity.ApiKey{}, fmt.Errorf("ApiKeyUseCase_RevokeKey: %w", err)
+	}
+
+	return key, nil
+}
+
+// Authenticate находит действующий ключ по его значению из запроса.
+func (aku *ApiKeyUseCase) Authenticate(ctx context.Context, secret string) (entity.ApiKey, error) {
+	hash := hashToken(secret)
+
+	if key, ok := aku.static[hash]; ok {
+		return key, nil
+	}
+
+	// ключи из базы всегда начинаются с pk_, остальное не стоит запроса в базу
+	if !strings.HasPrefix(secret, _apiKeyPrefix) {
+		return entity.ApiKey{}, ErrUnauthorized
+	}
+
+	key, err := aku.repo.GetApiKeyByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, ErrNoApiKey) {
+			return entity.ApiKey{}, ErrUnauthorized
+		}
+
+		return entity.ApiKey{}, fmt.Errorf("ApiKeyUseCase_Authenticate: %w", err)
+	}
+
+	if !key.Active(time.Now()) {
+		return entity.ApiKey{}, ErrUnauthorized
+	}
+
+	return key, nil
+}
+
+func keyPrefix(key string) string {
+	return key[:len(_apiKeyPrefix)+_apiKeyShown]
+}
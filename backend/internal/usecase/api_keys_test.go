@@ -0,0 +1,20 @@
+package usecase
+
+import "testing"
+
+func TestCheckStaticKeys(t *testing.T) {
+	for _, tc := range []struct {
+		key     string
+		wantErr bool
+	}{
+		{key: ""},
+		{key: "9c1e4b7f0a2d8e6c5b3a1f0e9d8c7b6a"},
+		{key: "change-me-pinger-key", wantErr: true},
+		{key: "CHANGE-ME", wantErr: true},
+	} {
+		err := CheckStaticKeys(StaticApiKey{Name: "INGEST_API_KEY", Key: tc.key})
+		if (err != nil) != tc.wantErr {
+			t.Errorf("CheckStaticKeys(%q) error = %v, wantErr %t", tc.key, err, tc.wantErr)
+		}
+	}
+}
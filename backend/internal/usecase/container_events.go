@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+)
+
+const (
+	_defaultEventsLimit = 100
+	_maxEventsLimit     = 1000
+)
+
+// _containerIdRe - id Docker-контейнера или его префикс, как в docker ps.
+var _containerIdRe = regexp.MustCompile(`^[0-9a-f]{4,64}$`)
+
+var _containerEventTypes = map[string]bool{
+	entity.ContainerEventCreated:             true,
+	entity.ContainerEventStarted:             true,
+	entity.ContainerEventDied:                true,
+	entity.ContainerEventRestarted:           true,
+	entity.ContainerEventOOMKilled:           true,
+	entity.ContainerEventCrashLoop:           true,
+	entity.ContainerEventHealthStatus:        true,
+	entity.ContainerEventNetworkConnected:    true,
+	entity.ContainerEventNetworkDisconnected: true,
+	entity.ContainerEventIPChanged:           true,
+}
+
+type ContainerEventUseCase struct {
+	repo      ContainerEventRepo
+	publisher EventPublisher
+	observer  ContainerEventObserver
+	l         logger.Logger
+}
+
+func NewContainerEvents(r ContainerEventRepo, p EventPublisher, o ContainerEventObserver, l logger.Logger) *ContainerEventUseCase {
+	return &ContainerEventUseCase{
+		repo:      r,
+		publisher: p,
+		observer:  o,
+		l:         l,
+	}
+}
+
+// AddEvent сохраняет событие и рассылает его подписчикам стрима, чтобы по нему срабатывали оповещения.
+// Событие без ip хранится по id контейнера.
+// Ошибка рассылки после записи только пишется в лог, как в ContainerUseCase.
+func (ceu *ContainerEventUseCase) AddEvent(ctx context.Context, event entity.ContainerEvent) (entity.ContainerEvent, error) {
+	if event.IpAddr != "" {
+		ip, err := canonicalIp(event.IpAddr)
+		if err != nil {
+			return entity.ContainerEvent{}, fmt.Errorf("ContainerEventUseCase_AddEvent: %w", err)
+		}
+		event.IpAddr = ip
+	}
+
+	var v validator
+	v.check(_containerEventTypes[event.Type], "type", "unknown event type %q", event.Type)
+	v.check(_containerIdRe.MatchString(event.ContainerId), "container_id", "must be a docker container id")
+	v.check(event.RestartCount >= 0, "restart_count", "must not be negative")
+	if err := v.err(); err != nil {
+		return entity.ContainerEvent{}, fmt.Errorf("ContainerEventUseCase_AddEvent: %w", err)
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	var err error
+
+	event.Id, err = ceu.repo.AddEvent(ctx, event)
+	if err != nil {
+		return entity.ContainerEvent{}, fmt.Errorf("ContainerEventUseCase_AddEvent: %w", err)
+	}
+
+	ceu.observer.ObserveContainerEvent(event)
+
+	err = ceu.publisher.Publish(ctx, entity.Event{
+		Type:      entity.EventLifecycle,
+		IpAddr:    event.IpAddr,
+		Time:      event.Time,
+		Lifecycle: &event,
+	})
+	if err != nil {
+		ceu.l.Error(ctx, fmt.Sprintf("ContainerEventUseCase_AddEvent - publish %s: %s", entity.EventLifecycle, err))
+	}
+
+	return event, nil
+}
+
+// Events возвращает страницу хронологии контейнера, по умолчанию новые события первыми.
+func (ceu *ContainerEventUseCase) Events(ctx context.Context, query entity.ContainerEventQuery) (entity.ContainerEventPage, error) {
+	switch {
+	case query.IpAddr != "":
+		ip, err := canonicalIp(query.IpAddr)
+		if err != nil {
+			return entity.ContainerEventPage{}, fmt.Errorf("ContainerEventUseCase_Events: %w", err)
+		}
+		query.IpAddr = ip
+	case !_containerIdRe.MatchString(query.ContainerId):
+		return entity.ContainerEventPage{}, fmt.Errorf("ContainerEventUseCase_Events: %w",
+			ErrBadQuery.Field("id", "%q is neither an ip address nor a container id", query.ContainerId))
+	}
+
+	switch {
+	case query.Limit == 0:
+		query.Limit = _defaultEventsLimit
+	case query.Limit < 0 || query.Limit > _maxEventsLimit:
+		return entity.ContainerEventPage{}, fmt.Errorf("ContainerEventUseCase_Events: %w",
+			ErrBadQuery.Field("limit", "must be between 1 and %d, got %d", _maxEventsLimit, query.Limit))
+	}
+
+	page, err := ceu.repo.GetEvents(ctx, query)
+	if err != nil {
+		return entity.ContainerEventPage{}, fmt.Errorf("ContainerEventUseCase_Events: %w", err)
+	}
+
+	return page, nil
+}
@@ -2,21 +2,43 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+
 	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/pkg/logger"
+)
+
+const (
+	_defaultContainersLimit = 100
+	_maxContainersLimit     = 1000
 )
 
 type ContainerUseCase struct {
-	repo ContainerRepo
+	repo      ContainerRepo
+	publisher EventPublisher
+	observer  PingObserver
+	silences  SilenceChecker
+	l         logger.Logger
 }
 
-func New(r ContainerRepo) *ContainerUseCase {
+func New(r ContainerRepo, p EventPublisher, o PingObserver, s SilenceChecker, l logger.Logger) *ContainerUseCase {
 	return &ContainerUseCase{
-		repo: r,
+		repo:      r,
+		publisher: p,
+		observer:  o,
+		silences:  s,
+		l:         l,
 	}
 }
 
 func (cus *ContainerUseCase) Container(ctx context.Context, ip string) (entity.Container, error) {
+	ip, err := canonicalIp(ip)
+	if err != nil {
+		return entity.Container{}, fmt.Errorf("ContainerUseCase_Container: %w", err)
+	}
+
 	container, err := cus.repo.GetContainer(ctx, ip)
 	if err != nil {
 		return entity.Container{}, fmt.Errorf("ContainerUseCase_Container: %w", err)
@@ -34,33 +56,211 @@ func (cus *ContainerUseCase) AllContainers(ctx context.Context) ([]entity.Contai
 	return containers, nil
 }
 
+// ListContainers возвращает страницу списка контейнеров, по умолчанию отсортированного по ip.
+func (cus *ContainerUseCase) ListContainers(ctx context.Context, query entity.ContainerQuery) (entity.ContainerPage, error) {
+	switch query.Status {
+	case "", entity.StatusUp, entity.StatusDown:
+	default:
+		return entity.ContainerPage{}, fmt.Errorf("ContainerUseCase_ListContainers: %w",
+			ErrBadQuery.Field("status", "must be %s or %s, got %q", entity.StatusUp, entity.StatusDown, query.Status))
+	}
+
+	switch query.Sort {
+	case "":
+		query.Sort = entity.SortIp
+	case entity.SortIp, entity.SortLatency, entity.SortLastSuccessful, entity.SortStatus, entity.SortName:
+	default:
+		return entity.ContainerPage{}, fmt.Errorf("ContainerUseCase_ListContainers: %w", ErrBadQuery.Field("sort", "unknown sort %q", query.Sort))
+	}
+
+	if query.MinLatency != nil && *query.MinLatency < 0 {
+		return entity.ContainerPage{}, fmt.Errorf("ContainerUseCase_ListContainers: %w", ErrBadQuery.Field("min_latency", "must not be negative"))
+	}
+	if query.MaxLatency != nil && (*query.MaxLatency < 0 || query.MinLatency != nil && *query.MinLatency > *query.MaxLatency) {
+		return entity.ContainerPage{}, fmt.Errorf("ContainerUseCase_ListContainers: %w",
+			ErrBadQuery.Field("max_latency", "must not be negative or less than min_latency"))
+	}
+
+	switch {
+	case query.Limit == 0:
+		query.Limit = _defaultContainersLimit
+	case query.Limit < 0 || query.Limit > _maxContainersLimit:
+		return entity.ContainerPage{}, fmt.Errorf("ContainerUseCase_ListContainers: %w",
+			ErrBadQuery.Field("limit", "must be between 1 and %d, got %d", _maxContainersLimit, query.Limit))
+	}
+
+	page, err := cus.repo.GetContainers(ctx, query)
+	if err != nil {
+		return entity.ContainerPage{}, fmt.Errorf("ContainerUseCase_ListContainers: %w", err)
+	}
+
+	return page, nil
+}
+
 func (cus *ContainerUseCase) NewContainer(ctx context.Context, pingContainer entity.Container) (string, error) {
-	ip, err := cus.repo.AddContainer(ctx, entity.Container{
-		IpAddr:         pingContainer.IpAddr,
+	ipAddr, err := canonicalIp(pingContainer.IpAddr)
+	if err != nil {
+		return "", fmt.Errorf("ContainerUseCase_NewContainer: %w", err)
+	}
+
+	if err = validateContainer(pingContainer); err != nil {
+		return "", fmt.Errorf("ContainerUseCase_NewContainer: %w", err)
+	}
+
+	container := entity.Container{
+		IpAddr:         ipAddr,
 		PingTime:       pingContainer.PingTime,
-		LastSuccessful: pingContainer.LastSuccessful,
-	})
+		IsSuccessful:   pingContainer.IsSuccessful,
+		LastSuccessful: knownTime(pingContainer.LastSuccessful),
+		Name:           pingContainer.Name,
+		Image:          pingContainer.Image,
+		Labels:         pingContainer.Labels,
+	}
+
+	if container.Labels == nil {
+		container.Labels = map[string]string{}
+	}
+
+	if !container.IsSuccessful {
+		container.ConsecutiveFailures = 1
+	}
+
+	ip, err := cus.repo.AddContainer(ctx, container)
 	if err != nil {
 		return "", fmt.Errorf("ContainerUseCase_NewContainer: %w", err)
 	}
 
+	cus.observer.ObservePing(container)
+
+	events := []string{entity.EventContainerUpdated}
+	if !container.IsSuccessful {
+		events = append(events, entity.EventIncident)
+	}
+
+	cus.publish(ctx, "ContainerUseCase_NewContainer", container, events...)
+
 	return ip, nil
 }
 
 func (cus *ContainerUseCase) UpdateContainer(ctx context.Context, container entity.Container) (entity.Container, error) {
+	ipAddr, err := canonicalIp(container.IpAddr)
+	if err != nil {
+		return entity.Container{}, fmt.Errorf("ContainerUseCase_UpdateContainer: %w", err)
+	}
+	container.IpAddr = ipAddr
+	container.LastSuccessful = knownTime(container.LastSuccessful)
+
+	if err = validateContainer(container); err != nil {
+		return entity.Container{}, fmt.Errorf("ContainerUseCase_UpdateContainer: %w", err)
+	}
+
+	prev, err := cus.repo.GetContainer(ctx, container.IpAddr)
+	if err != nil && !errors.Is(err, ErrNoIp) {
+		return entity.Container{}, fmt.Errorf("ContainerUseCase_UpdateContainer: %w", err)
+	}
+	known := err == nil
+
 	updateContainer, err := cus.repo.UpdateContainer(ctx, container)
 	if err != nil {
 		return entity.Container{}, fmt.Errorf("ContainerUseCase_UpdateContainer: %w", err)
 	}
 
+	cus.observer.ObservePing(updateContainer)
+
+	events := []string{entity.EventContainerUpdated}
+	if known && prev.IsSuccessful != updateContainer.IsSuccessful {
+		events = append(events, entity.EventStatusChanged)
+
+		if !updateContainer.IsSuccessful {
+			events = append(events, entity.EventIncident)
+		}
+	}
+
+	cus.publish(ctx, "ContainerUseCase_UpdateContainer", updateContainer, events...)
+
 	return updateContainer, nil
 }
 
 func (cus *ContainerUseCase) DeleteContainer(ctx context.Context, ip string) error {
-	err := cus.repo.DeleteContainer(ctx, ip)
+	ip, err := canonicalIp(ip)
+	if err != nil {
+		return fmt.Errorf("ContainerUseCase_DeleteContainer: %w", err)
+	}
+
+	err = cus.repo.DeleteContainer(ctx, ip)
 	if err != nil {
 		return fmt.Errorf("ContainerUseCase_DeleteContainer: %w", err)
 	}
 
+	cus.publish(ctx, "ContainerUseCase_DeleteContainer", entity.Container{IpAddr: ip}, entity.EventContainerDeleted)
+
 	return nil
 }
+
+// publish рассылает события контейнера. Инцидент по контейнеру под тишиной не рассылается.
+// Запись в базе к этому моменту уже сделана, поэтому ошибка рассылки только пишется в лог:
+// ответ с ошибкой заставил бы пингер повторить уже выполненный запрос.
+func (cus *ContainerUseCase) publish(ctx context.Context, op string, container entity.Container, types ...string) {
+	now := time.Now().UTC()
+
+	for _, t := range types {
+		if t == entity.EventIncident {
+			// без ответа о тишине инцидент лучше разослать, чем потерять
+			silenced, err := cus.silences.Silenced(ctx, container.IpAddr)
+			if err != nil {
+				cus.l.Error(ctx, fmt.Sprintf("%s - publish %s - silences.Silenced: %s", op, t, err))
+			}
+			if silenced {
+				continue
+			}
+		}
+
+		status := entity.ContainerStatus(container)
+		if t == entity.EventContainerDeleted {
+			status = ""
+		}
+
+		err := cus.publisher.Publish(ctx, entity.Event{
+			Type:      t,
+			IpAddr:    container.IpAddr,
+			Status:    status,
+			Container: container,
+			Time:      now,
+		})
+		if err != nil {
+			cus.l.Error(ctx, fmt.Sprintf("%s - publish %s: %s", op, t, err))
+		}
+	}
+}
+
+func validateContainer(container entity.Container) error {
+	var v validator
+
+	v.check(container.PingTime >= 0, "ping_time", "must not be negative")
+	v.check(!container.IsSuccessful || container.LastSuccessful != nil, "last_successful", "is required for a successful ping")
+
+	for key := range container.Labels {
+		v.check(key != "", "labels", "keys must not be empty")
+	}
+
+	return v.err()
+}
+
+// knownTime - nil вместо нулевого времени: старые пингеры присылают 0001-01-01, если успешных пингов не было.
+func knownTime(t *time.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+
+	return t
+}
+
+// canonicalIp - один адрес в разной записи (::ffff:10.0.0.1 и 10.0.0.1) должен попадать в одну запись.
+func canonicalIp(ip string) (string, error) {
+	canonical, ok := entity.CanonicalIp(ip)
+	if !ok {
+		return "", ErrBadIp.Field("ip", "%q is not an ip address", ip)
+	}
+
+	return canonical, nil
+}
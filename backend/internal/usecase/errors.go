@@ -1,7 +1,107 @@
 package usecase
 
-import "errors"
+import "fmt"
+
+// ErrorKind - категория ошибки, по ней контроллер выбирает код ответа.
+type ErrorKind int
+
+const (
+	KindValidation ErrorKind = iota + 1
+	KindNotFound
+	KindConflict
+	KindUnauthorized
+	KindForbidden
+	KindUnavailable
+)
+
+// Error - ошибка с машиночитаемым кодом. Ошибки с одним Code равны для errors.Is,
+// поэтому к ним можно добавлять подробности, не теряя сравнения с ErrBadIp и другими.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+// FieldError - ошибка одного поля тела или параметра запроса.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
 
 var (
-	ErrNoIp = errors.New("no ip address")
+	ErrNoIp            = &Error{Kind: KindNotFound, Code: "container_not_found", Message: "container not found"}
+	ErrContainerExists = &Error{Kind: KindConflict, Code: "container_exists", Message: "container already exists"}
+	ErrBadIp           = &Error{Kind: KindValidation, Code: "bad_ip", Message: "bad ip address"}
+	// ErrBadQuery - неверные параметры выборки: курсор, лимит, id контейнера.
+	ErrBadQuery = &Error{Kind: KindValidation, Code: "bad_query", Message: "bad query"}
+	// ErrValidation - неверное тело запроса, подробности по полям в Fields.
+	ErrValidation = &Error{Kind: KindValidation, Code: "validation_failed", Message: "validation failed"}
+	// ErrUnauthorized - ключ не передан, неизвестен, отозван или истёк.
+	ErrUnauthorized = &Error{Kind: KindUnauthorized, Code: "unauthorized", Message: "missing or invalid api key"}
+	// ErrForbidden - у ключа нет права на запрос.
+	ErrForbidden = &Error{Kind: KindForbidden, Code: "forbidden", Message: "api key scope does not allow this request"}
+	ErrNoApiKey  = &Error{Kind: KindNotFound, Code: "api_key_not_found", Message: "api key not found"}
+	// ErrBadCredentials - неверный логин или пароль при входе.
+	ErrBadCredentials = &Error{Kind: KindUnauthorized, Code: "invalid_credentials", Message: "invalid login or password"}
+	// ErrCsrf - изменяющий запрос по cookie сессии без верного заголовка X-CSRF-Token.
+	ErrCsrf       = &Error{Kind: KindForbidden, Code: "csrf_failed", Message: "missing or invalid csrf token"}
+	ErrNoSession  = &Error{Kind: KindUnauthorized, Code: "session_required", Message: "session required"}
+	ErrNoUser     = &Error{Kind: KindNotFound, Code: "user_not_found", Message: "user not found"}
+	ErrUserExists = &Error{Kind: KindConflict, Code: "user_exists", Message: "user already exists"}
+	// ErrLastAdmin - нельзя удалить или понизить единственного администратора.
+	ErrLastAdmin = &Error{Kind: KindConflict, Code: "last_admin", Message: "cannot remove the last admin"}
+	// ErrOidcDisabled - вход через провайдера не настроен.
+	ErrOidcDisabled = &Error{Kind: KindNotFound, Code: "oidc_disabled", Message: "single sign-on is not configured"}
+	// ErrOidcFailed - вход через провайдера не начинался, истёк, отклонён провайдером или токен не прошёл проверку.
+	ErrOidcFailed = &Error{Kind: KindUnauthorized, Code: "oidc_failed", Message: "single sign-on failed"}
+	// ErrOidcUnavailable - провайдер не отвечает на discovery.
+	ErrOidcUnavailable = &Error{Kind: KindUnavailable, Code: "oidc_unavailable", Message: "single sign-on provider is unavailable"}
+	// ErrOidcNoRole - ни одна группа пользователя не даёт роли в Pinger.
+	ErrOidcNoRole = &Error{Kind: KindForbidden, Code: "oidc_no_role", Message: "user groups do not grant a pinger role"}
+	ErrNoSilence  = &Error{Kind: KindNotFound, Code: "silence_not_found", Message: "silence not found"}
 )
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+
+	return ok && t.Code == e.Code
+}
+
+// Field возвращает копию e с ошибкой поля field.
+func (e *Error) Field(field, format string, args ...any) *Error {
+	fe := FieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+
+	res := *e
+	res.Message = e.Message + ": " + field + " " + fe.Message
+	res.Fields = append(append([]FieldError(nil), e.Fields...), fe)
+
+	return &res
+}
+
+// validator собирает ошибки всех полей, чтобы клиент увидел их за один запрос.
+type validator struct {
+	fields []FieldError
+}
+
+func (v *validator) check(ok bool, field, format string, args ...any) {
+	if !ok {
+		v.fields = append(v.fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// err возвращает ErrValidation с собранными полями или nil.
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+
+	res := *ErrValidation
+	res.Fields = v.fields
+
+	return &res
+}
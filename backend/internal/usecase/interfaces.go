@@ -3,12 +3,14 @@ package usecase
 import (
 	"context"
 	"github.com/k1v4/Pinger/backend/internal/entity"
+	"time"
 )
 
 type (
 	Container interface {
 		Container(ctx context.Context, ip string) (entity.Container, error)
 		AllContainers(ctx context.Context) ([]entity.Container, error)
+		ListContainers(ctx context.Context, query entity.ContainerQuery) (entity.ContainerPage, error)
 		NewContainer(ctx context.Context, pingContainer entity.Container) (string, error)
 		UpdateContainer(ctx context.Context, container entity.Container) (entity.Container, error)
 		DeleteContainer(ctx context.Context, ip string) error
@@ -16,11 +18,118 @@ type (
 		//History(context.Context) ([]entity.Translation, error)
 	}
 
+	Stats interface {
+		AddStats(ctx context.Context, stats entity.ContainerStats) error
+		Stats(ctx context.Context, ip string, from, to time.Time) ([]entity.ContainerStats, error)
+	}
+
+	ContainerEvents interface {
+		AddEvent(ctx context.Context, event entity.ContainerEvent) (entity.ContainerEvent, error)
+		Events(ctx context.Context, query entity.ContainerEventQuery) (entity.ContainerEventPage, error)
+	}
+
+	ApiKeys interface {
+		CreateKey(ctx context.Context, key entity.ApiKey) (entity.ApiKey, string, error)
+		ApiKeys(ctx context.Context) ([]entity.ApiKey, error)
+		RevokeKey(ctx context.Context, id int64) (entity.ApiKey, error)
+		Authenticate(ctx context.Context, secret string) (entity.ApiKey, error)
+	}
+
+	Users interface {
+		Login(ctx context.Context, login, password string) (entity.User, entity.Session, string, error)
+		Session(ctx context.Context, token string) (entity.User, entity.Session, error)
+		Logout(ctx context.Context, token string) error
+		ChangePassword(ctx context.Context, userId int64, token, current, password string) error
+		Users(ctx context.Context) ([]entity.User, error)
+		CreateUser(ctx context.Context, user entity.User, password string) (entity.User, error)
+		UpdateUser(ctx context.Context, user entity.User, password string) (entity.User, error)
+		DeleteUser(ctx context.Context, id int64) error
+	}
+
+	// Oidc - вход через OpenID Connect провайдера по authorization code с PKCE.
+	Oidc interface {
+		Begin(ctx context.Context) (entity.OidcFlow, string, error)
+		Login(ctx context.Context, flow entity.OidcFlow, state, code string) (entity.User, entity.Session, string, error)
+	}
+
+	Silences interface {
+		AddSilence(ctx context.Context, silence entity.Silence) (entity.Silence, error)
+		Silences(ctx context.Context) ([]entity.Silence, error)
+		DeleteSilence(ctx context.Context, id int64) error
+	}
+
+	Stream interface {
+		Subscribe(filter entity.EventFilter) (<-chan entity.Event, func())
+	}
+
 	ContainerRepo interface {
 		GetContainer(ctx context.Context, ip string) (entity.Container, error)
 		GetAllContainers(ctx context.Context) ([]entity.Container, error)
+		GetContainers(ctx context.Context, query entity.ContainerQuery) (entity.ContainerPage, error)
 		AddContainer(ctx context.Context, container entity.Container) (string, error)
 		UpdateContainer(ctx context.Context, container entity.Container) (entity.Container, error)
 		DeleteContainer(ctx context.Context, ip string) error
 	}
+
+	StatsRepo interface {
+		AddStats(ctx context.Context, stats entity.ContainerStats) error
+		GetStats(ctx context.Context, ip string, from, to time.Time) ([]entity.ContainerStats, error)
+		DeleteStatsBefore(ctx context.Context, before time.Time) (int64, error)
+	}
+
+	ContainerEventRepo interface {
+		AddEvent(ctx context.Context, event entity.ContainerEvent) (int64, error)
+		GetEvents(ctx context.Context, query entity.ContainerEventQuery) (entity.ContainerEventPage, error)
+	}
+
+	ApiKeyRepo interface {
+		AddApiKey(ctx context.Context, key entity.ApiKey, hash string) (int64, error)
+		GetApiKeys(ctx context.Context) ([]entity.ApiKey, error)
+		GetApiKeyByHash(ctx context.Context, hash string) (entity.ApiKey, error)
+		RevokeApiKey(ctx context.Context, id int64, at time.Time) (entity.ApiKey, error)
+	}
+
+	UserRepo interface {
+		AddUser(ctx context.Context, user entity.User, hash string) (int64, error)
+		GetUsers(ctx context.Context) ([]entity.User, error)
+		GetUser(ctx context.Context, id int64) (entity.User, string, error)
+		GetUserByLogin(ctx context.Context, login string) (entity.User, string, error)
+		UpdateUser(ctx context.Context, user entity.User, hash string) (entity.User, error)
+		DeleteUser(ctx context.Context, id int64) error
+		CountAdmins(ctx context.Context) (int, error)
+		AddSession(ctx context.Context, hash string, session entity.Session) error
+		GetSession(ctx context.Context, hash string) (entity.User, entity.Session, error)
+		DeleteSession(ctx context.Context, hash string) error
+		DeleteUserSessions(ctx context.Context, userId int64, exceptHash string) error
+		DeleteSessionsBefore(ctx context.Context, before time.Time) (int64, error)
+		UpsertOidcUser(ctx context.Context, user entity.User, subject string) (entity.User, error)
+	}
+
+	SilenceRepo interface {
+		AddSilence(ctx context.Context, silence entity.Silence) (int64, error)
+		GetSilences(ctx context.Context, activeAt time.Time) ([]entity.Silence, error)
+		DeleteSilence(ctx context.Context, id int64) error
+		IsSilenced(ctx context.Context, ip string, at time.Time) (bool, error)
+	}
+
+	SilenceChecker interface {
+		Silenced(ctx context.Context, ip string) (bool, error)
+	}
+
+	OidcProvider interface {
+		AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+		Exchange(ctx context.Context, code, verifier, nonce string) (entity.OidcIdentity, error)
+	}
+
+	EventPublisher interface {
+		Publish(ctx context.Context, event entity.Event) error
+	}
+
+	PingObserver interface {
+		ObservePing(container entity.Container)
+	}
+
+	ContainerEventObserver interface {
+		ObserveContainerEvent(event entity.ContainerEvent)
+	}
 )
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+)
+
+// _rolePrecedence - роли по возрастанию прав: из нескольких групп пользователь получает старшую роль.
+var _rolePrecedence = []string{entity.RoleViewer, entity.RoleOperator, entity.RoleAdmin}
+
+type OidcUseCase struct {
+	provider    OidcProvider
+	repo        UserRepo
+	sessionTTL  time.Duration
+	groupRoles  map[string]string
+	defaultRole string
+}
+
+// NewOidc - вход через провайдера p. groupRoles сопоставляет группы провайдера ролям Pinger,
+// defaultRole получают пользователи без сопоставленных групп, с пустой ролью им отказывается во входе.
+func NewOidc(p OidcProvider, r UserRepo, sessionTTL time.Duration, groupRoles map[string]string, defaultRole string) (*OidcUseCase, error) {
+	for group, role := range groupRoles {
+		if !entity.ValidRole(role) {
+			return nil, fmt.Errorf("OidcUseCase_NewOidc: unknown role %q for group %q", role, group)
+		}
+	}
+
+	if defaultRole != "" && !entity.ValidRole(defaultRole) {
+		return nil, fmt.Errorf("OidcUseCase_NewOidc: unknown default role %q", defaultRole)
+	}
+
+	return &OidcUseCase{
+		provider:    p,
+		repo:        r,
+		sessionTTL:  sessionTTL,
+		groupRoles:  groupRoles,
+		defaultRole: defaultRole,
+	}, nil
+}
+
+// Begin начинает вход: возвращает state, nonce и PKCE verifier для браузера и адрес страницы входа провайдера.
+// Если провайдер недоступен, возвращает ErrOidcUnavailable.
+func (ou *OidcUseCase) Begin(ctx context.Context) (entity.OidcFlow, string, error) {
+	var (
+		flow entity.OidcFlow
+		err  error
+	)
+
+	for _, v := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		if *v, err = newToken(""); err != nil {
+			return entity.OidcFlow{}, "", fmt.Errorf("OidcUseCase_Begin: %w", err)
+		}
+	}
+
+	url, err := ou.provider.AuthCodeURL(ctx, flow.State, flow.Nonce, flow.Verifier)
+	if err != nil {
+		return entity.OidcFlow{}, "", fmt.Errorf("OidcUseCase_Begin: %w: %w", ErrOidcUnavailable, err)
+	}
+
+	return flow, url, nil
+}
+
+// Login завершает вход по code с callback: сверяет state, меняет code на ID-токен,
+// назначает роль по группам и открывает сессию. Пользователь создаётся при первом входе,
+// логин и роль обновляются при каждом.
+func (ou *OidcUseCase) Login(ctx context.Context, flow entity.OidcFlow, state, code string) (entity.User, entity.Session, string, error) {
+	if flow.State == "" || subtle.ConstantTimeCompare([]byte(state), []byte(flow.State)) != 1 {
+		return entity.User{}, entity.Session{}, "", ErrOidcFailed.Field("state", "does not match the started login")
+	}
+
+	identity, err := ou.provider.Exchange(ctx, code, flow.Verifier, flow.Nonce)
+	if err != nil {
+		return entity.User{}, entity.Session{}, "", fmt.Errorf("OidcUseCase_Login: %w: %w", ErrOidcFailed, err)
+	}
+
+	if !_loginRe.MatchString(identity.Login) {
+		return entity.User{}, entity.Session{}, "", ErrOidcFailed.Field("login", "must be 1 to 64 letters, digits or ._@-")
+	}
+
+	role := ou.role(identity.Groups)
+	if role == "" {
+		return entity.User{}, entity.Session{}, "", ErrOidcNoRole
+	}
+
+	user, err := ou.repo.UpsertOidcUser(ctx, entity.User{
+		Login:     identity.Login,
+		Role:      role,
+		CreatedAt: time.Now().UTC(),
+	}, identity.Subject)
+	if err != nil {
+		return entity.User{}, entity.Session{}, "", fmt.Errorf("OidcUseCase_Login: %w", err)
+	}
+
+	session, token, err := openSession(ctx, ou.repo, user.Id, ou.sessionTTL)
+	if err != nil {
+		return entity.User{}, entity.Session{}, "", fmt.Errorf("OidcUseCase_Login: %w", err)
+	}
+
+	return user, session, token, nil
+}
+
+// role - старшая из ролей групп пользователя или defaultRole.
+func (ou *OidcUseCase) role(groups []string) string {
+	role := ou.defaultRole
+
+	for _, group := range groups {
+		mapped, ok := ou.groupRoles[group]
+		if ok && slices.Index(_rolePrecedence, mapped) > slices.Index(_rolePrecedence, role) {
+			role = mapped
+		}
+	}
+
+	return role
+}
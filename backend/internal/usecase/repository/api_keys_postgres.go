@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/DB/postgres"
+)
+
+var _apiKeyColumns = []string{"id", "name", "prefix", "scopes", "created_at", "expires_at", "revoked_at"}
+
+type ApiKeyRepo struct {
+	*postgres.Postgres
+}
+
+func NewApiKeyRepo(pg *postgres.Postgres) *ApiKeyRepo {
+	return &ApiKeyRepo{
+		Postgres: pg,
+	}
+}
+
+func (ar *ApiKeyRepo) AddApiKey(ctx context.Context, key entity.ApiKey, hash string) (int64, error) {
+	sql, args, err := ar.Builder.
+		Insert("api_keys").
+		Columns("name", "prefix", "hash", "scopes", "created_at", "expires_at").
+		Values(key.Name, key.Prefix, hash, key.Scopes, key.CreatedAt, key.ExpiresAt).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("ApiKeyRepo-AddApiKey: %w", err)
+	}
+
+	var id int64
+
+	err = ar.Pool.QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ApiKeyRepo-AddApiKey: %w", err)
+	}
+
+	return id, nil
+}
+
+func (ar *ApiKeyRepo) GetApiKeys(ctx context.Context) ([]entity.ApiKey, error) {
+	sql, args, err := ar.Builder.
+		Select(_apiKeyColumns...).
+		From("api_keys").
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("ApiKeyRepo-GetApiKeys: %w", err)
+	}
+
+	rows, err := ar.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ApiKeyRepo-GetApiKeys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]entity.ApiKey, 0)
+
+	for rows.Next() {
+		var key entity.ApiKey
+
+		if err = scanApiKey(rows, &key); err != nil {
+			return nil, fmt.Errorf("ApiKeyRepo-GetApiKeys: %w", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ApiKeyRepo-GetApiKeys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (ar *ApiKeyRepo) GetApiKeyByHash(ctx context.Context, hash string) (entity.ApiKey, error) {
+	sql, args, err := ar.Builder.
+		Select(_apiKeyColumns...).
+		From("api_keys").
+		Where(sq.Eq{"hash": hash}).
+		ToSql()
+	if err != nil {
+		return entity.ApiKey{}, fmt.Errorf("ApiKeyRepo-GetApiKeyByHash: %w", err)
+	}
+
+	var key entity.ApiKey
+
+	err = scanApiKey(ar.Pool.QueryRow(ctx, sql, args...), &key)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.ApiKey{}, usecase.ErrNoApiKey
+		}
+
+		return entity.ApiKey{}, fmt.Errorf("ApiKeyRepo-GetApiKeyByHash: %w", err)
+	}
+
+	return key, nil
+}
+
+// RevokeApiKey ставит время отзыва, если ключ ещё не отозван, и возвращает ключ.
+func (ar *ApiKeyRepo) RevokeApiKey(ctx context.Context, id int64, at time.Time) (entity.ApiKey, error) {
+	sql, args, err := ar.Builder.
+		Update("api_keys").
+		Set("revoked_at", sq.Expr("COALESCE(revoked_at, ?)", at)).
+		Where(sq.Eq{"id": id}).
+		Suffix("RETURNING id, name, prefix, scopes, created_at, expires_at, revoked_at").
+		ToSql()
+	if err != nil {
+		return entity.ApiKey{}, fmt.Errorf("ApiKeyRepo-RevokeApiKey: %w", err)
+	}
+
+	var key entity.ApiKey
+
+	err = scanApiKey(ar.Pool.QueryRow(ctx, sql, args...), &key)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.ApiKey{}, usecase.ErrNoApiKey
+		}
+
+		return entity.ApiKey{}, fmt.Errorf("ApiKeyRepo-RevokeApiKey: %w", err)
+	}
+
+	return key, nil
+}
+
+func scanApiKey(row pgx.Row, key *entity.ApiKey) error {
+	return row.Scan(&key.Id, &key.Name, &key.Prefix, &key.Scopes, &key.CreatedAt, &key.ExpiresAt, &key.RevokedAt)
+}
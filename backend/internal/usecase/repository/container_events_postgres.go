@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/DB/postgres"
+)
+
+// _eventIpColumn - ip события, у событий без ip пустая строка.
+const _eventIpColumn = "COALESCE(host(ip), '') AS ip"
+
+type ContainerEventRepo struct {
+	*postgres.Postgres
+}
+
+func NewContainerEventRepo(pg *postgres.Postgres) *ContainerEventRepo {
+	return &ContainerEventRepo{
+		Postgres: pg,
+	}
+}
+
+func (cer *ContainerEventRepo) AddEvent(ctx context.Context, event entity.ContainerEvent) (int64, error) {
+	var ip any
+	if event.IpAddr != "" {
+		ip = event.IpAddr
+	}
+
+	sql, args, err := cer.Builder.
+		Insert("container_events").
+		Columns("ip", "type", "container_id", "container_name", "image", "docker_host",
+			"exit_code", "restart_count", "message", "time", "reported_by").
+		Values(ip, event.Type, event.ContainerId, event.ContainerName, event.Image, event.DockerHost,
+			event.ExitCode, event.RestartCount, event.Message, event.Time, event.ReportedBy).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("ContainerEventRepo-AddEvent: %w", err)
+	}
+
+	var id int64
+
+	err = cer.Pool.QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ContainerEventRepo-AddEvent: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetEvents отдаёт страницу по ключу (time, id): новые события, пришедшие между запросами,
+// не сдвигают страницы, как при OFFSET.
+func (cer *ContainerEventRepo) GetEvents(ctx context.Context, query entity.ContainerEventQuery) (entity.ContainerEventPage, error) {
+	builder := cer.Builder.
+		Select("id", _eventIpColumn, "type", "container_id", "container_name", "image", "docker_host",
+			"exit_code", "restart_count", "message", "time", "reported_by").
+		From("container_events").
+		Limit(uint64(query.Limit) + 1)
+
+	if query.IpAddr != "" {
+		// события контейнера до получения ip (обычно created) хранятся без ip, их находим по container_id
+		builder = builder.Where(sq.Or{
+			sq.Eq{"ip": query.IpAddr},
+			sq.Expr("container_id IN (SELECT container_id FROM container_events WHERE ip = ?)", query.IpAddr),
+		})
+	} else {
+		builder = builder.Where(sq.Like{"container_id": query.ContainerId + "%"})
+	}
+
+	if len(query.Types) > 0 {
+		builder = builder.Where(sq.Eq{"type": query.Types})
+	}
+	if !query.From.IsZero() {
+		builder = builder.Where(sq.GtOrEq{"time": query.From})
+	}
+	if !query.To.IsZero() {
+		builder = builder.Where(sq.LtOrEq{"time": query.To})
+	}
+
+	if query.Cursor != "" {
+		t, id, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return entity.ContainerEventPage{}, fmt.Errorf("ContainerEventRepo-GetEvents: %w", err)
+		}
+
+		if query.Ascending {
+			builder = builder.Where(sq.Expr("(time, id) > (?, ?)", t, id))
+		} else {
+			builder = builder.Where(sq.Expr("(time, id) < (?, ?)", t, id))
+		}
+	}
+
+	if query.Ascending {
+		builder = builder.OrderBy("time ASC", "id ASC")
+	} else {
+		builder = builder.OrderBy("time DESC", "id DESC")
+	}
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return entity.ContainerEventPage{}, fmt.Errorf("ContainerEventRepo-GetEvents: %w", err)
+	}
+
+	rows, err := cer.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return entity.ContainerEventPage{}, fmt.Errorf("ContainerEventRepo-GetEvents-r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]entity.ContainerEvent, 0, query.Limit+1)
+
+	for rows.Next() {
+		var e entity.ContainerEvent
+
+		err = rows.Scan(&e.Id, &e.IpAddr, &e.Type, &e.ContainerId, &e.ContainerName, &e.Image, &e.DockerHost,
+			&e.ExitCode, &e.RestartCount, &e.Message, &e.Time, &e.ReportedBy)
+		if err != nil {
+			return entity.ContainerEventPage{}, fmt.Errorf("ContainerEventRepo-GetEvents: %w", err)
+		}
+
+		events = append(events, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return entity.ContainerEventPage{}, fmt.Errorf("ContainerEventRepo-GetEvents: %w", err)
+	}
+
+	page := entity.ContainerEventPage{Events: events}
+	if len(events) > query.Limit {
+		page.Events = events[:query.Limit]
+		last := page.Events[query.Limit-1]
+		page.NextCursor = encodeCursor(last.Time, last.Id)
+	}
+
+	return page, nil
+}
+
+// encodeCursor - непрозрачный для клиента курсор "время в наносекундах.id".
+func encodeCursor(t time.Time, id int64) string {
+	return strconv.FormatInt(t.UnixNano(), 10) + "." + strconv.FormatInt(id, 10)
+}
+
+func decodeCursor(cursor string) (time.Time, int64, error) {
+	ns, idStr, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return time.Time{}, 0, usecase.ErrBadQuery.Field("cursor", "malformed cursor")
+	}
+
+	n, err := strconv.ParseInt(ns, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, usecase.ErrBadQuery.Field("cursor", "malformed cursor")
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, usecase.ErrBadQuery.Field("cursor", "malformed cursor")
+	}
+
+	return time.Unix(0, n), id, nil
+}
@@ -3,9 +3,16 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
+
 	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
 	"github.com/k1v4/Pinger/backend/internal/entity"
 	"github.com/k1v4/Pinger/backend/internal/usecase"
@@ -14,6 +21,40 @@ import (
 
 const _defaultEntityCap = 64
 
+// _uniqueViolation - SQLSTATE нарушения уникальности.
+const _uniqueViolation = "23505"
+
+// _ipColumn - ip хранится как inet, а наружу отдаётся адресом без маски.
+const _ipColumn = "host(ip) AS ip"
+
+var _containerColumns = []string{
+	_ipColumn, "ping_time", "last_successful", "is_successful", "consecutive_failures", "name", "image", "labels",
+	"reported_by",
+}
+
+// _neverSucceeded - место в сортировке по last_successful контейнеров без успешных пингов (NULL): раньше всех.
+const _neverSucceeded = "COALESCE(last_successful, '-infinity')"
+
+// _containerSorts - колонка сортировки и её значение у контейнера для курсора.
+var _containerSorts = map[string]struct {
+	column string
+	value  func(c entity.Container) any
+}{
+	entity.SortIp:             {"ip", nil},
+	entity.SortLatency:        {"ping_time", func(c entity.Container) any { return c.PingTime }},
+	entity.SortLastSuccessful: {_neverSucceeded, func(c entity.Container) any { return c.LastSuccessful }},
+	entity.SortStatus:         {"is_successful", func(c entity.Container) any { return c.IsSuccessful }},
+	entity.SortName:           {"name", func(c entity.Container) any { return c.Name }},
+}
+
+// containerCursor - позиция в списке: значение сортировки и ip последнего контейнера страницы.
+type containerCursor struct {
+	Sort       string          `json:"s"`
+	Descending bool            `json:"d,omitempty"`
+	Value      json.RawMessage `json:"v,omitempty"`
+	Ip         string          `json:"ip"`
+}
+
 type ContainerRepo struct {
 	*postgres.Postgres
 }
@@ -26,7 +67,7 @@ func NewContainerRepo(pg *postgres.Postgres) *ContainerRepo {
 
 func (cr *ContainerRepo) GetContainer(ctx context.Context, ip string) (entity.Container, error) {
 	s, args, err := cr.Builder.
-		Select("*").
+		Select(_containerColumns...).
 		From("containers").
 		Where(sq.Eq{"ip": ip}).
 		ToSql()
@@ -40,9 +81,7 @@ func (cr *ContainerRepo) GetContainer(ctx context.Context, ip string) (entity.Co
 
 	var container entity.Container
 
-	err = cr.Pool.
-		QueryRow(ctx, s, args...).
-		Scan(&container.IpAddr, &container.PingTime, &container.LastSuccessful)
+	err = scanContainer(cr.Pool.QueryRow(ctx, s, args...), &container)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return entity.Container{}, usecase.ErrNoIp
@@ -56,9 +95,9 @@ func (cr *ContainerRepo) GetContainer(ctx context.Context, ip string) (entity.Co
 
 func (cr *ContainerRepo) GetAllContainers(ctx context.Context) ([]entity.Container, error) {
 	sql, _, err := cr.Builder.
-		Select("*").
+		Select(_containerColumns...).
 		From("containers").
-		OrderBy("ip ASC").
+		OrderBy("containers.ip ASC").
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("ContainerRepo-GetAllContainers-r.Builder: %w", err)
@@ -75,7 +114,7 @@ func (cr *ContainerRepo) GetAllContainers(ctx context.Context) ([]entity.Contain
 	for rows.Next() {
 		container := entity.Container{}
 
-		err = rows.Scan(&container.IpAddr, &container.PingTime, &container.LastSuccessful)
+		err = scanContainer(rows, &container)
 		if err != nil {
 			return nil, fmt.Errorf("ContainerRepo-GetAllContainers: %w", err)
 		}
@@ -86,11 +125,110 @@ func (cr *ContainerRepo) GetAllContainers(ctx context.Context) ([]entity.Contain
 	return containers, nil
 }
 
+// GetContainers отдаёт страницу списка по ключу (колонка сортировки, ip) и счётчики по всей выборке.
+func (cr *ContainerRepo) GetContainers(ctx context.Context, query entity.ContainerQuery) (entity.ContainerPage, error) {
+	sort, ok := _containerSorts[query.Sort]
+	if !ok {
+		return entity.ContainerPage{}, fmt.Errorf("ContainerRepo-GetContainers: %w", usecase.ErrBadQuery.Field("sort", "unknown sort %q", query.Sort))
+	}
+
+	page := entity.ContainerPage{Containers: make([]entity.Container, 0, query.Limit+1)}
+
+	counts := cr.Builder.
+		Select("count(*)", "count(*) FILTER (WHERE is_successful)").
+		From("containers")
+	counts = containerFilters(counts, query)
+
+	s, args, err := counts.ToSql()
+	if err != nil {
+		return entity.ContainerPage{}, fmt.Errorf("ContainerRepo-GetContainers: %w", err)
+	}
+
+	err = cr.Pool.QueryRow(ctx, s, args...).Scan(&page.Total, &page.Up)
+	if err != nil {
+		return entity.ContainerPage{}, fmt.Errorf("ContainerRepo-GetContainers: %w", err)
+	}
+	page.Down = page.Total - page.Up
+
+	builder := cr.Builder.
+		Select(_containerColumns...).
+		From("containers").
+		Limit(uint64(query.Limit) + 1)
+	builder = containerFilters(builder, query)
+
+	cmp, dir := ">", "ASC"
+	if query.Descending {
+		cmp, dir = "<", "DESC"
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeContainerCursor(query)
+		if err != nil {
+			return entity.ContainerPage{}, fmt.Errorf("ContainerRepo-GetContainers: %w", err)
+		}
+
+		if sort.value == nil {
+			builder = builder.Where(sq.Expr("ip "+cmp+" ?", cursor.Ip))
+		} else {
+			value, err := cursorValue(query.Sort, cursor.Value)
+			if err != nil {
+				return entity.ContainerPage{}, fmt.Errorf("ContainerRepo-GetContainers: %w", err)
+			}
+
+			builder = builder.Where(sq.Expr("("+sort.column+", ip) "+cmp+" (?, ?)", value, cursor.Ip))
+		}
+	}
+
+	if sort.value != nil {
+		builder = builder.OrderBy(sort.column + " " + dir)
+	}
+	builder = builder.OrderBy("containers.ip " + dir)
+
+	s, args, err = builder.ToSql()
+	if err != nil {
+		return entity.ContainerPage{}, fmt.Errorf("ContainerRepo-GetContainers: %w", err)
+	}
+
+	rows, err := cr.Pool.Query(ctx, s, args...)
+	if err != nil {
+		return entity.ContainerPage{}, fmt.Errorf("ContainerRepo-GetContainers-r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		container := entity.Container{}
+
+		err = scanContainer(rows, &container)
+		if err != nil {
+			return entity.ContainerPage{}, fmt.Errorf("ContainerRepo-GetContainers: %w", err)
+		}
+
+		page.Containers = append(page.Containers, container)
+	}
+
+	if err = rows.Err(); err != nil {
+		return entity.ContainerPage{}, fmt.Errorf("ContainerRepo-GetContainers: %w", err)
+	}
+
+	if len(page.Containers) > query.Limit {
+		page.Containers = page.Containers[:query.Limit]
+
+		page.NextCursor, err = encodeContainerCursor(query, page.Containers[query.Limit-1])
+		if err != nil {
+			return entity.ContainerPage{}, fmt.Errorf("ContainerRepo-GetContainers: %w", err)
+		}
+	}
+
+	return page, nil
+}
+
 func (cr *ContainerRepo) AddContainer(ctx context.Context, container entity.Container) (string, error) {
 	sql, args, err := cr.Builder.
 		Insert("containers").
-		Columns("ip", "ping_time", "last_successful").
-		Values(container.IpAddr, container.PingTime, container.LastSuccessful).
+		Columns("ip", "ping_time", "last_successful", "is_successful", "consecutive_failures", "name", "image", "labels",
+			"reported_by").
+		Values(container.IpAddr, container.PingTime, container.LastSuccessful, container.IsSuccessful, container.ConsecutiveFailures,
+			container.Name, container.Image, container.Labels, container.ReportedBy).
 		ToSql()
 	if err != nil {
 		return "", fmt.Errorf("ContainerRepo-AddContainer: %w", err)
@@ -98,24 +236,56 @@ func (cr *ContainerRepo) AddContainer(ctx context.Context, container entity.Cont
 
 	_, err = cr.Pool.Exec(ctx, sql, args...)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == _uniqueViolation {
+			return "", usecase.ErrContainerExists
+		}
+
 		return "", fmt.Errorf("ContainerRepo-AddContainer: %w", err)
 	}
 
 	return container.IpAddr, nil
 }
 
+// UpdateContainer обновляет результат пинга. Имя, образ, метки и отправитель меняются, только если переданы:
+// ручное обновление через API их не затирает.
 func (cr *ContainerRepo) UpdateContainer(ctx context.Context, container entity.Container) (entity.Container, error) {
-	sql, args, err := cr.Builder.Update("containers").
+	builder := cr.Builder.Update("containers").
 		Set("ping_time", container.PingTime).
 		Set("last_successful", container.LastSuccessful).
+		Set("is_successful", container.IsSuccessful).
+		Set("consecutive_failures", sq.Expr(
+			"CASE WHEN ? THEN 0 ELSE consecutive_failures + 1 END", container.IsSuccessful,
+		))
+
+	if container.Name != "" {
+		builder = builder.Set("name", container.Name)
+	}
+	if container.Image != "" {
+		builder = builder.Set("image", container.Image)
+	}
+	if container.Labels != nil {
+		builder = builder.Set("labels", container.Labels)
+	}
+	if container.ReportedBy != "" {
+		builder = builder.Set("reported_by", container.ReportedBy)
+	}
+
+	sql, args, err := builder.
 		Where(sq.Eq{"ip": container.IpAddr}).
+		Suffix("RETURNING consecutive_failures, name, image, labels, reported_by").
 		ToSql()
 	if err != nil {
 		return entity.Container{}, fmt.Errorf("ContainerRepo-UpdateContainer: %w", err)
 	}
 
-	_, err = cr.Pool.Exec(ctx, sql, args...)
+	err = cr.Pool.QueryRow(ctx, sql, args...).Scan(&container.ConsecutiveFailures, &container.Name, &container.Image, &container.Labels,
+		&container.ReportedBy)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Container{}, usecase.ErrNoIp
+		}
+
 		return entity.Container{}, fmt.Errorf("ContainerRepo-UpdateContainer: %w", err)
 	}
 
@@ -128,10 +298,146 @@ func (cr *ContainerRepo) DeleteContainer(ctx context.Context, ip string) error {
 		return fmt.Errorf("ContainerRepo-DeleteContainer: %w", err)
 	}
 
-	_, err = cr.Pool.Exec(ctx, sql, args...)
+	tag, err := cr.Pool.Exec(ctx, sql, args...)
 	if err != nil {
 		return fmt.Errorf("ContainerRepo-DeleteContainer: %w", err)
 	}
 
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrNoIp
+	}
+
 	return nil
 }
+
+func scanContainer(row pgx.Row, c *entity.Container) error {
+	return row.Scan(&c.IpAddr, &c.PingTime, &c.LastSuccessful, &c.IsSuccessful, &c.ConsecutiveFailures,
+		&c.Name, &c.Image, &c.Labels, &c.ReportedBy)
+}
+
+// containerFilters добавляет к выборке фильтры query, общие для страницы и счётчиков.
+func containerFilters(builder sq.SelectBuilder, query entity.ContainerQuery) sq.SelectBuilder {
+	switch query.Status {
+	case entity.StatusUp:
+		builder = builder.Where(sq.Eq{"is_successful": true})
+	case entity.StatusDown:
+		builder = builder.Where(sq.Eq{"is_successful": false})
+	}
+
+	if query.MinLatency != nil {
+		builder = builder.Where(sq.GtOrEq{"ping_time": *query.MinLatency})
+	}
+	if query.MaxLatency != nil {
+		builder = builder.Where(sq.LtOrEq{"ping_time": *query.MaxLatency})
+	}
+
+	if query.Image != "" {
+		if strings.Contains(query.Image, "*") {
+			builder = builder.Where(sq.Like{"image": strings.ReplaceAll(escapeLike(query.Image), "*", "%")})
+		} else {
+			builder = builder.Where(sq.Eq{"image": query.Image})
+		}
+	}
+
+	if query.Name != "" {
+		builder = builder.Where(sq.ILike{"name": "%" + escapeLike(query.Name) + "%"})
+	}
+
+	for _, req := range query.Labels {
+		switch req.Op {
+		case entity.LabelEquals:
+			builder = builder.Where(sq.Expr("labels @> ?", map[string]string{req.Key: req.Value}))
+		case entity.LabelNotEquals:
+			builder = builder.Where(sq.Expr("NOT labels @> ?", map[string]string{req.Key: req.Value}))
+		case entity.LabelExists:
+			// ?? - оператор jsonb ?, экранированный от плейсхолдеров squirrel
+			builder = builder.Where(sq.Expr("labels ?? ?", req.Key))
+		case entity.LabelNotExists:
+			builder = builder.Where(sq.Expr("NOT labels ?? ?", req.Key))
+		}
+	}
+
+	return builder
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы подстрока искалась как есть.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// encodeContainerCursor - непрозрачный для клиента курсор, привязанный к сортировке query.
+func encodeContainerCursor(query entity.ContainerQuery, last entity.Container) (string, error) {
+	cursor := containerCursor{Sort: query.Sort, Descending: query.Descending, Ip: last.IpAddr}
+
+	if value := _containerSorts[query.Sort].value; value != nil {
+		raw, err := json.Marshal(value(last))
+		if err != nil {
+			return "", err
+		}
+		cursor.Value = raw
+	}
+
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeContainerCursor(query entity.ContainerQuery) (containerCursor, error) {
+	var cursor containerCursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err != nil || json.Unmarshal(raw, &cursor) != nil {
+		return containerCursor{}, usecase.ErrBadQuery.Field("cursor", "malformed cursor")
+	}
+
+	if cursor.Sort != query.Sort || cursor.Descending != query.Descending {
+		return containerCursor{}, usecase.ErrBadQuery.Field("cursor", "was issued for another sort or order")
+	}
+
+	if _, ok := entity.CanonicalIp(cursor.Ip); !ok {
+		return containerCursor{}, usecase.ErrBadQuery.Field("cursor", "malformed cursor")
+	}
+
+	return cursor, nil
+}
+
+// cursorValue - значение сортировки из курсора в типе колонки.
+func cursorValue(sort string, raw json.RawMessage) (any, error) {
+	var (
+		value any
+		err   error
+	)
+
+	switch sort {
+	case entity.SortLatency:
+		var v int
+		err = json.Unmarshal(raw, &v)
+		value = v
+	case entity.SortLastSuccessful:
+		var v *time.Time
+		err = json.Unmarshal(raw, &v)
+		value = v
+		if v == nil {
+			value = pgtype.Timestamp{Status: pgtype.Present, InfinityModifier: pgtype.NegativeInfinity}
+		}
+	case entity.SortStatus:
+		var v bool
+		err = json.Unmarshal(raw, &v)
+		value = v
+	case entity.SortName:
+		var v string
+		err = json.Unmarshal(raw, &v)
+		value = v
+	default:
+		err = fmt.Errorf("unknown sort %q", sort)
+	}
+
+	if err != nil {
+		return nil, usecase.ErrBadQuery.Field("cursor", "malformed cursor value: %s", err)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/pkg/DB/postgres"
+)
+
+const _eventsChannel = "pinger_events"
+
+type EventRepo struct {
+	*postgres.Postgres
+}
+
+func NewEventRepo(pg *postgres.Postgres) *EventRepo {
+	return &EventRepo{
+		Postgres: pg,
+	}
+}
+
+// Publish отправляет событие через NOTIFY, его получат все реплики, включая текущую.
+func (er *EventRepo) Publish(ctx context.Context, event entity.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("EventRepo-Publish-json.Marshal: %w", err)
+	}
+
+	_, err = er.Pool.Exec(ctx, "SELECT pg_notify($1, $2)", _eventsChannel, string(payload))
+	if err != nil {
+		return fmt.Errorf("EventRepo-Publish: %w", err)
+	}
+
+	return nil
+}
+
+// Listen передаёт в handle события, опубликованные любой репликой, до отмены ctx.
+func (er *EventRepo) Listen(ctx context.Context, handle func(entity.Event)) error {
+	return er.Postgres.Listen(ctx, _eventsChannel, func(payload string) {
+		var event entity.Event
+
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			return
+		}
+
+		handle(event)
+	})
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/DB/postgres"
+)
+
+type SilenceRepo struct {
+	*postgres.Postgres
+}
+
+func NewSilenceRepo(pg *postgres.Postgres) *SilenceRepo {
+	return &SilenceRepo{
+		Postgres: pg,
+	}
+}
+
+func (sr *SilenceRepo) AddSilence(ctx context.Context, silence entity.Silence) (int64, error) {
+	sql, args, err := sr.Builder.
+		Insert("silences").
+		Columns("ip", "comment", "created_by", "created_at", "until").
+		Values(silence.IpAddr, silence.Comment, silence.CreatedBy, silence.CreatedAt, silence.Until).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("SilenceRepo-AddSilence: %w", err)
+	}
+
+	var id int64
+
+	err = sr.Pool.QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("SilenceRepo-AddSilence: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetSilences отдаёт тишины, действующие в момент activeAt.
+func (sr *SilenceRepo) GetSilences(ctx context.Context, activeAt time.Time) ([]entity.Silence, error) {
+	sql, args, err := sr.Builder.
+		Select("id", _ipColumn, "comment", "created_by", "created_at", "until").
+		From("silences").
+		Where(sq.Gt{"until": activeAt}).
+		OrderBy("until", "id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SilenceRepo-GetSilences: %w", err)
+	}
+
+	rows, err := sr.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("SilenceRepo-GetSilences: %w", err)
+	}
+	defer rows.Close()
+
+	silences := make([]entity.Silence, 0)
+
+	for rows.Next() {
+		var s entity.Silence
+
+		err = rows.Scan(&s.Id, &s.IpAddr, &s.Comment, &s.CreatedBy, &s.CreatedAt, &s.Until)
+		if err != nil {
+			return nil, fmt.Errorf("SilenceRepo-GetSilences: %w", err)
+		}
+
+		silences = append(silences, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("SilenceRepo-GetSilences: %w", err)
+	}
+
+	return silences, nil
+}
+
+func (sr *SilenceRepo) DeleteSilence(ctx context.Context, id int64) error {
+	sql, args, err := sr.Builder.
+		Delete("silences").
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("SilenceRepo-DeleteSilence: %w", err)
+	}
+
+	tag, err := sr.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("SilenceRepo-DeleteSilence: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrNoSilence
+	}
+
+	return nil
+}
+
+func (sr *SilenceRepo) IsSilenced(ctx context.Context, ip string, at time.Time) (bool, error) {
+	sql, args, err := sr.Builder.
+		Select("1").
+		From("silences").
+		Where(sq.Eq{"ip": ip}).
+		Where(sq.Gt{"until": at}).
+		Prefix("SELECT EXISTS (").
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("SilenceRepo-IsSilenced: %w", err)
+	}
+
+	var silenced bool
+
+	err = sr.Pool.QueryRow(ctx, sql, args...).Scan(&silenced)
+	if err != nil {
+		return false, fmt.Errorf("SilenceRepo-IsSilenced: %w", err)
+	}
+
+	return silenced, nil
+}
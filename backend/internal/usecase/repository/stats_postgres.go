@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/pkg/DB/postgres"
+)
+
+type StatsRepo struct {
+	*postgres.Postgres
+}
+
+func NewStatsRepo(pg *postgres.Postgres) *StatsRepo {
+	return &StatsRepo{
+		Postgres: pg,
+	}
+}
+
+// AddStats сохраняет снимок вместе с последней задержкой пинга контейнера.
+func (sr *StatsRepo) AddStats(ctx context.Context, stats entity.ContainerStats) error {
+	sql, args, err := sr.Builder.
+		Insert("container_stats").
+		Columns("ip", "time", "cpu_percent", "memory_usage", "memory_limit",
+			"net_rx_rate", "net_tx_rate", "block_read_rate", "block_write_rate", "ping_time", "reported_by").
+		Values(stats.IpAddr, stats.Time, stats.CPUPercent, int64(stats.MemoryUsage), int64(stats.MemoryLimit),
+			stats.NetRxRate, stats.NetTxRate, stats.BlockReadRate, stats.BlockWriteRate,
+			sq.Expr("(SELECT ping_time FROM containers WHERE ip = ?)", stats.IpAddr), stats.ReportedBy,
+		).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("StatsRepo-AddStats: %w", err)
+	}
+
+	_, err = sr.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("StatsRepo-AddStats: %w", err)
+	}
+
+	return nil
+}
+
+func (sr *StatsRepo) GetStats(ctx context.Context, ip string, from, to time.Time) ([]entity.ContainerStats, error) {
+	sql, args, err := sr.Builder.
+		Select(_ipColumn, "time", "cpu_percent", "memory_usage", "memory_limit",
+			"net_rx_rate", "net_tx_rate", "block_read_rate", "block_write_rate", "ping_time", "reported_by").
+		From("container_stats").
+		Where(sq.Eq{"ip": ip}).
+		Where(sq.GtOrEq{"time": from}).
+		Where(sq.LtOrEq{"time": to}).
+		OrderBy("time ASC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("StatsRepo-GetStats: %w", err)
+	}
+
+	rows, err := sr.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("StatsRepo-GetStats-r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make([]entity.ContainerStats, 0, _defaultEntityCap)
+
+	for rows.Next() {
+		var (
+			s                        entity.ContainerStats
+			memoryUsage, memoryLimit int64
+		)
+
+		err = rows.Scan(&s.IpAddr, &s.Time, &s.CPUPercent, &memoryUsage, &memoryLimit,
+			&s.NetRxRate, &s.NetTxRate, &s.BlockReadRate, &s.BlockWriteRate, &s.PingTime, &s.ReportedBy)
+		if err != nil {
+			return nil, fmt.Errorf("StatsRepo-GetStats: %w", err)
+		}
+
+		s.MemoryUsage, s.MemoryLimit = uint64(memoryUsage), uint64(memoryLimit)
+		stats = append(stats, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("StatsRepo-GetStats: %w", err)
+	}
+
+	return stats, nil
+}
+
+func (sr *StatsRepo) DeleteStatsBefore(ctx context.Context, before time.Time) (int64, error) {
+	sql, args, err := sr.Builder.Delete("container_stats").Where(sq.Lt{"time": before}).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("StatsRepo-DeleteStatsBefore: %w", err)
+	}
+
+	tag, err := sr.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("StatsRepo-DeleteStatsBefore: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"github.com/k1v4/Pinger/backend/internal/usecase"
+	"github.com/k1v4/Pinger/backend/pkg/DB/postgres"
+)
+
+type UserRepo struct {
+	*postgres.Postgres
+}
+
+func NewUserRepo(pg *postgres.Postgres) *UserRepo {
+	return &UserRepo{
+		Postgres: pg,
+	}
+}
+
+func (ur *UserRepo) AddUser(ctx context.Context, user entity.User, hash string) (int64, error) {
+	sql, args, err := ur.Builder.
+		Insert("users").
+		Columns("login", "password_hash", "role", "created_at").
+		Values(user.Login, hash, user.Role, user.CreatedAt).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo-AddUser: %w", err)
+	}
+
+	var id int64
+
+	err = ur.Pool.QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == _uniqueViolation {
+			return 0, usecase.ErrUserExists
+		}
+
+		return 0, fmt.Errorf("UserRepo-AddUser: %w", err)
+	}
+
+	return id, nil
+}
+
+func (ur *UserRepo) GetUsers(ctx context.Context) ([]entity.User, error) {
+	sql, args, err := ur.Builder.
+		Select("id", "login", "role", "created_at").
+		From("users").
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("UserRepo-GetUsers: %w", err)
+	}
+
+	rows, err := ur.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("UserRepo-GetUsers: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]entity.User, 0)
+
+	for rows.Next() {
+		var user entity.User
+
+		if err = rows.Scan(&user.Id, &user.Login, &user.Role, &user.CreatedAt); err != nil {
+			return nil, fmt.Errorf("UserRepo-GetUsers: %w", err)
+		}
+
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("UserRepo-GetUsers: %w", err)
+	}
+
+	return users, nil
+}
+
+// GetUser отдаёт пользователя и хеш его пароля.
+func (ur *UserRepo) GetUser(ctx context.Context, id int64) (entity.User, string, error) {
+	user, hash, err := ur.getUser(ctx, sq.Eq{"id": id})
+	if err != nil {
+		return entity.User{}, "", fmt.Errorf("UserRepo-GetUser: %w", err)
+	}
+
+	return user, hash, nil
+}
+
+// GetUserByLogin отдаёт пользователя и хеш его пароля.
+func (ur *UserRepo) GetUserByLogin(ctx context.Context, login string) (entity.User, string, error) {
+	user, hash, err := ur.getUser(ctx, sq.Eq{"login": login})
+	if err != nil {
+		return entity.User{}, "", fmt.Errorf("UserRepo-GetUserByLogin: %w", err)
+	}
+
+	return user, hash, nil
+}
+
+func (ur *UserRepo) getUser(ctx context.Context, where sq.Eq) (entity.User, string, error) {
+	sql, args, err := ur.Builder.
+		Select("id", "login", "role", "created_at", "password_hash").
+		From("users").
+		Where(where).
+		ToSql()
+	if err != nil {
+		return entity.User{}, "", err
+	}
+
+	var (
+		user entity.User
+		hash string
+	)
+
+	err = ur.Pool.QueryRow(ctx, sql, args...).Scan(&user.Id, &user.Login, &user.Role, &user.CreatedAt, &hash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.User{}, "", usecase.ErrNoUser
+		}
+
+		return entity.User{}, "", err
+	}
+
+	return user, hash, nil
+}
+
+// UpdateUser меняет роль и хеш пароля, если они переданы, и возвращает пользователя.
+func (ur *UserRepo) UpdateUser(ctx context.Context, user entity.User, hash string) (entity.User, error) {
+	if user.Role == "" && hash == "" {
+		user, _, err := ur.getUser(ctx, sq.Eq{"id": user.Id})
+		if err != nil {
+			return entity.User{}, fmt.Errorf("UserRepo-UpdateUser: %w", err)
+		}
+
+		return user, nil
+	}
+
+	builder := ur.Builder.
+		Update("users").
+		Where(sq.Eq{"id": user.Id}).
+		Suffix("RETURNING id, login, role, created_at")
+
+	if user.Role != "" {
+		builder = builder.Set("role", user.Role)
+	}
+	if hash != "" {
+		builder = builder.Set("password_hash", hash)
+	}
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return entity.User{}, fmt.Errorf("UserRepo-UpdateUser: %w", err)
+	}
+
+	err = ur.Pool.QueryRow(ctx, sql, args...).Scan(&user.Id, &user.Login, &user.Role, &user.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.User{}, usecase.ErrNoUser
+		}
+
+		return entity.User{}, fmt.Errorf("UserRepo-UpdateUser: %w", err)
+	}
+
+	return user, nil
+}
+
+// DeleteUser удаляет пользователя, его сессии удаляются каскадно.
+func (ur *UserRepo) DeleteUser(ctx context.Context, id int64) error {
+	sql, args, err := ur.Builder.
+		Delete("users").
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("UserRepo-DeleteUser: %w", err)
+	}
+
+	tag, err := ur.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("UserRepo-DeleteUser: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrNoUser
+	}
+
+	return nil
+}
+
+func (ur *UserRepo) CountAdmins(ctx context.Context) (int, error) {
+	sql, args, err := ur.Builder.
+		Select("COUNT(*)").
+		From("users").
+		Where(sq.Eq{"role": entity.RoleAdmin}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo-CountAdmins: %w", err)
+	}
+
+	var n int
+
+	err = ur.Pool.QueryRow(ctx, sql, args...).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo-CountAdmins: %w", err)
+	}
+
+	return n, nil
+}
+
+// UpsertOidcUser создаёт пользователя провайдера с id subject или обновляет его логин и роль.
+// Пароля у такого пользователя нет: пустой хеш не совпадёт ни с одним паролем.
+func (ur *UserRepo) UpsertOidcUser(ctx context.Context, user entity.User, subject string) (entity.User, error) {
+	sql, args, err := ur.Builder.
+		Insert("users").
+		Columns("login", "password_hash", "role", "created_at", "oidc_subject").
+		Values(user.Login, "", user.Role, user.CreatedAt, subject).
+		Suffix("ON CONFLICT (oidc_subject) DO UPDATE SET login = EXCLUDED.login, role = EXCLUDED.role").
+		Suffix("RETURNING id, login, role, created_at").
+		ToSql()
+	if err != nil {
+		return entity.User{}, fmt.Errorf("UserRepo-UpsertOidcUser: %w", err)
+	}
+
+	err = ur.Pool.QueryRow(ctx, sql, args...).Scan(&user.Id, &user.Login, &user.Role, &user.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == _uniqueViolation {
+			return entity.User{}, usecase.ErrUserExists
+		}
+
+		return entity.User{}, fmt.Errorf("UserRepo-UpsertOidcUser: %w", err)
+	}
+
+	return user, nil
+}
+
+func (ur *UserRepo) AddSession(ctx context.Context, hash string, session entity.Session) error {
+	sql, args, err := ur.Builder.
+		Insert("sessions").
+		Columns("hash", "user_id", "csrf_token", "created_at", "expires_at").
+		Values(hash, session.UserId, session.CsrfToken, session.CreatedAt, session.ExpiresAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("UserRepo-AddSession: %w", err)
+	}
+
+	_, err = ur.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("UserRepo-AddSession: %w", err)
+	}
+
+	return nil
+}
+
+// GetSession отдаёт сессию вместе с пользователем: роль берётся на момент запроса.
+func (ur *UserRepo) GetSession(ctx context.Context, hash string) (entity.User, entity.Session, error) {
+	sql, args, err := ur.Builder.
+		Select("u.id", "u.login", "u.role", "u.created_at", "s.csrf_token", "s.created_at", "s.expires_at").
+		From("sessions s").
+		Join("users u ON u.id = s.user_id").
+		Where(sq.Eq{"s.hash": hash}).
+		ToSql()
+	if err != nil {
+		return entity.User{}, entity.Session{}, fmt.Errorf("UserRepo-GetSession: %w", err)
+	}
+
+	var (
+		user    entity.User
+		session entity.Session
+	)
+
+	err = ur.Pool.QueryRow(ctx, sql, args...).Scan(&user.Id, &user.Login, &user.Role, &user.CreatedAt,
+		&session.CsrfToken, &session.CreatedAt, &session.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.User{}, entity.Session{}, usecase.ErrNoSession
+		}
+
+		return entity.User{}, entity.Session{}, fmt.Errorf("UserRepo-GetSession: %w", err)
+	}
+
+	session.UserId = user.Id
+
+	return user, session, nil
+}
+
+func (ur *UserRepo) DeleteSession(ctx context.Context, hash string) error {
+	sql, args, err := ur.Builder.
+		Delete("sessions").
+		Where(sq.Eq{"hash": hash}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("UserRepo-DeleteSession: %w", err)
+	}
+
+	_, err = ur.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("UserRepo-DeleteSession: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteUserSessions закрывает все сессии пользователя, кроме exceptHash.
+func (ur *UserRepo) DeleteUserSessions(ctx context.Context, userId int64, exceptHash string) error {
+	sql, args, err := ur.Builder.
+		Delete("sessions").
+		Where(sq.Eq{"user_id": userId}).
+		Where(sq.NotEq{"hash": exceptHash}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("UserRepo-DeleteUserSessions: %w", err)
+	}
+
+	_, err = ur.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("UserRepo-DeleteUserSessions: %w", err)
+	}
+
+	return nil
+}
+
+func (ur *UserRepo) DeleteSessionsBefore(ctx context.Context, before time.Time) (int64, error) {
+	sql, args, err := ur.Builder.
+		Delete("sessions").
+		Where(sq.Lt{"expires_at": before}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo-DeleteSessionsBefore: %w", err)
+	}
+
+	tag, err := ur.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo-DeleteSessionsBefore: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+)
+
+const _maxSilenceCommentLen = 500
+
+type SilenceUseCase struct {
+	repo SilenceRepo
+}
+
+func NewSilences(r SilenceRepo) *SilenceUseCase {
+	return &SilenceUseCase{
+		repo: r,
+	}
+}
+
+func (su *SilenceUseCase) AddSilence(ctx context.Context, silence entity.Silence) (entity.Silence, error) {
+	ip, err := canonicalIp(silence.IpAddr)
+	if err != nil {
+		return entity.Silence{}, fmt.Errorf("SilenceUseCase_AddSilence: %w", err)
+	}
+	silence.IpAddr = ip
+
+	now := time.Now().UTC()
+
+	var v validator
+	v.check(silence.Until.After(now), "until", "must be in the future")
+	v.check(len(silence.Comment) <= _maxSilenceCommentLen, "comment", "must be at most %d characters", _maxSilenceCommentLen)
+	if err = v.err(); err != nil {
+		return entity.Silence{}, fmt.Errorf("SilenceUseCase_AddSilence: %w", err)
+	}
+
+	silence.CreatedAt = now
+
+	silence.Id, err = su.repo.AddSilence(ctx, silence)
+	if err != nil {
+		return entity.Silence{}, fmt.Errorf("SilenceUseCase_AddSilence: %w", err)
+	}
+
+	return silence, nil
+}
+
+// Silences отдаёт действующие сейчас тишины.
+func (su *SilenceUseCase) Silences(ctx context.Context) ([]entity.Silence, error) {
+	silences, err := su.repo.GetSilences(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("SilenceUseCase_Silences: %w", err)
+	}
+
+	return silences, nil
+}
+
+func (su *SilenceUseCase) DeleteSilence(ctx context.Context, id int64) error {
+	if err := su.repo.DeleteSilence(ctx, id); err != nil {
+		return fmt.Errorf("SilenceUseCase_DeleteSilence: %w", err)
+	}
+
+	return nil
+}
+
+// Silenced сообщает, действует ли сейчас тишина по ip.
+func (su *SilenceUseCase) Silenced(ctx context.Context, ip string) (bool, error) {
+	silenced, err := su.repo.IsSilenced(ctx, ip, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("SilenceUseCase_Silenced: %w", err)
+	}
+
+	return silenced, nil
+}
@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+)
+
+type StatsUseCase struct {
+	repo StatsRepo
+}
+
+func NewStats(r StatsRepo) *StatsUseCase {
+	return &StatsUseCase{
+		repo: r,
+	}
+}
+
+func (su *StatsUseCase) AddStats(ctx context.Context, stats entity.ContainerStats) error {
+	ip, err := canonicalIp(stats.IpAddr)
+	if err != nil {
+		return fmt.Errorf("StatsUseCase_AddStats: %w", err)
+	}
+	stats.IpAddr = ip
+
+	var v validator
+	v.check(stats.CPUPercent >= 0, "cpu_percent", "must not be negative")
+	v.check(stats.NetRxRate >= 0, "net_rx_bytes_per_second", "must not be negative")
+	v.check(stats.NetTxRate >= 0, "net_tx_bytes_per_second", "must not be negative")
+	v.check(stats.BlockReadRate >= 0, "block_read_bytes_per_second", "must not be negative")
+	v.check(stats.BlockWriteRate >= 0, "block_write_bytes_per_second", "must not be negative")
+	if err = v.err(); err != nil {
+		return fmt.Errorf("StatsUseCase_AddStats: %w", err)
+	}
+
+	if stats.Time.IsZero() {
+		stats.Time = time.Now().UTC()
+	}
+
+	err = su.repo.AddStats(ctx, stats)
+	if err != nil {
+		return fmt.Errorf("StatsUseCase_AddStats: %w", err)
+	}
+
+	return nil
+}
+
+// Stats возвращает снимки контейнера за [from, to] по возрастанию времени.
+func (su *StatsUseCase) Stats(ctx context.Context, ip string, from, to time.Time) ([]entity.ContainerStats, error) {
+	ip, err := canonicalIp(ip)
+	if err != nil {
+		return nil, fmt.Errorf("StatsUseCase_Stats: %w", err)
+	}
+
+	if from.After(to) {
+		return nil, fmt.Errorf("StatsUseCase_Stats: %w", ErrBadQuery.Field("from", "must not be after to"))
+	}
+
+	stats, err := su.repo.GetStats(ctx, ip, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("StatsUseCase_Stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// Prune удаляет снимки старше before и возвращает их число.
+func (su *StatsUseCase) Prune(ctx context.Context, before time.Time) (int64, error) {
+	n, err := su.repo.DeleteStatsBefore(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("StatsUseCase_Prune: %w", err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"sync"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+)
+
+const _defaultSubscriberBuffer = 64
+
+type subscriber struct {
+	filter entity.EventFilter
+	ch     chan entity.Event
+}
+
+// StreamUseCase - in-process хаб, раздающий события всем подписчикам реплики.
+// События попадают сюда из LISTEN/NOTIFY, поэтому все реплики видят одно и то же.
+type StreamUseCase struct {
+	mu     sync.RWMutex
+	subs   map[*subscriber]struct{}
+	closed bool
+}
+
+func NewStream() *StreamUseCase {
+	return &StreamUseCase{
+		subs: make(map[*subscriber]struct{}),
+	}
+}
+
+// Subscribe возвращает канал событий и функцию отписки.
+// Канал закрывается при отписке или при Close хаба.
+func (s *StreamUseCase) Subscribe(filter entity.EventFilter) (<-chan entity.Event, func()) {
+	sub := &subscriber{
+		filter: filter,
+		ch:     make(chan entity.Event, _defaultSubscriberBuffer),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		close(sub.ch)
+
+		return sub.ch, func() {}
+	}
+
+	s.subs[sub] = struct{}{}
+
+	var once sync.Once
+
+	return sub.ch, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			if _, ok := s.subs[sub]; ok {
+				delete(s.subs, sub)
+				close(sub.ch)
+			}
+		})
+	}
+}
+
+// Broadcast рассылает событие подходящим подписчикам.
+// Медленный подписчик с заполненным буфером пропускает событие, а не тормозит остальных.
+func (s *StreamUseCase) Broadcast(event entity.Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for sub := range s.subs {
+		if !sub.filter.Match(event) {
+			continue
+		}
+
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
+
+// Close закрывает каналы всех подписчиков, чтобы долгоживущие соединения завершились.
+func (s *StreamUseCase) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+
+	for sub := range s.subs {
+		delete(s.subs, sub)
+		close(sub.ch)
+	}
+}
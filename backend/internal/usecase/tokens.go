@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	_tokenBytes = 32
+	// _placeholderPrefix - так начинаются значения-заглушки из примеров .env
+	_placeholderPrefix = "change-me"
+)
+
+// newToken - случайный токен для API-ключей, сессий и CSRF.
+func newToken(prefix string) (string, error) {
+	b := make([]byte, _tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return prefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken - sha256 без соли: токены случайные и длинные, перебор по хешу им не страшен.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// isPlaceholder сообщает, что секрет из конфигурации остался заглушкой из примера.
+func isPlaceholder(secret string) bool {
+	return strings.HasPrefix(strings.ToLower(secret), _placeholderPrefix)
+}
@@ -0,0 +1,294 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	_minPasswordLen = 8
+	// _maxPasswordLen - bcrypt учитывает только первые 72 байта пароля
+	_maxPasswordLen = 72
+)
+
+var _loginRe = regexp.MustCompile(`^[a-zA-Z0-9._@-]{1,64}$`)
+
+// _dummyHash сравнивается с паролем при неизвестном логине, чтобы время ответа не выдавало, есть ли пользователь.
+var _dummyHash, _ = bcrypt.GenerateFromPassword([]byte("pinger-dummy-password"), bcrypt.DefaultCost)
+
+type UserUseCase struct {
+	repo       UserRepo
+	sessionTTL time.Duration
+}
+
+func NewUsers(r UserRepo, sessionTTL time.Duration) *UserUseCase {
+	return &UserUseCase{
+		repo:       r,
+		sessionTTL: sessionTTL,
+	}
+}
+
+// Login проверяет пароль и открывает сессию. Возвращает значение cookie сессии, в базе остаётся его хеш.
+func (uu *UserUseCase) Login(ctx context.Context, login, password string) (entity.User, entity.Session, string, error) {
+	user, hash, err := uu.repo.GetUserByLogin(ctx, login)
+	if err != nil {
+		if errors.Is(err, ErrNoUser) {
+			_ = bcrypt.CompareHashAndPassword(_dummyHash, []byte(password))
+			return entity.User{}, entity.Session{}, "", ErrBadCredentials
+		}
+
+		return entity.User{}, entity.Session{}, "", fmt.Errorf("UserUseCase_Login: %w", err)
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return entity.User{}, entity.Session{}, "", ErrBadCredentials
+	}
+
+	session, token, err := openSession(ctx, uu.repo, user.Id, uu.sessionTTL)
+	if err != nil {
+		return entity.User{}, entity.Session{}, "", fmt.Errorf("UserUseCase_Login: %w", err)
+	}
+
+	return user, session, token, nil
+}
+
+// Session находит действующую сессию по значению cookie.
+func (uu *UserUseCase) Session(ctx context.Context, token string) (entity.User, entity.Session, error) {
+	user, session, err := uu.repo.GetSession(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, ErrNoSession) {
+			return entity.User{}, entity.Session{}, ErrUnauthorized
+		}
+
+		return entity.User{}, entity.Session{}, fmt.Errorf("UserUseCase_Session: %w", err)
+	}
+
+	if !time.Now().Before(session.ExpiresAt) {
+		return entity.User{}, entity.Session{}, ErrUnauthorized
+	}
+
+	return user, session, nil
+}
+
+func (uu *UserUseCase) Logout(ctx context.Context, token string) error {
+	if err := uu.repo.DeleteSession(ctx, hashToken(token)); err != nil {
+		return fmt.Errorf("UserUseCase_Logout: %w", err)
+	}
+
+	return nil
+}
+
+// ChangePassword меняет пароль по текущему и закрывает остальные сессии пользователя, кроме сессии token.
+func (uu *UserUseCase) ChangePassword(ctx context.Context, userId int64, token, current, password string) error {
+	_, hash, err := uu.repo.GetUser(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("UserUseCase_ChangePassword: %w", err)
+	}
+
+	var v validator
+	v.check(bcrypt.CompareHashAndPassword([]byte(hash), []byte(current)) == nil, "current_password", "is incorrect")
+	checkPassword(&v, "new_password", password)
+	if err = v.err(); err != nil {
+		return fmt.Errorf("UserUseCase_ChangePassword: %w", err)
+	}
+
+	newHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("UserUseCase_ChangePassword: %w", err)
+	}
+
+	if _, err = uu.repo.UpdateUser(ctx, entity.User{Id: userId}, string(newHash)); err != nil {
+		return fmt.Errorf("UserUseCase_ChangePassword: %w", err)
+	}
+
+	if err = uu.repo.DeleteUserSessions(ctx, userId, hashToken(token)); err != nil {
+		return fmt.Errorf("UserUseCase_ChangePassword: %w", err)
+	}
+
+	return nil
+}
+
+func (uu *UserUseCase) Users(ctx context.Context) ([]entity.User, error) {
+	users, err := uu.repo.GetUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("UserUseCase_Users: %w", err)
+	}
+
+	return users, nil
+}
+
+func (uu *UserUseCase) CreateUser(ctx context.Context, user entity.User, password string) (entity.User, error) {
+	var v validator
+	v.check(_loginRe.MatchString(user.Login), "login", "must be 1 to 64 letters, digits or ._@-")
+	v.check(entity.ValidRole(user.Role), "role", "unknown role %q", user.Role)
+	checkPassword(&v, "password", password)
+	if err := v.err(); err != nil {
+		return entity.User{}, fmt.Errorf("UserUseCase_CreateUser: %w", err)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return entity.User{}, fmt.Errorf("UserUseCase_CreateUser: %w", err)
+	}
+
+	user.CreatedAt = time.Now().UTC()
+
+	user.Id, err = uu.repo.AddUser(ctx, user, string(hash))
+	if err != nil {
+		return entity.User{}, fmt.Errorf("UserUseCase_CreateUser: %w", err)
+	}
+
+	return user, nil
+}
+
+// UpdateUser меняет роль и пароль пользователя, пустые значения не меняются.
+// После смены пароля все сессии пользователя закрываются.
+func (uu *UserUseCase) UpdateUser(ctx context.Context, user entity.User, password string) (entity.User, error) {
+	var v validator
+	v.check(user.Role == "" || entity.ValidRole(user.Role), "role", "unknown role %q", user.Role)
+	if password != "" {
+		checkPassword(&v, "password", password)
+	}
+	if err := v.err(); err != nil {
+		return entity.User{}, fmt.Errorf("UserUseCase_UpdateUser: %w", err)
+	}
+
+	if user.Role != "" && user.Role != entity.RoleAdmin {
+		if err := uu.keepAdmin(ctx, user.Id); err != nil {
+			return entity.User{}, fmt.Errorf("UserUseCase_UpdateUser: %w", err)
+		}
+	}
+
+	var hash []byte
+	if password != "" {
+		var err error
+		if hash, err = bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost); err != nil {
+			return entity.User{}, fmt.Errorf("UserUseCase_UpdateUser: %w", err)
+		}
+	}
+
+	user, err := uu.repo.UpdateUser(ctx, user, string(hash))
+	if err != nil {
+		return entity.User{}, fmt.Errorf("UserUseCase_UpdateUser: %w", err)
+	}
+
+	if password != "" {
+		if err = uu.repo.DeleteUserSessions(ctx, user.Id, ""); err != nil {
+			return entity.User{}, fmt.Errorf("UserUseCase_UpdateUser: %w", err)
+		}
+	}
+
+	return user, nil
+}
+
+// DeleteUser удаляет пользователя вместе с его сессиями.
+func (uu *UserUseCase) DeleteUser(ctx context.Context, id int64) error {
+	if err := uu.keepAdmin(ctx, id); err != nil {
+		return fmt.Errorf("UserUseCase_DeleteUser: %w", err)
+	}
+
+	if err := uu.repo.DeleteUser(ctx, id); err != nil {
+		return fmt.Errorf("UserUseCase_DeleteUser: %w", err)
+	}
+
+	return nil
+}
+
+// CheckAdminPassword проверяет ADMIN_PASSWORD до запуска: с заглушкой из примера .env администратор
+// создался бы с паролем, который знают все.
+func CheckAdminPassword(password string) error {
+	if isPlaceholder(password) {
+		return errors.New("ADMIN_PASSWORD: placeholder value, set a password or leave it empty")
+	}
+
+	return nil
+}
+
+// EnsureAdmin создаёт администратора login, если пользователя с таким логином ещё нет.
+func (uu *UserUseCase) EnsureAdmin(ctx context.Context, login, password string) error {
+	_, _, err := uu.repo.GetUserByLogin(ctx, login)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, ErrNoUser) {
+		return fmt.Errorf("UserUseCase_EnsureAdmin: %w", err)
+	}
+
+	_, err = uu.CreateUser(ctx, entity.User{Login: login, Role: entity.RoleAdmin}, password)
+	if err != nil && !errors.Is(err, ErrUserExists) {
+		return fmt.Errorf("UserUseCase_EnsureAdmin: %w", err)
+	}
+
+	return nil
+}
+
+// PruneSessions удаляет сессии, истёкшие до before.
+func (uu *UserUseCase) PruneSessions(ctx context.Context, before time.Time) (int64, error) {
+	n, err := uu.repo.DeleteSessionsBefore(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("UserUseCase_PruneSessions: %w", err)
+	}
+
+	return n, nil
+}
+
+// keepAdmin не даёт удалить или понизить последнего администратора.
+func (uu *UserUseCase) keepAdmin(ctx context.Context, id int64) error {
+	user, _, err := uu.repo.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if user.Role != entity.RoleAdmin {
+		return nil
+	}
+
+	admins, err := uu.repo.CountAdmins(ctx)
+	if err != nil {
+		return err
+	}
+
+	if admins <= 1 {
+		return ErrLastAdmin
+	}
+
+	return nil
+}
+
+// openSession создаёт сессию пользователя на ttl и возвращает её вместе со значением cookie.
+func openSession(ctx context.Context, repo UserRepo, userId int64, ttl time.Duration) (entity.Session, string, error) {
+	token, err := newToken("")
+	if err != nil {
+		return entity.Session{}, "", err
+	}
+
+	csrf, err := newToken("")
+	if err != nil {
+		return entity.Session{}, "", err
+	}
+
+	now := time.Now().UTC()
+	session := entity.Session{
+		UserId:    userId,
+		CsrfToken: csrf,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	if err = repo.AddSession(ctx, hashToken(token), session); err != nil {
+		return entity.Session{}, "", err
+	}
+
+	return session, token, nil
+}
+
+func checkPassword(v *validator, field, password string) {
+	v.check(len(password) >= _minPasswordLen && len(password) <= _maxPasswordLen, field,
+		"must be %d to %d bytes", _minPasswordLen, _maxPasswordLen)
+}
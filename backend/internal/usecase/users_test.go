@@ -0,0 +1,20 @@
+package usecase
+
+import "testing"
+
+func TestCheckAdminPassword(t *testing.T) {
+	for _, tc := range []struct {
+		password string
+		wantErr  bool
+	}{
+		{password: ""},
+		{password: "correct horse battery staple"},
+		{password: "change-me-admin", wantErr: true},
+		{password: "Change-Me", wantErr: true},
+	} {
+		err := CheckAdminPassword(tc.password)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("CheckAdminPassword(%q) error = %v, wantErr %t", tc.password, err, tc.wantErr)
+		}
+	}
+}
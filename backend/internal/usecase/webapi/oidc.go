@@ -0,0 +1,144 @@
+package webapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/k1v4/Pinger/backend/internal/entity"
+	"golang.org/x/oauth2"
+)
+
+// OidcConfig - клиент Pinger у OpenID Connect провайдера.
+type OidcConfig struct {
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+	// LoginClaim и GroupsClaim - claims ID-токена с логином и группами пользователя
+	LoginClaim  string
+	GroupsClaim string
+}
+
+type OidcWebAPI struct {
+	cfg OidcConfig
+
+	// mu защищает discovery, oauth и verifier заполняются один раз при первом удачном Discover
+	mu       sync.Mutex
+	oauth    oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOidc - клиент провайдера без обращения к нему: настройки читаются в Discover.
+func NewOidc(cfg OidcConfig) *OidcWebAPI {
+	return &OidcWebAPI{cfg: cfg}
+}
+
+// Discover читает .well-known/openid-configuration провайдера. Ключи подписи берутся из его JWKS
+// при проверке токенов и перечитываются, когда провайдер меняет ключ. Пока discovery не удался,
+// его повторяют AuthCodeURL и Exchange, так что провайдер, недоступный на старте, подхватывается при входе.
+func (o *OidcWebAPI) Discover(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.verifier != nil {
+		return nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, o.cfg.Issuer)
+	if err != nil {
+		return fmt.Errorf("OidcWebAPI - Discover - oidc.NewProvider: %w", err)
+	}
+
+	scopes := o.cfg.Scopes
+	if !slices.Contains(scopes, oidc.ScopeOpenID) {
+		scopes = append([]string{oidc.ScopeOpenID}, scopes...)
+	}
+
+	o.oauth = oauth2.Config{
+		ClientID:     o.cfg.ClientId,
+		ClientSecret: o.cfg.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  o.cfg.RedirectUrl,
+		Scopes:       scopes,
+	}
+	o.verifier = provider.Verifier(&oidc.Config{ClientID: o.cfg.ClientId})
+
+	return nil
+}
+
+// AuthCodeURL - страница входа провайдера. Провайдер получает S256-хеш verifier, сам verifier
+// передаётся только при обмене code.
+func (o *OidcWebAPI) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	if err := o.Discover(ctx); err != nil {
+		return "", fmt.Errorf("OidcWebAPI - AuthCodeURL: %w", err)
+	}
+
+	return o.oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Exchange меняет code на токены и проверяет ID-токен: подпись по JWKS, iss, aud, срок действия и nonce.
+func (o *OidcWebAPI) Exchange(ctx context.Context, code, verifier, nonce string) (entity.OidcIdentity, error) {
+	if err := o.Discover(ctx); err != nil {
+		return entity.OidcIdentity{}, fmt.Errorf("OidcWebAPI - Exchange: %w", err)
+	}
+
+	token, err := o.oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return entity.OidcIdentity{}, fmt.Errorf("OidcWebAPI - Exchange - oauth.Exchange: %w", err)
+	}
+
+	raw, ok := token.Extra("id_token").(string)
+	if !ok {
+		return entity.OidcIdentity{}, errors.New("OidcWebAPI - Exchange: no id_token in token response")
+	}
+
+	idToken, err := o.verifier.Verify(ctx, raw)
+	if err != nil {
+		return entity.OidcIdentity{}, fmt.Errorf("OidcWebAPI - Exchange - verifier.Verify: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		return entity.OidcIdentity{}, errors.New("OidcWebAPI - Exchange: id_token nonce does not match")
+	}
+
+	var claims map[string]any
+	if err = idToken.Claims(&claims); err != nil {
+		return entity.OidcIdentity{}, fmt.Errorf("OidcWebAPI - Exchange - idToken.Claims: %w", err)
+	}
+
+	login, _ := claims[o.cfg.LoginClaim].(string)
+	if login == "" {
+		return entity.OidcIdentity{}, fmt.Errorf("OidcWebAPI - Exchange: no %q claim in id_token", o.cfg.LoginClaim)
+	}
+
+	return entity.OidcIdentity{
+		Subject: idToken.Subject,
+		Login:   login,
+		Groups:  stringsClaim(claims[o.cfg.GroupsClaim]),
+	}, nil
+}
+
+// stringsClaim - группы из claim: провайдеры отдают их списком, а единственную группу иногда строкой.
+func stringsClaim(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		res := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				res = append(res, s)
+			}
+		}
+
+		return res
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const _defaultListenRetry = time.Second
+
+// Listen -.
+// Держит отдельное от пула соединение с LISTEN на channel и вызывает handle для каждого NOTIFY.
+// При обрыве соединения переподключается, пока не отменён ctx.
+func (p *Postgres) Listen(ctx context.Context, channel string, handle func(payload string)) error {
+	for {
+		err := p.listen(ctx, channel, handle)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		log.Printf("postgres - Listen %s: %s, reconnecting", channel, err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(_defaultListenRetry):
+		}
+	}
+}
+
+func (p *Postgres) listen(ctx context.Context, channel string, handle func(payload string)) error {
+	conn, err := pgx.ConnectConfig(ctx, p.Pool.Config().ConnConfig)
+	if err != nil {
+		return fmt.Errorf("postgres - listen - pgx.ConnectConfig: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize())
+	if err != nil {
+		return fmt.Errorf("postgres - listen - LISTEN: %w", err)
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+
+			return fmt.Errorf("postgres - listen - WaitForNotification: %w", err)
+		}
+
+		handle(n.Payload)
+	}
+}
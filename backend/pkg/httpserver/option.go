@@ -35,3 +35,43 @@ func ShutdownTimeout(timeout time.Duration) Option {
 		s.shutdownTimeout = timeout
 	}
 }
+
+// OnShutdown -.
+// f вызывается в начале Shutdown, чтобы закрыть долгоживущие соединения (SSE, WebSocket).
+func OnShutdown(f func()) Option {
+	return func(s *Server) {
+		s.server.RegisterOnShutdown(f)
+	}
+}
+
+// TLS -.
+// Сервер принимает https с сертификатом certFile и ключом keyFile, файлы перечитываются при изменении.
+func TLS(certFile, keyFile string) Option {
+	return func(s *Server) {
+		s.certs().certFile, s.certs().keyFile = certFile, keyFile
+	}
+}
+
+// ClientCA -.
+// Клиентские сертификаты проверяются по CA из caFile. Соединение без сертификата принимается,
+// проверенная цепочка доступна обработчику в r.TLS.VerifiedChains.
+func ClientCA(caFile string) Option {
+	return func(s *Server) {
+		s.certs().caFile = caFile
+	}
+}
+
+// MinTLSVersion -.
+func MinTLSVersion(version uint16) Option {
+	return func(s *Server) {
+		s.certs().minVersion = version
+	}
+}
+
+// ErrorHandler -.
+// f получает ошибки, после которых сервер продолжает работать: например, не удалось перечитать сертификаты.
+func ErrorHandler(f func(err error)) Option {
+	return func(s *Server) {
+		s.onError = f
+	}
+}
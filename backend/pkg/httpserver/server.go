@@ -2,6 +2,7 @@ package httpserver
 
 import (
 	"context"
+	"crypto/tls"
 	"net/http"
 	"time"
 )
@@ -11,6 +12,7 @@ const (
 	_defaultWriteTimeout    = 5 * time.Second
 	_defaultAddr            = ":8080"
 	_defaultShutdownTimeout = 3 * time.Second
+	_defaultMinTLSVersion   = tls.VersionTLS12
 )
 
 // Server -.
@@ -18,6 +20,9 @@ type Server struct {
 	server          *http.Server
 	notify          chan error
 	shutdownTimeout time.Duration
+	// tls задан опциями TLS, ClientCA и MinTLSVersion, без него сервер работает по http
+	tls     *certFiles
+	onError func(error)
 }
 
 func New(handler http.Handler, opts ...Option) *Server {
@@ -46,11 +51,35 @@ func New(handler http.Handler, opts ...Option) *Server {
 
 func (s *Server) start() {
 	go func() {
-		s.notify <- s.server.ListenAndServe()
+		s.notify <- s.serve()
 		close(s.notify)
 	}()
 }
 
+func (s *Server) serve() error {
+	if s.tls == nil {
+		return s.server.ListenAndServe()
+	}
+
+	s.tls.onError = s.onError
+
+	config, err := s.tls.tlsConfig()
+	if err != nil {
+		return err
+	}
+	s.server.TLSConfig = config
+
+	return s.server.ListenAndServeTLS("", "")
+}
+
+func (s *Server) certs() *certFiles {
+	if s.tls == nil {
+		s.tls = &certFiles{minVersion: _defaultMinTLSVersion}
+	}
+
+	return s.tls
+}
+
 func (s *Server) Notify() <-chan error {
 	return s.notify
 }
@@ -0,0 +1,151 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// _certCheckInterval - как часто при рукопожатиях проверяется, не сменились ли файлы сертификатов.
+const _certCheckInterval = 10 * time.Second
+
+// certFiles - сертификат сервера и CA клиентов из файлов. Файлы перечитываются без перезапуска,
+// когда меняется время их изменения: новый сертификат получают следующие соединения.
+type certFiles struct {
+	certFile   string
+	keyFile    string
+	caFile     string
+	minVersion uint16
+	// onError получает ошибки перечитывания файлов, может быть nil
+	onError func(error)
+
+	mu        sync.Mutex
+	config    *tls.Config
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// TLSVersion разбирает версию TLS вида "1.2" или "1.3".
+func TLSVersion(version string) (uint16, error) {
+	switch version {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("httpserver - TLSVersion: unsupported tls version %q", version)
+	}
+}
+
+// tlsConfig - конфиг сервера, который на каждое соединение отдаёт актуальные сертификаты.
+func (f *certFiles) tlsConfig() (*tls.Config, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:         f.minVersion,
+		GetConfigForClient: f.configForClient,
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			config, err := f.configForClient(hello)
+			if err != nil {
+				return nil, err
+			}
+
+			return &config.Certificates[0], nil
+		},
+	}, nil
+}
+
+func (f *certFiles) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(f.checkedAt) < _certCheckInterval {
+		return f.config, nil
+	}
+	f.checkedAt = now
+
+	modTime, err := f.latestModTime()
+	if err == nil && !modTime.Equal(f.modTime) {
+		err = f.load()
+	}
+	if err != nil && f.onError != nil {
+		// файлы могут быть записаны не до конца, старый сертификат работает до следующей проверки
+		f.onError(fmt.Errorf("httpserver - reload certificates: %w", err))
+	}
+
+	return f.config, nil
+}
+
+// load читает файлы и собирает конфиг для соединений. Вызывается под f.mu.
+func (f *certFiles) load() error {
+	if f.certFile == "" || f.keyFile == "" {
+		return fmt.Errorf("httpserver - load certificate: certificate and key files are required")
+	}
+
+	modTime, err := f.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(f.certFile, f.keyFile)
+	if err != nil {
+		return fmt.Errorf("httpserver - load certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   f.minVersion,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	if f.caFile != "" {
+		pem, err := os.ReadFile(f.caFile)
+		if err != nil {
+			return fmt.Errorf("httpserver - load client ca: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("httpserver - load client ca: no certificates in %s", f.caFile)
+		}
+
+		// сертификат не обязателен: браузеры дашборда приходят без него, права решает обработчик
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	f.config, f.modTime = config, modTime
+
+	return nil
+}
+
+// latestModTime - самое позднее время изменения файлов сертификатов.
+func (f *certFiles) latestModTime() (time.Time, error) {
+	var latest time.Time
+
+	for _, name := range []string{f.certFile, f.keyFile, f.caFile} {
+		if name == "" {
+			continue
+		}
+
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("httpserver - stat certificates: %w", err)
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
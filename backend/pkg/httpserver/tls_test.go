@@ -0,0 +1,281 @@
+package httpserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testCA выпускает сертификаты для тестов.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	return pool
+}
+
+// issue выпускает сертификат сервера для 127.0.0.1 или, с client, клиентский сертификат с CN name.
+func (ca *testCA) issue(t *testing.T, name string, serial int64, client bool) (certPem, keyPem []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	if client {
+		template.ExtKeyUsage, template.IPAddresses = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, nil
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+// writeFile пишет файл и сдвигает время его изменения, чтобы смену файла было видно без ожидания.
+func writeFile(t *testing.T, name string, data []byte, modTime time.Time) {
+	t.Helper()
+
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(name, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startTLS запускает сервер с конфигом certFiles так же, как Server.serve.
+func startTLS(t *testing.T, f *certFiles, handler http.Handler) *httptest.Server {
+	t.Helper()
+
+	config, err := f.tlsConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewUnstartedServer(handler)
+	srv.TLS = config
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// tlsClient доверяет ca и, если задан cert, отправляет его серверу, даже если тот просит сертификат другого CA.
+func tlsClient(ca *testCA, cert *tls.Certificate) *http.Client {
+	config := &tls.Config{RootCAs: ca.pool()}
+	if cert != nil {
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return cert, nil
+		}
+	}
+
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: config, DisableKeepAlives: true}}
+}
+
+// TestCertFilesReload - новый сертификат получают соединения после очередной проверки файлов,
+// а недописанный файл не ломает сервер: остаётся старый сертификат, ошибка уходит в onError.
+func TestCertFilesReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+
+	ca := newTestCA(t, "test-ca")
+	now := time.Now()
+
+	certPem, keyPem := ca.issue(t, "backend", 2, false)
+	writeFile(t, certFile, certPem, now)
+	writeFile(t, keyFile, keyPem, now)
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+
+	f := &certFiles{certFile: certFile, keyFile: keyFile, minVersion: tls.VersionTLS12, onError: func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}}
+	srv := startTLS(t, f, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	client := tlsClient(ca, nil)
+
+	served := func() int64 {
+		t.Helper()
+
+		res, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		return res.TLS.PeerCertificates[0].SerialNumber.Int64()
+	}
+
+	// следующая проверка файлов - не раньше чем через _certCheckInterval
+	recheck := func() {
+		f.mu.Lock()
+		f.checkedAt = time.Time{}
+		f.mu.Unlock()
+	}
+
+	if serial := served(); serial != 2 {
+		t.Fatalf("served certificate %d, want 2", serial)
+	}
+
+	certPem, keyPem = ca.issue(t, "backend", 3, false)
+	writeFile(t, certFile, certPem, now.Add(time.Minute))
+	writeFile(t, keyFile, keyPem, now.Add(time.Minute))
+
+	if serial := served(); serial != 2 {
+		t.Errorf("certificate %d served before the check interval, want 2", serial)
+	}
+
+	recheck()
+	if serial := served(); serial != 3 {
+		t.Errorf("served certificate %d after reload, want 3", serial)
+	}
+
+	writeFile(t, certFile, certPem[:len(certPem)/2], now.Add(2*time.Minute))
+
+	recheck()
+	if serial := served(); serial != 3 {
+		t.Errorf("served certificate %d after a broken reload, want 3", serial)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "reload certificates") {
+		t.Errorf("reload errors %v", errs)
+	}
+}
+
+// TestClientCertIfGiven - с CA клиентов сервер пускает и без сертификата, сертификат проверяет по CA.
+func TestClientCertIfGiven(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.crt")
+
+	ca := newTestCA(t, "test-ca")
+	now := time.Now()
+
+	certPem, keyPem := ca.issue(t, "backend", 2, false)
+	writeFile(t, certFile, certPem, now)
+	writeFile(t, keyFile, keyPem, now)
+	writeFile(t, caFile, ca.pem, now)
+
+	f := &certFiles{certFile: certFile, keyFile: keyFile, caFile: caFile, minVersion: tls.VersionTLS12}
+	srv := startTLS(t, f, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.VerifiedChains) == 0 {
+			_, _ = io.WriteString(w, "anonymous")
+			return
+		}
+
+		_, _ = io.WriteString(w, r.TLS.VerifiedChains[0][0].Subject.CommonName)
+	}))
+
+	clientCert := func(ca *testCA) *tls.Certificate {
+		certPem, keyPem := ca.issue(t, "pinger-1", 10, true)
+
+		cert, err := tls.X509KeyPair(certPem, keyPem)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return &cert
+	}
+
+	get := func(client *http.Client) (string, error) {
+		res, err := client.Get(srv.URL)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+
+		body, err := io.ReadAll(res.Body)
+
+		return string(body), err
+	}
+
+	if got, err := get(tlsClient(ca, nil)); err != nil || got != "anonymous" {
+		t.Errorf("without certificate: %q, %v", got, err)
+	}
+
+	if got, err := get(tlsClient(ca, clientCert(ca))); err != nil || got != "pinger-1" {
+		t.Errorf("with certificate: %q, %v", got, err)
+	}
+
+	// сертификат чужого CA не принимается, хотя без сертификата запрос бы прошёл
+	if got, err := get(tlsClient(ca, clientCert(newTestCA(t, "other-ca")))); err == nil {
+		t.Errorf("certificate of another ca accepted: %q", got)
+	}
+}
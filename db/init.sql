@@ -1,7 +1,143 @@
-CREATE TABLE IF NOT EXISTS containers (
-                                     ip TEXT PRIMARY KEY,
-                                     ping_time INTEGER NOT NULL,
-                                     last_successful TIMESTAMP NOT NULL
+CREATE TABLE IF NOT EXISTS containers
+(
+    ip                   INET PRIMARY KEY,
+    ping_time            INTEGER   NOT NULL DEFAULT 0,
+    last_successful      TIMESTAMP,
+    is_successful        BOOLEAN   NOT NULL DEFAULT TRUE,
+    consecutive_failures INTEGER   NOT NULL DEFAULT 0,
+    name                 TEXT      NOT NULL DEFAULT '',
+    image                TEXT      NOT NULL DEFAULT '',
+    labels               JSONB     NOT NULL DEFAULT '{}',
+    reported_by          TEXT      NOT NULL DEFAULT ''
 );
 
-CREATE INDEX IF NOT EXISTS idx_ip ON containers (ip);
\ No newline at end of file
+-- файл повторяется на существующей базе при обновлении, поэтому изменения схемы идемпотентны
+ALTER TABLE containers
+    ALTER COLUMN ping_time SET DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS is_successful        BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN IF NOT EXISTS consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS name                 TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS image                TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS labels               JSONB   NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS reported_by          TEXT    NOT NULL DEFAULT '',
+    ALTER COLUMN last_successful DROP NOT NULL;
+
+-- ip хранился как TEXT до перехода на inet
+DO
+$$
+    BEGIN
+        IF (SELECT data_type
+            FROM information_schema.columns
+            WHERE table_schema = current_schema()
+              AND table_name = 'containers'
+              AND column_name = 'ip') <> 'inet' THEN
+            ALTER TABLE containers ALTER COLUMN ip TYPE INET USING ip::inet;
+        END IF;
+    END
+$$;
+
+-- контейнеры без успешных пингов раньше хранились с last_successful 0001-01-01
+UPDATE containers SET last_successful = NULL WHERE last_successful < '0002-01-01';
+
+DROP INDEX IF EXISTS idx_ip;
+DROP INDEX IF EXISTS containers_last_successful_idx;
+
+CREATE INDEX IF NOT EXISTS containers_ping_time_idx ON containers (ping_time, ip);
+CREATE INDEX IF NOT EXISTS containers_last_success_idx ON containers ((COALESCE(last_successful, '-infinity')), ip);
+CREATE INDEX IF NOT EXISTS containers_name_idx ON containers (name, ip);
+CREATE INDEX IF NOT EXISTS containers_labels_idx ON containers USING GIN (labels);
+
+CREATE TABLE IF NOT EXISTS container_stats
+(
+    ip               INET             NOT NULL,
+    time             TIMESTAMPTZ      NOT NULL,
+    cpu_percent      DOUBLE PRECISION NOT NULL,
+    memory_usage     BIGINT           NOT NULL,
+    memory_limit     BIGINT           NOT NULL,
+    net_rx_rate      DOUBLE PRECISION NOT NULL,
+    net_tx_rate      DOUBLE PRECISION NOT NULL,
+    block_read_rate  DOUBLE PRECISION NOT NULL,
+    block_write_rate DOUBLE PRECISION NOT NULL,
+    ping_time        INTEGER,
+    reported_by      TEXT             NOT NULL DEFAULT ''
+);
+
+ALTER TABLE container_stats
+    ADD COLUMN IF NOT EXISTS reported_by TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS container_stats_ip_time_idx ON container_stats (ip, time);
+
+CREATE TABLE IF NOT EXISTS container_events
+(
+    id             BIGSERIAL PRIMARY KEY,
+    ip             INET,
+    type           TEXT        NOT NULL,
+    container_id   TEXT        NOT NULL,
+    container_name TEXT        NOT NULL DEFAULT '',
+    image          TEXT        NOT NULL DEFAULT '',
+    docker_host    TEXT        NOT NULL DEFAULT '',
+    exit_code      INTEGER,
+    restart_count  INTEGER     NOT NULL DEFAULT 0,
+    message        TEXT        NOT NULL DEFAULT '',
+    time           TIMESTAMPTZ NOT NULL,
+    reported_by    TEXT        NOT NULL DEFAULT ''
+);
+
+-- события до подключения контейнера к сети хранятся без ip
+ALTER TABLE container_events
+    ALTER COLUMN ip DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS reported_by TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS container_events_ip_time_idx ON container_events (ip, time);
+
+CREATE INDEX IF NOT EXISTS container_events_container_id_time_idx ON container_events (container_id text_pattern_ops, time);
+
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id         BIGSERIAL PRIMARY KEY,
+    name       TEXT        NOT NULL,
+    prefix     TEXT        NOT NULL,
+    hash       TEXT        NOT NULL UNIQUE,
+    scopes     TEXT[]      NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS users
+(
+    id            BIGSERIAL PRIMARY KEY,
+    login         TEXT        NOT NULL UNIQUE,
+    password_hash TEXT        NOT NULL,
+    role          TEXT        NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL,
+    oidc_subject  TEXT
+);
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS oidc_subject TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_oidc_subject_idx ON users (oidc_subject);
+
+CREATE TABLE IF NOT EXISTS sessions
+(
+    hash       TEXT PRIMARY KEY,
+    user_id    BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    csrf_token TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id);
+
+CREATE TABLE IF NOT EXISTS silences
+(
+    id         BIGSERIAL PRIMARY KEY,
+    ip         INET        NOT NULL,
+    comment    TEXT        NOT NULL DEFAULT '',
+    created_by TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    until      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS silences_ip_until_idx ON silences (ip, until);
@@ -1,7 +1,48 @@
-import React from "react";
+import React, { useEffect, useState } from "react";
+import { Button, Spinner } from "react-bootstrap";
 import DataTable from "./DataTable";
+import Login from "./Login";
+import { api, setCsrfToken, SessionType, User } from "./api";
 import 'bootstrap/dist/css/bootstrap.min.css';
 
-const App: React.FC = () => <DataTable />;
+const App: React.FC = () => {
+  const [user, setUser] = useState<User | null>(null);
+  const [checked, setChecked] = useState<boolean>(false);
+
+  const applySession = (session: SessionType) => {
+    setCsrfToken(session.csrf_token);
+    setUser(session.user);
+  };
+
+  // Сессия могла остаться с прошлого входа
+  useEffect(() => {
+    api.get<SessionType>("/v1/auth/me")
+      .then(response => applySession(response.data))
+      .catch(() => setUser(null))
+      .finally(() => setChecked(true));
+  }, []);
+
+  const logout = () => {
+    api.post("/v1/auth/logout").finally(() => setUser(null));
+  };
+
+  if (!checked) {
+    return <Spinner animation="border" />;
+  }
+
+  if (!user) {
+    return <Login onLogin={applySession} />;
+  }
+
+  return (
+    <>
+      <div className="container mt-2 d-flex justify-content-end align-items-center gap-2">
+        <span>{user.login} ({user.role})</span>
+        <Button size="sm" variant="outline-secondary" onClick={logout}>Выйти</Button>
+      </div>
+      <DataTable />
+    </>
+  );
+};
 
 export default App;
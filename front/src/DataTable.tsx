@@ -1,24 +1,45 @@
-import React, { useEffect, useState } from "react";
-import { Table, Spinner, Alert } from "react-bootstrap";
+import React, { useEffect, useRef, useState } from "react";
+import { Table, Spinner, Alert, Button } from "react-bootstrap";
 import { parseISO, format } from "date-fns";
-import axios from "axios";
+import { api, API_URL } from "./api";
 
 interface DataType {
   ip: string;  // Первичный ключ
   ping_time: number;  // Время пинга в мс
+  is_successful: boolean;  // Успешен ли последний пинг
   last_successful: string;  // Дата последнего успешного пинга
+  name: string;  // Имя контейнера
+  image: string;  // Образ контейнера
 }
 
+interface PageType {
+  containers: DataType[];
+  total: number;
+  next_cursor?: string;
+}
+
+const PAGE_URL = "/v1/containers/?limit=100";
+
 const DataTable: React.FC = () => {
   const [data, setData] = useState<DataType[]>([]);
   const [loading, setLoading] = useState<boolean>(true);
   const [error, setError] = useState<string | null>(null);
+  const [total, setTotal] = useState<number>(0);
+  const [cursor, setCursor] = useState<string | undefined>(undefined);
+  const cursorRef = useRef<string | undefined>(undefined);
+
+  const applyPage = (page: PageType) => {
+    setTotal(page.total);
+    setCursor(page.next_cursor);
+    cursorRef.current = page.next_cursor;
+  };
 
-  // Функция для загрузки данных
+  // Функция для загрузки первой страницы
   const fetchData = () => {
-    axios.get("http://localhost:8080/v1/containers/")
+    api.get<PageType>(PAGE_URL)
       .then(response => {
-        setData(response.data);
+        setData(response.data.containers);
+        applyPage(response.data);
         setError(null); // Сброс ошибки, если данные успешно загружены
       })
       .catch(error => {
@@ -28,15 +49,48 @@ const DataTable: React.FC = () => {
       .finally(() => setLoading(false));
   };
 
+  // Догружаем следующую страницу по курсору
+  const fetchMore = () => {
+    api.get<PageType>(`${PAGE_URL}&cursor=${encodeURIComponent(cursor ?? "")}`)
+      .then(response => {
+        setData(prev => [...prev, ...response.data.containers]);
+        applyPage(response.data);
+      })
+      .catch(error => {
+        setError("Ошибка загрузки данных");
+        console.error(error);
+      });
+  };
+
   useEffect(() => {
     // Загружаем данные сразу при монтировании компонента
     fetchData();
 
-    // Устанавливаем интервал для обновления данных каждые 10 секунд
-    const intervalId = setInterval(fetchData, 10000);
+    // Дальше получаем изменения из стрима вместо опроса каждые 10 секунд
+    const source = new EventSource(`${API_URL}/v1/stream?type=container_updated,container_deleted`, { withCredentials: true });
 
-    // Очистка интервала при размонтировании компонента
-    return () => clearInterval(intervalId);
+    source.addEventListener("container_updated", (e) => {
+      const { container } = JSON.parse((e as MessageEvent).data);
+      setData(prev => {
+        // Новые контейнеры добавляем, только когда загружены все страницы, иначе их место дальше по списку
+        if (cursorRef.current && !prev.some(item => item.ip === container.ip)) {
+          return prev;
+        }
+        const rest = prev.filter(item => item.ip !== container.ip);
+        return [...rest, container].sort((a, b) => a.ip.localeCompare(b.ip));
+      });
+    });
+
+    source.addEventListener("container_deleted", (e) => {
+      const { ip } = JSON.parse((e as MessageEvent).data);
+      setData(prev => prev.filter(item => item.ip !== ip));
+    });
+
+    // После переподключения догружаем то, что могли пропустить
+    source.onopen = fetchData;
+
+    // Закрываем стрим при размонтировании компонента
+    return () => source.close();
   }, []); // Пустой массив зависимостей, чтобы эффект выполнялся только при монтировании и размонтировании
 
   return (
@@ -49,6 +103,7 @@ const DataTable: React.FC = () => {
           <thead>
             <tr>
               <th>IP-адрес</th>
+              <th>Имя</th>
               <th>Время пинга (мс)</th>
               <th>Последний успешный пинг</th>
             </tr>
@@ -57,6 +112,7 @@ const DataTable: React.FC = () => {
             {data.map((item) => (
               <tr key={item.ip}>
                 <td>{item.ip}</td>
+                <td>{item.name}</td>
                 <td>{item.ping_time} мс</td>
                 <td>
                   {item.last_successful
@@ -68,6 +124,11 @@ const DataTable: React.FC = () => {
           </tbody>
         </Table>
       )}
+      {!loading && !error && cursor && (
+        <Button variant="secondary" onClick={fetchMore}>
+          Показать ещё (загружено {data.length} из {total})
+        </Button>
+      )}
     </div>
   );
 };
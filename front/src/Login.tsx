@@ -0,0 +1,47 @@
+import React, { useState } from "react";
+import { Form, Button, Alert } from "react-bootstrap";
+import { api, OIDC_LOGIN_URL, SessionType } from "./api";
+
+interface LoginProps {
+  onLogin: (session: SessionType) => void;
+}
+
+const Login: React.FC<LoginProps> = ({ onLogin }) => {
+  const [login, setLogin] = useState<string>("");
+  const [password, setPassword] = useState<string>("");
+  const [error, setError] = useState<string | null>(null);
+
+  const submit = (e: React.FormEvent) => {
+    e.preventDefault();
+    api.post<SessionType>("/v1/auth/login", { login, password })
+      .then(response => onLogin(response.data))
+      .catch(error => {
+        setError(error.response?.status === 401 ? "Неверный логин или пароль" : "Ошибка входа");
+        console.error(error);
+      });
+  };
+
+  return (
+    <div className="container mt-4" style={{ maxWidth: 400 }}>
+      <h2>Вход</h2>
+      {error && <Alert variant="danger">{error}</Alert>}
+      <Form onSubmit={submit}>
+        <Form.Group className="mb-3">
+          <Form.Label>Логин</Form.Label>
+          <Form.Control value={login} onChange={e => setLogin(e.target.value)} autoComplete="username" />
+        </Form.Group>
+        <Form.Group className="mb-3">
+          <Form.Label>Пароль</Form.Label>
+          <Form.Control type="password" value={password} onChange={e => setPassword(e.target.value)}
+                        autoComplete="current-password" />
+        </Form.Group>
+        <Button type="submit">Войти</Button>
+        {OIDC_LOGIN_URL && (
+          <Button variant="outline-primary" className="ms-2" href={OIDC_LOGIN_URL}>Войти через SSO</Button>
+        )}
+      </Form>
+    </div>
+  );
+};
+
+export default Login;
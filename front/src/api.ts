@@ -0,0 +1,24 @@
+import axios from "axios";
+
+export const API_URL = "http://localhost:8080";
+
+// Вход через OpenID Connect провайдера, кнопка показывается при сборке с REACT_APP_OIDC=true
+export const OIDC_LOGIN_URL = process.env.REACT_APP_OIDC === "true" ? `${API_URL}/v1/auth/oidc/login` : null;
+
+export interface User {
+  id: number;
+  login: string;
+  role: "viewer" | "operator" | "admin";
+}
+
+export interface SessionType {
+  user: User;
+  csrf_token: string;
+}
+
+// Запросы идут с cookie сессии, изменяющим запросам нужен CSRF-токен из ответа входа
+export const api = axios.create({ baseURL: API_URL, withCredentials: true });
+
+export const setCsrfToken = (token: string) => {
+  api.defaults.headers.common["X-CSRF-Token"] = token;
+};
@@ -1,215 +1,211 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/network"
+	"github.com/k1v4/Pinger/pinger/internal/config"
+	"github.com/k1v4/Pinger/pinger/internal/controller/http/api"
+	"github.com/k1v4/Pinger/pinger/internal/delivery"
+	"github.com/k1v4/Pinger/pinger/internal/discovery"
+	"github.com/k1v4/Pinger/pinger/internal/docker"
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+	"github.com/k1v4/Pinger/pinger/internal/lifecycle"
+	"github.com/k1v4/Pinger/pinger/internal/metrics"
+	"github.com/k1v4/Pinger/pinger/internal/prober"
+	"github.com/k1v4/Pinger/pinger/internal/scheduler"
+	"github.com/k1v4/Pinger/pinger/internal/stats"
+	"github.com/k1v4/Pinger/pinger/pkg/httpserver"
+	"github.com/labstack/echo/v4"
 	"log"
 	"net"
-	"net/http"
 	"os"
-	"os/exec"
-	"runtime"
-	"strings"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
 	"time"
-
-	"github.com/docker/docker/client"
 )
 
-type dockerContainer struct {
-	Id     string
-	Image  string
-	Status string
-	Ip     string
-}
-
-type PingResult struct {
-	IP             string    `json:"ip"`            // ip-адрес контейнера
-	PingTime       int       `json:"ping_time"`     // продолжительность пинга в миллисекундах
-	Success        bool      `json:"is_successful"` // успешен ли pingFunc
-	LastSuccessful time.Time `json:"last_successful"`
-}
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-func isHostAvailable(host string) bool {
-	conn, err := net.Dial("tcp", strings.TrimPrefix(host, "tcp://"))
-	if err != nil {
-		return false
+	cfg := config.MustLoadConfig()
+	if err := cfg.CheckBackendAuth(); err != nil {
+		log.Fatalf("Ошибка настройки доступа к бэкенду: %s", err)
 	}
-	conn.Close()
-	return true
-}
 
-func getDockerHost() string {
-	if dockerHost := os.Getenv("DOCKER_HOST"); dockerHost != "" {
-		return dockerHost
-	}
+	pingerMetrics := metrics.New()
+	targets := inventory.New()
+	probers := prober.Default()
 
-	var dockerHost string
-	switch runtime.GOOS {
-	case "linux":
-		dockerHost = "unix:///var/run/docker.sock"
-	case "darwin", "windows":
-		dockerHost = "tcp://host.docker.internal:2375"
-	default:
-		dockerHost = "tcp://localhost:2375"
-	}
+	pingerMetrics.Register(metrics.NewInventoryCollector(targets))
 
-	// Проверяем доступность хоста
-	if strings.HasPrefix(dockerHost, "tcp://") && !isHostAvailable(dockerHost) {
-		log.Println("Хост", dockerHost, "недоступен. Использую fallback: tcp://localhost:2375")
-		return "tcp://localhost:2375"
-	}
+	dockerClis := make(map[string]docker.Client)
+	var dockerHosts []string
+	for _, e := range cfg.Dockers() {
+		if _, ok := dockerClis[e.Name]; ok {
+			log.Fatalf("Docker-демон %q указан несколько раз", e.Name)
+		}
 
-	return dockerHost
-}
+		cli, err := docker.New(e)
+		if err != nil {
+			log.Fatalf("Ошибка при создании Docker-клиента %s: %s", e.Name, err)
+		}
 
-func pingFunc(ip string) (int, bool) {
-	start := time.Now()
-	_, err := exec.Command("ping", "-c", "4", ip).Output()
-	if err != nil {
-		return 0, false
+		dockerClis[e.Name] = cli
+		dockerHosts = append(dockerHosts, e.Name)
 	}
 
-	return int(time.Since(start).Milliseconds()), true
-}
+	probers.Register("docker", prober.NewDocker(dockerClis))
+	probers.SetVantage(prober.NewExec(dockerClis))
 
-func takeDockerContainers() []dockerContainer {
-	cli, err := client.NewClientWithOpts(
-		client.WithHost(getDockerHost()),
-		client.WithAPIVersionNegotiation(),
-	)
-	if err != nil {
-		log.Fatalf("Ошибка при создании Docker-клиента: %s", err)
-	}
+	// пингер готов, если отвечает хотя бы один Docker-демон
+	dockerCheck := func(ctx context.Context) error {
+		var errs []error
+		for _, host := range dockerHosts {
+			if _, err := dockerClis[host].Ping(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", host, err))
+				continue
+			}
+
+			return nil
+		}
 
-	containers, err := cli.ContainerList(context.Background(), container.ListOptions{})
-	if err != nil {
-		log.Fatalf("Ошибка при получении списка контейнеров: %s", err)
+		return errors.Join(errs...)
 	}
 
-	var dockerContainers []dockerContainer
-	for _, c := range containers {
-		containerDetails, err := cli.ContainerInspect(context.Background(), c.ID)
+	senderOpts := []delivery.Option{
+		delivery.BufferSize(cfg.DeliveryBuffer),
+		delivery.ApiKey(cfg.BackendApiKey),
+	}
+	if cfg.BackendCAFile != "" || cfg.BackendCertFile != "" {
+		tlsConfig, err := delivery.NewTLSConfig(delivery.TLSFiles{
+			CAFile:   cfg.BackendCAFile,
+			CertFile: cfg.BackendCertFile,
+			KeyFile:  cfg.BackendKeyFile,
+		})
 		if err != nil {
-			log.Printf("Ошибка при получении деталей контейнера %s: %s", c.ID[:10], err)
-			continue
+			log.Fatalf("Ошибка настройки TLS для бэкенда: %s", err)
 		}
 
-		if _, ok := containerDetails.NetworkSettings.Networks["ping_network"]; !ok {
-			continue
-		}
+		senderOpts = append(senderOpts, delivery.TLSConfig(tlsConfig))
+	}
+
+	sender := delivery.New(cfg.BackendURL, pingerMetrics, targets, senderOpts...)
 
-		ipAddress := containerDetails.NetworkSettings.Networks["ping_network"].IPAddress
+	providers := []discovery.Provider{
+		discovery.NewStaticProvider(cfg.StaticGroups()),
+	}
+	if cfg.DockerDiscovery {
+		providers = append(providers, discovery.NewDaemonProvider(dockerHosts))
 
-		if ipAddress == "" {
-			for _, netw := range containerDetails.NetworkSettings.Networks {
-				ipAddress = netw.IPAddress
+		for _, host := range dockerHosts {
+			if cfg.DockerSwarm {
+				providers = append(providers, discovery.NewSwarmProvider(host, dockerClis[host], cfg.PingInterval))
+			} else {
+				providers = append(providers, discovery.NewDockerProvider(host, dockerClis[host], cfg.DockerNetworks, cfg.DockerAttach, cfg.PingInterval))
 			}
 		}
+	}
+	if len(cfg.FileSDFiles) > 0 {
+		providers = append(providers, discovery.NewFileProvider(cfg.FileSDFiles, cfg.FileSDRefresh))
+	}
+	if len(cfg.DnsSDNames) > 0 || len(cfg.DnsSDSRVNames) > 0 {
+		var resolver *net.Resolver
+		if cfg.DnsSDServer != "" {
+			resolver = discovery.NewResolver(cfg.DnsSDServer)
+		}
 
-		dockerContainers = append(dockerContainers, dockerContainer{
-			Id:     c.ID,
-			Image:  c.Image,
-			Status: c.Status,
-			Ip:     ipAddress,
-		})
+		providers = append(providers, discovery.NewDNSProvider(
+			cfg.DnsSDNames, cfg.DnsSDSRVNames, cfg.DnsSDProbePorts, cfg.DnsSDRefresh, resolver,
+		))
+	}
+	for _, url := range cfg.HttpSDURLs {
+		providers = append(providers, discovery.NewHTTPProvider(url, cfg.HttpSDRefresh, cfg.HttpSDHeaders))
 	}
 
-	return dockerContainers
-}
+	go discovery.NewManager(targets, providers...).Run(ctx)
 
-func sendPingResult(result PingResult) {
-	postBody, _ := json.Marshal(result)
-	responseBody := bytes.NewBuffer(postBody)
-	_, err := http.Post(
-		fmt.Sprintf("http://backend:8080/v1/containers/%s", result.IP),
-		"application/json",
-		responseBody,
-	)
-	if err != nil {
-		log.Fatalf("An Error Occured %v", err)
+	// наблюдатели и сборщик статистики отправляют через sender, поэтому должны остановиться до sender.Shutdown
+	var watchers sync.WaitGroup
+	if cfg.DockerEvents {
+		for _, host := range dockerHosts {
+			w := lifecycle.NewWatcher(host, dockerClis[host], targets, sender, cfg.CrashLoopCount, cfg.CrashLoopWindow)
+
+			watchers.Add(1)
+			go func() {
+				defer watchers.Done()
+				w.Run(ctx)
+			}()
+		}
 	}
-}
 
-func uniteConteiners() {
-	ctx := context.Background()
+	if cfg.DockerStats {
+		statsCollector := stats.New(dockerClis, targets, sender, cfg.ProbeTimeout, cfg.ProbeConcurrency)
 
-	cli, err := client.NewClientWithOpts(
-		client.WithHost("tcp://host.docker.internal:2375"), // Используем TCP
-		client.WithAPIVersionNegotiation(),
-	)
-	if err != nil {
-		log.Fatalf("Ошибка при создании клиента: %s", err)
+		watchers.Add(1)
+		go func() {
+			defer watchers.Done()
+			statsCollector.Run(ctx, cfg.PingInterval)
+		}()
 	}
 
-	containers, err := cli.ContainerList(context.Background(), container.ListOptions{})
-	if err != nil {
-		log.Fatalf("Ошибка при получении списка контейнеров: %s", err)
-	}
+	sched := scheduler.New(probers, targets, sender, pingerMetrics, cfg.ProbeTimeout, cfg.ProbeConcurrency)
 
-	networkName := "ping_network"
-	net, err := cli.NetworkInspect(ctx, networkName, network.InspectOptions{})
-	if err != nil {
-		log.Fatalln("Ошибка сети")
-	}
+	handler := echo.New()
+	api.NewRouter(handler, cfg, pingerMetrics, probers, targets, dockerCheck, sender.Ping)
 
-	for _, c := range containers {
-		isConnected := false
+	httpServer := httpserver.New(handler,
+		httpserver.Port(strconv.Itoa(cfg.HttpServerPort)),
+		httpserver.WriteTimeout(cfg.ProbeTimeout+time.Second),
+	)
 
-		for k := range net.Containers {
-			if k == c.ID {
-				isConnected = true
-				break
-			}
-		}
+	ticker := time.NewTicker(cfg.PingInterval)
+	defer ticker.Stop()
 
-		if !isConnected {
-			err = cli.NetworkConnect(ctx, networkName, c.ID, nil)
-			if err != nil {
-				log.Printf("Ошибка подключения контейнера %s к сети %s: %v", c.ID, networkName, err)
-			} else {
-				log.Printf("Контейнер %s подключен к сети %s\n", c.Image, networkName)
-
-				err = cli.ContainerRestart(ctx, c.ID, container.StopOptions{})
-				if err != nil {
-					log.Printf("Ошибка перезапуска контейнера %s: %v", c.Image, err)
-				} else {
-					log.Printf("Контейнер %s перезапущен\n", c.Image)
-				}
-			}
-		} else {
-			log.Printf("Контейнер %s уже подключен к сети %s\n", c.Image, networkName)
+loop:
+	for {
+		cycleStart := time.Now()
+
+		sched.ProbeAll(ctx)
+
+		pingerMetrics.ObserveCycle(len(targets.Targets()), time.Since(cycleStart))
+
+		log.Printf("sleeping %s\n", cfg.PingInterval)
+
+		select {
+		case <-ctx.Done():
+			log.Println("Получен сигнал остановки")
+			break loop
+		case err := <-httpServer.Notify():
+			log.Printf("Ошибка HTTP-сервера: %s", err)
+			break loop
+		case <-ticker.C:
 		}
 	}
 
-	log.Println("Все контейнеры были обработаны")
-}
+	// shutdown
+	stop()
+	watchers.Wait()
 
-func main() {
-	for {
-		uniteConteiners()
-		dockerContainers := takeDockerContainers()
-
-		for _, ip := range dockerContainers {
-			pingTime, success := pingFunc(ip.Ip) // Пингуем IP-адрес
-
-			if success {
-				sendPingResult(PingResult{
-					IP:             ip.Ip,
-					PingTime:       pingTime,
-					Success:        success,
-					LastSuccessful: time.Now().UTC(),
-				})
-			}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
 
-			log.Printf("IP: %s, PingTime: %d Success: %t Image: %s\n", ip.Ip, pingTime, success, ip.Image)
-		}
+	if err := sender.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Ошибка досылки результатов: %s", err)
+	}
 
-		time.Sleep(10 * time.Second)
-		log.Printf("sleeping %d second\n", 10)
+	if err := httpServer.Shutdown(); err != nil {
+		log.Printf("Ошибка остановки HTTP-сервера: %s", err)
+	}
+
+	for host, cli := range dockerClis {
+		if err := cli.Close(); err != nil {
+			log.Printf("Ошибка закрытия Docker-клиента %s: %s", host, err)
+		}
 	}
 
+	log.Println("Пингер остановлен")
 }
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/k1v4/Pinger/pinger/internal/discovery"
+	"github.com/k1v4/Pinger/pinger/internal/docker"
+)
+
+type Config struct {
+	HttpServerPort int           `yaml:"http_server_port" env:"HTTP_SERVER_PORT" env-description:"probe and metrics server port" env-default:"8081"`
+	BackendURL     string        `yaml:"backend_url" env:"BACKEND_URL" env-description:"backend base url" env-default:"http://backend:8080"`
+	BackendApiKey  string        `yaml:"backend_api_key" env:"BACKEND_API_KEY" env-description:"backend api key with the ingest scope"`
+	ProbeTimeout   time.Duration `yaml:"probe_timeout" env:"PROBE_TIMEOUT" env-description:"default timeout of a single probe" env-default:"10s"`
+
+	BackendCAFile   string `yaml:"backend_ca_file" env:"BACKEND_CA_FILE" env-description:"ca of the backend https certificate, system cas if empty"`
+	BackendCertFile string `yaml:"backend_cert_file" env:"BACKEND_CERT_FILE" env-description:"client certificate the backend identifies the pinger by"`
+	BackendKeyFile  string `yaml:"backend_key_file" env:"BACKEND_KEY_FILE" env-description:"client certificate key"`
+
+	ReadyDeliveryWindow time.Duration `yaml:"ready_delivery_window" env:"READY_DELIVERY_WINDOW" env-description:"max age of the last delivery attempt /readyz relies on before checking the backend directly" env-default:"60s"`
+
+	PingInterval     time.Duration `yaml:"ping_interval" env:"PING_INTERVAL" env-description:"pause between probing cycles and container stats snapshots" env-default:"10s"`
+	ProbeConcurrency int           `yaml:"probe_concurrency" env:"PROBE_CONCURRENCY" env-description:"targets probed at the same time" env-default:"16"`
+	DeliveryBuffer   int           `yaml:"delivery_buffer" env:"DELIVERY_BUFFER" env-description:"results buffered for the backend" env-default:"1024"`
+	ShutdownTimeout  time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" env-description:"time to flush buffered results on shutdown" env-default:"5s"`
+
+	DockerDiscovery bool                    `yaml:"docker_discovery" env:"DOCKER_DISCOVERY" env-description:"discover docker containers" env-default:"true"`
+	DockerHosts     []string                `yaml:"-" env:"DOCKER_HOSTS" env-description:"comma separated docker endpoints, DOCKER_HOST if empty"`
+	DockerEndpoints []docker.Endpoint       `yaml:"docker_hosts"`
+	DockerNetworks  []string                `yaml:"docker_networks" env:"DOCKER_NETWORKS" env-description:"docker networks to probe: names, globs or all" env-default:"ping_network"`
+	DockerAttach    bool                    `yaml:"docker_attach" env:"DOCKER_ATTACH" env-description:"attach all containers to the probed network" env-default:"true"`
+	DockerStats     bool                    `yaml:"docker_stats" env:"DOCKER_STATS" env-description:"collect container cpu, memory, network and block io stats" env-default:"false"`
+	DockerEvents    bool                    `yaml:"docker_events" env:"DOCKER_EVENTS" env-description:"report exits, oom kills and crash loops" env-default:"true"`
+	CrashLoopCount  int                     `yaml:"crash_loop_restarts" env:"CRASH_LOOP_RESTARTS" env-description:"restarts within the window that make a crash loop" env-default:"3"`
+	CrashLoopWindow time.Duration           `yaml:"crash_loop_window" env:"CRASH_LOOP_WINDOW" env-description:"crash loop detection window" env-default:"5m"`
+	DockerSwarm     bool                    `yaml:"docker_swarm" env:"DOCKER_SWARM" env-description:"discover swarm service tasks instead of local containers" env-default:"false"`
+	StaticTargets   []string                `yaml:"static_targets" env:"STATIC_TARGETS" env-description:"comma separated host or host:port targets"`
+	StaticConfigs   []discovery.TargetGroup `yaml:"static_configs"`
+	FileSDFiles     []string                `yaml:"file_sd_files" env:"FILE_SD_FILES" env-description:"comma separated file_sd json/yaml globs"`
+	FileSDRefresh   time.Duration           `yaml:"file_sd_refresh" env:"FILE_SD_REFRESH" env-description:"re-read interval of file_sd files" env-default:"5m"`
+	HttpSDURLs      []string                `yaml:"http_sd_urls" env:"HTTP_SD_URLS" env-description:"comma separated http_sd endpoints"`
+	HttpSDRefresh   time.Duration           `yaml:"http_sd_refresh" env:"HTTP_SD_REFRESH" env-description:"poll interval of http_sd endpoints" env-default:"60s"`
+	HttpSDHeaders   map[string]string       `yaml:"http_sd_headers" env:"HTTP_SD_HEADERS" env-description:"headers for http_sd requests, e.g. Authorization:Bearer token"`
+	DnsSDNames      []string                `yaml:"dns_sd_names" env:"DNS_SD_NAMES" env-description:"comma separated names resolved to A/AAAA targets"`
+	DnsSDSRVNames   []string                `yaml:"dns_sd_srv_names" env:"DNS_SD_SRV_NAMES" env-description:"comma separated SRV names"`
+	DnsSDProbePorts bool                    `yaml:"dns_sd_probe_ports" env:"DNS_SD_PROBE_PORTS" env-description:"also probe SRV ports over tcp" env-default:"false"`
+	DnsSDRefresh    time.Duration           `yaml:"dns_sd_refresh" env:"DNS_SD_REFRESH" env-description:"re-resolve interval of dns names" env-default:"30s"`
+	DnsSDServer     string                  `yaml:"dns_sd_server" env:"DNS_SD_SERVER" env-description:"host:port of the dns server, system resolver if empty"`
+}
+
+// MustLoadConfig читает конфиг из YAML-файла CONFIG_PATH, если он задан, и из переменных окружения.
+func MustLoadConfig() *Config {
+	cfg := Config{}
+
+	var err error
+	if path := os.Getenv("CONFIG_PATH"); path != "" {
+		err = cleanenv.ReadConfig(path, &cfg)
+	} else {
+		err = cleanenv.ReadEnv(&cfg)
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	return &cfg
+}
+
+// CheckBackendAuth проверяет, что пингеру есть чем подтвердить себя бэкенду: ключом или клиентским сертификатом.
+// Заглушка из примера .env ключом не считается.
+func (c *Config) CheckBackendAuth() error {
+	if strings.HasPrefix(strings.ToLower(c.BackendApiKey), "change-me") {
+		return errors.New("BACKEND_API_KEY - заглушка из примера, задайте случайный ключ")
+	}
+	if c.BackendApiKey == "" && c.BackendCertFile == "" {
+		return errors.New("не задан ни BACKEND_API_KEY, ни BACKEND_CERT_FILE")
+	}
+
+	return nil
+}
+
+// StaticGroups - static_configs вместе с целями из STATIC_TARGETS.
+func (c *Config) StaticGroups() []discovery.TargetGroup {
+	groups := c.StaticConfigs
+	if len(c.StaticTargets) > 0 {
+		groups = append(groups, discovery.TargetGroup{Targets: c.StaticTargets})
+	}
+
+	return groups
+}
+
+// Dockers - Docker-демоны из docker_hosts, DOCKER_HOSTS или один демон по DOCKER_HOST.
+// Имя демона без явного name - хост из адреса или "local" для unix-сокета.
+func (c *Config) Dockers() []docker.Endpoint {
+	if len(c.DockerEndpoints) > 0 {
+		endpoints := make([]docker.Endpoint, 0, len(c.DockerEndpoints))
+		for _, e := range c.DockerEndpoints {
+			if e.Name == "" {
+				e.Name = endpointName(e.Host)
+			}
+			endpoints = append(endpoints, e)
+		}
+
+		return endpoints
+	}
+
+	hosts := c.DockerHosts
+	if len(hosts) == 0 {
+		hosts = []string{discovery.DockerHost()}
+	}
+
+	endpoints := make([]docker.Endpoint, 0, len(hosts))
+	for _, h := range hosts {
+		endpoints = append(endpoints, docker.Endpoint{Name: endpointName(h), Host: h})
+	}
+
+	return endpoints
+}
+
+func endpointName(host string) string {
+	u, err := url.Parse(host)
+	if err != nil || u.Host == "" {
+		return "local"
+	}
+
+	return u.Hostname()
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+	"github.com/labstack/echo/v4"
+)
+
+const _readyCheckTimeout = 3 * time.Second
+
+type healthRoutes struct {
+	inv            *inventory.Inventory
+	dockerCheck    func(ctx context.Context) error
+	backendCheck   func(ctx context.Context) error
+	deliveryWindow time.Duration
+}
+
+type readyResponse struct {
+	Status       string            `json:"status"`
+	Checks       map[string]string `json:"checks"`
+	LastDelivery *time.Time        `json:"last_delivery,omitempty"`
+}
+
+func (hr *healthRoutes) Healthz(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readyz - пингер готов, если Docker и бэкенд доступны. Доступность бэкенда берётся из последней
+// отправки за deliveryWindow, а без недавних отправок (целей нет или все они не отвечают) проверяется
+// запросом к бэкенду.
+func (hr *healthRoutes) Readyz(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), _readyCheckTimeout)
+	defer cancel()
+
+	resp := readyResponse{
+		Status: "ok",
+		Checks: map[string]string{"docker": "ok", "backend": "ok"},
+	}
+
+	if err := hr.dockerCheck(ctx); err != nil {
+		resp.Status = "fail"
+		resp.Checks["docker"] = err.Error()
+	}
+
+	if last := hr.inv.LastDelivery(); !last.IsZero() {
+		resp.LastDelivery = &last
+	}
+
+	attempt, err := hr.inv.LastAttempt()
+	if attempt.IsZero() || time.Since(attempt) > hr.deliveryWindow {
+		err = hr.backendCheck(ctx)
+	}
+	if err != nil {
+		resp.Status = "fail"
+		resp.Checks["backend"] = err.Error()
+	}
+
+	if resp.Status != "ok" {
+		return c.JSON(http.StatusServiceUnavailable, resp)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (hr *healthRoutes) Targets(c echo.Context) error {
+	return c.JSON(http.StatusOK, hr.inv.Targets())
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/k1v4/Pinger/pinger/internal/metrics"
+	"github.com/k1v4/Pinger/pinger/internal/prober"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// запас на отдачу ответа, как timeout_offset у blackbox_exporter
+const _timeoutOffset = 500 * time.Millisecond
+
+type probeRoutes struct {
+	probers *prober.Registry
+	m       *metrics.Metrics
+	timeout time.Duration
+}
+
+func newProbeRoutes(probers *prober.Registry, m *metrics.Metrics, timeout time.Duration) *probeRoutes {
+	return &probeRoutes{probers: probers, m: m, timeout: timeout}
+}
+
+// Probe повторяет контракт /probe blackbox_exporter: на каждый запрос выполняется одна проверка,
+// результат отдаётся в формате Prometheus.
+func (pr *probeRoutes) Probe(c echo.Context) error {
+	target := c.QueryParam("target")
+	if target == "" {
+		return c.String(http.StatusBadRequest, "Target parameter is missing")
+	}
+
+	moduleName := c.QueryParam("module")
+	if moduleName == "" {
+		moduleName = "icmp"
+	}
+
+	probe, err := pr.probers.Get(moduleName)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Unknown module "+strconv.Quote(moduleName))
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), pr.scrapeTimeout(c.Request()))
+	defer cancel()
+
+	result := probe.Probe(ctx, target)
+	pr.m.ObserveProbe(moduleName, result.Success, result.Duration)
+
+	registry := prometheus.NewRegistry()
+
+	success := 0.0
+	if result.Success {
+		success = 1
+	}
+
+	gauge(registry, "probe_success", "Displays whether or not the probe was a success", success)
+	gauge(registry, "probe_duration_seconds", "Returns how long the probe took to complete in seconds", result.Duration.Seconds())
+
+	for name, value := range result.Metrics {
+		gauge(registry, "probe_"+name, "Value reported by the "+moduleName+" module", value)
+	}
+
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
+
+func (pr *probeRoutes) scrapeTimeout(r *http.Request) time.Duration {
+	v := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
+	if v == "" {
+		return pr.timeout
+	}
+
+	seconds, err := strconv.ParseFloat(v, 64)
+	if err != nil || seconds <= 0 {
+		return pr.timeout
+	}
+
+	timeout := time.Duration(seconds*float64(time.Second)) - _timeoutOffset
+	if timeout <= 0 || timeout > pr.timeout {
+		return pr.timeout
+	}
+
+	return timeout
+}
+
+func gauge(registry *prometheus.Registry, name, help string, value float64) {
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Name: name, Help: help})
+	g.Set(value)
+	registry.MustRegister(g)
+}
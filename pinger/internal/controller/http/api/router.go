@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/k1v4/Pinger/pinger/internal/config"
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+	"github.com/k1v4/Pinger/pinger/internal/metrics"
+	"github.com/k1v4/Pinger/pinger/internal/prober"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+func NewRouter(
+	handler *echo.Echo,
+	cfg *config.Config,
+	m *metrics.Metrics,
+	probers *prober.Registry,
+	inv *inventory.Inventory,
+	dockerCheck func(ctx context.Context) error,
+	backendCheck func(ctx context.Context) error,
+) {
+	// Middleware
+	handler.Use(middleware.Recover())
+
+	h := &healthRoutes{
+		inv:            inv,
+		dockerCheck:    dockerCheck,
+		backendCheck:   backendCheck,
+		deliveryWindow: cfg.ReadyDeliveryWindow,
+	}
+
+	handler.GET("/healthz", h.Healthz)
+	handler.GET("/readyz", h.Readyz)
+	handler.GET("/debug/targets", h.Targets)
+
+	handler.GET("/metrics", echo.WrapHandler(m.Handler()))
+
+	// GET /probe?target=...&module=icmp|tcp|http|tls
+	handler.GET("/probe", newProbeRoutes(probers, m, cfg.ProbeTimeout).Probe)
+
+	handler.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, "Pinger: /probe, /metrics, /healthz, /readyz, /debug/targets")
+	})
+}
@@ -0,0 +1,40 @@
+package delivery
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+// Option -.
+type Option func(*Sender)
+
+// BufferSize -.
+func BufferSize(size int) Option {
+	return func(s *Sender) {
+		s.queue = make(chan request, size)
+	}
+}
+
+// RequestTimeout -.
+func RequestTimeout(timeout time.Duration) Option {
+	return func(s *Sender) {
+		s.client.Timeout = timeout
+	}
+}
+
+// ApiKey - ключ бэкенда с правом ingest, передаётся в заголовке Authorization.
+func ApiKey(key string) Option {
+	return func(s *Sender) {
+		s.apiKey = key
+	}
+}
+
+// TLSConfig - https к бэкенду с CA и клиентским сертификатом из NewTLSConfig.
+func TLSConfig(config *tls.Config) Option {
+	return func(s *Sender) {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = config
+		s.client.Transport = transport
+	}
+}
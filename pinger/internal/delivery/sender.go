@@ -0,0 +1,264 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+	"github.com/k1v4/Pinger/pinger/internal/metrics"
+)
+
+const (
+	_defaultBufferSize     = 1024
+	_defaultRequestTimeout = 5 * time.Second
+)
+
+type PingResult struct {
+	IP             string            `json:"ip"`            // ip-адрес контейнера
+	PingTime       int               `json:"ping_time"`     // продолжительность пинга в миллисекундах
+	Success        bool              `json:"is_successful"` // успешен ли пинг
+	LastSuccessful *time.Time        `json:"last_successful,omitempty"`
+	Name           string            `json:"name,omitempty"`   // имя контейнера, задачи или хоста
+	Image          string            `json:"image,omitempty"`  // образ контейнера
+	Labels         map[string]string `json:"labels,omitempty"` // метки цели, по ним фильтруется список в бэкенде
+}
+
+// StatsResult - потребление ресурсов контейнера, скорости в байтах в секунду.
+type StatsResult struct {
+	IP             string    `json:"-"`
+	Time           time.Time `json:"time"`
+	CPUPercent     float64   `json:"cpu_percent"`
+	MemoryUsage    uint64    `json:"memory_usage"`
+	MemoryLimit    uint64    `json:"memory_limit"`
+	NetRxRate      float64   `json:"net_rx_bytes_per_second"`
+	NetTxRate      float64   `json:"net_tx_bytes_per_second"`
+	BlockReadRate  float64   `json:"block_read_bytes_per_second"`
+	BlockWriteRate float64   `json:"block_write_bytes_per_second"`
+}
+
+// ContainerEvent - событие жизненного цикла контейнера.
+type ContainerEvent struct {
+	IP            string    `json:"-"`
+	Type          string    `json:"type"`
+	ContainerID   string    `json:"container_id"`
+	ContainerName string    `json:"container_name"`
+	Image         string    `json:"image"`
+	DockerHost    string    `json:"docker_host"`
+	ExitCode      *int      `json:"exit_code,omitempty"`
+	RestartCount  int       `json:"restart_count"`
+	Message       string    `json:"message,omitempty"`
+	Time          time.Time `json:"time"`
+}
+
+// request - одна отправка в бэкенд: POST body на path.
+type request struct {
+	ip   string
+	path string
+	body any
+}
+
+// Sender отправляет результаты в бэкенд из фоновой горутины через буферизованную очередь,
+// чтобы медленный бэкенд не задерживал цикл проверок.
+type Sender struct {
+	backendURL string
+	apiKey     string
+	client     *http.Client
+	m          *metrics.Metrics
+	inv        *inventory.Inventory
+
+	queue chan request
+	done  chan struct{}
+	lost  int
+
+	// ctx запросов живёт дольше корневого контекста, чтобы при остановке дослать буфер
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func New(backendURL string, m *metrics.Metrics, inv *inventory.Inventory, opts ...Option) *Sender {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &Sender{
+		backendURL: backendURL,
+		client:     &http.Client{Timeout: _defaultRequestTimeout},
+		m:          m,
+		inv:        inv,
+		queue:      make(chan request, _defaultBufferSize),
+		done:       make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+
+	// Custom options
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	go s.run()
+
+	return s
+}
+
+// Send ставит результат проверки в очередь. При переполненном буфере результат отбрасывается.
+func (s *Sender) Send(result PingResult) {
+	s.enqueue(request{
+		ip:   result.IP,
+		path: "/v1/containers/" + url.PathEscape(result.IP),
+		body: result,
+	})
+}
+
+// SendStats ставит в очередь снимок потребления ресурсов.
+func (s *Sender) SendStats(stats StatsResult) {
+	s.enqueue(request{
+		ip:   stats.IP,
+		path: "/v1/containers/" + url.PathEscape(stats.IP) + "/stats",
+		body: stats,
+	})
+}
+
+// SendEvent ставит в очередь событие жизненного цикла контейнера.
+// Событие контейнера с неизвестным ip уходит по id контейнера.
+func (s *Sender) SendEvent(event ContainerEvent) {
+	key := event.IP
+	if key == "" {
+		key = event.ContainerID
+	}
+
+	s.enqueue(request{
+		ip:   key,
+		path: "/v1/containers/" + url.PathEscape(key) + "/events",
+		body: event,
+	})
+}
+
+func (s *Sender) enqueue(r request) {
+	select {
+	case s.queue <- r:
+	default:
+		s.m.ObserveDropped()
+		log.Printf("Буфер отправки заполнен, результат %s отброшен", r.ip)
+	}
+}
+
+// Shutdown перестаёт принимать результаты и досылает буфер, пока не истёк ctx.
+// Send после Shutdown вызывать нельзя.
+func (s *Sender) Shutdown(ctx context.Context) error {
+	close(s.queue)
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-s.done
+
+		return fmt.Errorf("delivery - Shutdown: не отправлено %d результатов: %w", s.lost, ctx.Err())
+	}
+}
+
+func (s *Sender) run() {
+	defer close(s.done)
+	defer s.cancel()
+
+	for r := range s.queue {
+		if s.ctx.Err() != nil {
+			s.lost++
+			continue
+		}
+
+		start := time.Now()
+		err := s.send(s.ctx, r)
+		s.m.ObserveDelivery(err == nil, time.Since(start))
+
+		if err != nil {
+			if s.ctx.Err() != nil {
+				s.lost++
+			}
+
+			log.Printf("Ошибка отправки результата %s: %v", r.ip, err)
+			s.inv.DeliveryAttempt(time.Now(), unavailable(err))
+			continue
+		}
+
+		s.inv.Delivered(time.Now())
+	}
+}
+
+func (s *Sender) send(ctx context.Context, r request) error {
+	postBody, err := json.Marshal(r.body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		s.backendURL+r.path,
+		bytes.NewBuffer(postBody),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &statusError{status: resp.Status, code: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// Ping проверяет, что бэкенд отвечает на /health по тому же соединению, что и отправка результатов.
+func (s *Sender) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.backendURL+"/health", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &statusError{status: resp.Status, code: resp.StatusCode}
+	}
+
+	return nil
+}
+
+type statusError struct {
+	status string
+	code   int
+}
+
+func (e *statusError) Error() string {
+	return "backend ответил " + e.status
+}
+
+// unavailable оставляет ошибки, из-за которых бэкенд не принимает результаты совсем: нет соединения,
+// 5xx или отказ в доступе. Отказ в отдельном результате (400, 404) значит, что бэкенд доступен.
+func unavailable(err error) error {
+	var se *statusError
+	if errors.As(err, &se) && se.code < 500 && se.code != http.StatusUnauthorized && se.code != http.StatusForbidden {
+		return nil
+	}
+
+	return err
+}
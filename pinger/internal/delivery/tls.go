@@ -0,0 +1,118 @@
+package delivery
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// _certCheckInterval - как часто при подключениях проверяется, не сменился ли клиентский сертификат.
+const _certCheckInterval = 10 * time.Second
+
+// TLSFiles - сертификаты пингера для https к бэкенду.
+type TLSFiles struct {
+	// CAFile - CA сертификата бэкенда, системные CA если пусто
+	CAFile string
+	// CertFile и KeyFile - клиентский сертификат пингера, по нему бэкенд узнаёт агента
+	CertFile string
+	KeyFile  string
+}
+
+// NewTLSConfig - конфиг клиента с CA бэкенда и клиентским сертификатом. Сертификат перечитывается
+// из файлов, когда они меняются, так что его можно перевыпускать без перезапуска пингера.
+func NewTLSConfig(files TLSFiles) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if files.CAFile != "" {
+		pem, err := os.ReadFile(files.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("delivery - NewTLSConfig: %w", err)
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("delivery - NewTLSConfig: no certificates in %s", files.CAFile)
+		}
+	}
+
+	if files.CertFile != "" {
+		cert := &clientCert{certFile: files.CertFile, keyFile: files.KeyFile}
+		if err := cert.load(); err != nil {
+			return nil, fmt.Errorf("delivery - NewTLSConfig: %w", err)
+		}
+
+		config.GetClientCertificate = cert.get
+	}
+
+	return config, nil
+}
+
+type clientCert struct {
+	certFile string
+	keyFile  string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func (c *clientCert) get(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.checkedAt) < _certCheckInterval {
+		return c.cert, nil
+	}
+	c.checkedAt = now
+
+	modTime, err := c.latestModTime()
+	if err == nil && !modTime.Equal(c.modTime) {
+		err = c.load()
+	}
+	if err != nil {
+		// файлы могут быть записаны не до конца, старый сертификат работает до следующей проверки
+		log.Printf("Ошибка чтения клиентского сертификата: %v", err)
+	}
+
+	return c.cert, nil
+}
+
+// load читает сертификат и ключ. Вызывается под c.mu или до первого подключения.
+func (c *clientCert) load() error {
+	modTime, err := c.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+
+	c.cert, c.modTime = &cert, modTime
+
+	return nil
+}
+
+func (c *clientCert) latestModTime() (time.Time, error) {
+	var latest time.Time
+
+	for _, name := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
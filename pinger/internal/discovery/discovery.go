@@ -0,0 +1,149 @@
+package discovery
+
+import (
+	"context"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+	"github.com/k1v4/Pinger/pinger/internal/prober"
+)
+
+// LabelProbes - то же, что prober.LabelProbes, для форматов, где точка в имени label недопустима (file_sd).
+const LabelProbes = "pinger_probes"
+
+// Provider - источник целей. Run отправляет в up полный актуальный набор целей провайдера
+// при каждом изменении и завершается с отменой ctx.
+type Provider interface {
+	Name() string
+	Run(ctx context.Context, up chan<- Update)
+}
+
+// Update - полный набор целей одного провайдера.
+type Update struct {
+	Provider string
+	Targets  []inventory.Target
+}
+
+// TargetGroup - группа целей в формате Prometheus static_configs/file_sd_configs.
+type TargetGroup struct {
+	Targets []string          `json:"targets" yaml:"targets"`
+	Labels  map[string]string `json:"labels" yaml:"labels"`
+}
+
+// Manager объединяет цели всех провайдеров в один инвентарь.
+type Manager struct {
+	inv       *inventory.Inventory
+	providers []Provider
+}
+
+func NewManager(inv *inventory.Inventory, providers ...Provider) *Manager {
+	return &Manager{
+		inv:       inv,
+		providers: providers,
+	}
+}
+
+// Run запускает провайдеров и обновляет инвентарь, пока не отменён ctx.
+func (m *Manager) Run(ctx context.Context) {
+	up := make(chan Update)
+
+	for _, p := range m.providers {
+		go p.Run(ctx, up)
+	}
+
+	sets := make(map[string][]inventory.Target, len(m.providers))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case u := <-up:
+			sets[u.Provider] = u.Targets
+
+			var merged []inventory.Target
+			for _, targets := range sets {
+				merged = append(merged, targets...)
+			}
+
+			m.inv.Set(merged)
+		}
+	}
+}
+
+func send(ctx context.Context, up chan<- Update, name string, targets []inventory.Target) {
+	select {
+	case up <- Update{Provider: name, Targets: targets}:
+	case <-ctx.Done():
+	}
+}
+
+// groupTargets превращает группы целей в цели инвентаря с ID вида "<source>/<адрес>".
+func groupTargets(source string, groups []TargetGroup) []inventory.Target {
+	var targets []inventory.Target
+
+	for _, g := range groups {
+		for _, addr := range g.Targets {
+			host, port := splitTarget(addr)
+
+			targets = append(targets, inventory.Target{
+				ID:      source + "/" + addr,
+				Address: host,
+				Labels:  g.Labels,
+				Probes:  specsFor(addr, g.Labels, port),
+			})
+		}
+	}
+
+	return targets
+}
+
+// splitTarget разбирает host:port, IPv6 без порта допускается и в квадратных скобках.
+func splitTarget(addr string) (string, int) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"), 0
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return addr, 0
+	}
+
+	return host, port
+}
+
+// familySuffix отличает id цели с IPv6-адресом от цели с IPv4-адресом в той же сети.
+func familySuffix(ip string) string {
+	if strings.Contains(ip, ":") {
+		return "/ipv6"
+	}
+
+	return ""
+}
+
+// specsFor берёт проверки из label цели, а без него проверяет порт по TCP или хост по ICMP.
+func specsFor(id string, labels map[string]string, port int) []prober.Spec {
+	for _, key := range []string{prober.LabelProbes, LabelProbes} {
+		v, ok := labels[key]
+		if !ok {
+			continue
+		}
+
+		specs, err := prober.ParseSpecs(v)
+		if err != nil {
+			log.Printf("Неверный %s у цели %s: %s", key, id, err)
+			break
+		}
+
+		return specs
+	}
+
+	if port != 0 {
+		return []prober.Spec{{Type: "tcp", Port: port}}
+	}
+
+	return prober.DefaultSpecs()
+}
@@ -0,0 +1,177 @@
+package discovery
+
+import (
+	"context"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+	"github.com/k1v4/Pinger/pinger/internal/prober"
+)
+
+const (
+	LabelDNSName   = "dns_name"
+	LabelSRVTarget = "srv_target"
+	LabelSRVPort   = "srv_port"
+)
+
+// DNSProvider периодически резолвит имена: A/AAAA-записи дают по цели на адрес,
+// SRV-записи - по цели на адрес каждого target, с проверкой его порта, если включено probePorts.
+// Если имя не резолвится, для него остаются цели прошлого удачного запроса.
+type DNSProvider struct {
+	names      []string
+	srvNames   []string
+	probePorts bool
+	refresh    time.Duration
+	resolver   *net.Resolver
+
+	lastGood map[string][]inventory.Target
+}
+
+func NewDNSProvider(names, srvNames []string, probePorts bool, refresh time.Duration, resolver *net.Resolver) *DNSProvider {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return &DNSProvider{
+		names:      names,
+		srvNames:   srvNames,
+		probePorts: probePorts,
+		refresh:    refresh,
+		resolver:   resolver,
+		lastGood:   make(map[string][]inventory.Target),
+	}
+}
+
+// NewResolver возвращает резолвер, который ходит только на server (host:port), например на локальный DNS.
+func NewResolver(server string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+func (dp *DNSProvider) Name() string {
+	return "dns"
+}
+
+func (dp *DNSProvider) Run(ctx context.Context, up chan<- Update) {
+	ticker := time.NewTicker(dp.refresh)
+	defer ticker.Stop()
+
+	for {
+		for _, name := range dp.names {
+			dp.resolve(ctx, name, dp.lookupHost)
+		}
+		for _, name := range dp.srvNames {
+			dp.resolve(ctx, name, dp.lookupSRV)
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		send(ctx, up, dp.Name(), dp.targets())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (dp *DNSProvider) resolve(ctx context.Context, name string, lookup func(context.Context, string) ([]inventory.Target, error)) {
+	targets, err := lookup(ctx, name)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("dns_sd %s: %s, используются прошлые адреса (%d)", name, err, len(dp.lastGood[name]))
+		}
+		return
+	}
+
+	dp.lastGood[name] = targets
+}
+
+func (dp *DNSProvider) lookupHost(ctx context.Context, name string) ([]inventory.Target, error) {
+	addrs, err := dp.resolver.LookupIPAddr(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{LabelDNSName: name}
+
+	targets := make([]inventory.Target, 0, len(addrs))
+	for _, a := range addrs {
+		targets = append(targets, inventory.Target{
+			ID:      "dns/" + name + "/" + a.IP.String(),
+			Address: a.IP.String(),
+			Name:    name,
+			Labels:  labels,
+			Probes:  prober.DefaultSpecs(),
+		})
+	}
+
+	return targets, nil
+}
+
+func (dp *DNSProvider) lookupSRV(ctx context.Context, name string) ([]inventory.Target, error) {
+	_, records, err := dp.resolver.LookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []inventory.Target
+	for _, srv := range records {
+		addrs, err := dp.resolver.LookupIPAddr(ctx, srv.Target)
+		if err != nil {
+			log.Printf("dns_sd %s: target %s: %s", name, srv.Target, err)
+			continue
+		}
+
+		port := strconv.Itoa(int(srv.Port))
+		labels := map[string]string{
+			LabelDNSName:   name,
+			LabelSRVTarget: srv.Target,
+			LabelSRVPort:   port,
+		}
+
+		specs := prober.DefaultSpecs()
+		if dp.probePorts {
+			specs = append(specs, prober.Spec{Type: "tcp", Port: int(srv.Port)})
+		}
+
+		for _, a := range addrs {
+			targets = append(targets, inventory.Target{
+				ID:      "dns/" + name + "/" + net.JoinHostPort(a.IP.String(), port),
+				Address: a.IP.String(),
+				Name:    name,
+				Labels:  labels,
+				Probes:  specs,
+			})
+		}
+	}
+
+	return targets, nil
+}
+
+func (dp *DNSProvider) targets() []inventory.Target {
+	names := make([]string, 0, len(dp.lastGood))
+	for name := range dp.lastGood {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var targets []inventory.Target
+	for _, name := range names {
+		targets = append(targets, dp.lastGood[name]...)
+	}
+
+	return targets
+}
@@ -0,0 +1,217 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsServer - DNS-сервер на UDP для тестов: отвечает A/AAAA/SRV из своих таблиц, на остальные имена - NXDOMAIN.
+type dnsServer struct {
+	addr    string
+	queries atomic.Int32
+
+	mu    sync.Mutex
+	hosts map[string][]netip.Addr
+	srv   map[string][]dnsmessage.SRVResource
+}
+
+func newDNSServer(t *testing.T) *dnsServer {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	s := &dnsServer{
+		addr:  conn.LocalAddr().String(),
+		hosts: make(map[string][]netip.Addr),
+		srv:   make(map[string][]dnsmessage.SRVResource),
+	}
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			if resp, err := s.answer(buf[:n]); err == nil {
+				_, _ = conn.WriteTo(resp, from)
+			}
+		}
+	}()
+
+	return s
+}
+
+func (s *dnsServer) setHost(name string, addrs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(addrs) == 0 {
+		delete(s.hosts, name+".")
+		return
+	}
+
+	s.hosts[name+"."] = nil
+	for _, a := range addrs {
+		s.hosts[name+"."] = append(s.hosts[name+"."], netip.MustParseAddr(a))
+	}
+}
+
+func (s *dnsServer) setSRV(name, target string, port uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.srv[name+"."] = append(s.srv[name+"."], dnsmessage.SRVResource{
+		Target: dnsmessage.MustNewName(target + "."),
+		Port:   port,
+	})
+}
+
+func (s *dnsServer) answer(query []byte) ([]byte, error) {
+	var p dnsmessage.Parser
+
+	h, err := p.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	q, err := p.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	s.queries.Add(1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := q.Name.String()
+	addrs, isHost := s.hosts[name]
+	records, isSRV := s.srv[name]
+
+	rcode := dnsmessage.RCodeSuccess
+	if !isHost && !isSRV {
+		rcode = dnsmessage.RCodeNameError
+	}
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 h.ID,
+		Response:           true,
+		Authoritative:      true,
+		RecursionDesired:   h.RecursionDesired,
+		RecursionAvailable: true,
+		RCode:              rcode,
+	})
+	if err = b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err = b.Question(q); err != nil {
+		return nil, err
+	}
+	if err = b.StartAnswers(); err != nil {
+		return nil, err
+	}
+
+	rh := dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: dnsmessage.ClassINET, TTL: 1}
+	switch q.Type {
+	case dnsmessage.TypeA:
+		for _, a := range addrs {
+			if a.Is4() {
+				err = b.AResource(rh, dnsmessage.AResource{A: a.As4()})
+			}
+		}
+	case dnsmessage.TypeAAAA:
+		for _, a := range addrs {
+			if a.Is6() {
+				err = b.AAAAResource(rh, dnsmessage.AAAAResource{AAAA: a.As16()})
+			}
+		}
+	case dnsmessage.TypeSRV:
+		for _, r := range records {
+			err = b.SRVResource(rh, r)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Finish()
+}
+
+func TestDNSProvider(t *testing.T) {
+	server := newDNSServer(t)
+	server.setHost("web.pinger.test", "10.0.0.1", "fd00::1")
+	server.setHost("node1.pinger.test", "10.0.1.1")
+	server.setSRV("_app._tcp.pinger.test", "node1.pinger.test", 8080)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	up := make(chan Update)
+	go NewDNSProvider(
+		[]string{"web.pinger.test", "missing.pinger.test"}, []string{"_app._tcp.pinger.test"},
+		true, 10*time.Millisecond, NewResolver(server.addr),
+	).Run(ctx, up)
+
+	// у missing.pinger.test (NXDOMAIN) целей нет
+	targets := waitTargets(t, up, "dns", map[string]string{
+		"dns/_app._tcp.pinger.test/10.0.1.1:8080": "10.0.1.1",
+		"dns/web.pinger.test/10.0.0.1":            "10.0.0.1",
+		"dns/web.pinger.test/fd00::1":             "fd00::1",
+	})
+
+	for _, target := range targets {
+		if target.ID != "dns/_app._tcp.pinger.test/10.0.1.1:8080" {
+			continue
+		}
+
+		if target.Labels[LabelSRVTarget] != "node1.pinger.test." || target.Labels[LabelSRVPort] != "8080" {
+			t.Errorf("srv labels: %v", target.Labels)
+		}
+		if probes := target.Probes; len(probes) == 0 || probes[len(probes)-1].Type != "tcp" || probes[len(probes)-1].Port != 8080 {
+			t.Errorf("srv probes: %+v", probes)
+		}
+	}
+
+	// на следующем цикле подхватываются новые адреса
+	server.setHost("web.pinger.test", "10.0.0.2")
+
+	want := map[string]string{
+		"dns/_app._tcp.pinger.test/10.0.1.1:8080": "10.0.1.1",
+		"dns/web.pinger.test/10.0.0.2":            "10.0.0.2",
+	}
+	waitTargets(t, up, "dns", want)
+
+	// имя пропало (NXDOMAIN) - остаются адреса прошлого удачного запроса;
+	// за цикл сервер получает не меньше шести запросов, ждём два цикла
+	server.setHost("web.pinger.test")
+	asked := server.queries.Load()
+
+	for server.queries.Load() < asked+12 {
+		select {
+		case <-up:
+		case <-time.After(2 * time.Second):
+			t.Fatal("no dns queries after web.pinger.test was removed")
+		}
+	}
+
+	select {
+	case u := <-up:
+		if !sameTargets(u.Targets, want) {
+			t.Errorf("targets after NXDOMAIN %+v, want %v", u.Targets, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no update after NXDOMAIN")
+	}
+}
@@ -0,0 +1,296 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/k1v4/Pinger/pinger/internal/docker"
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+	"github.com/k1v4/Pinger/pinger/internal/prober"
+)
+
+const (
+	PingNetwork = "ping_network"
+
+	LabelDockerHost      = "docker_host"
+	LabelDockerNetwork   = "docker_network"
+	LabelDockerContainer = "docker_container"
+)
+
+type DockerContainer struct {
+	Id       string
+	Name     string
+	Image    string
+	Status   string
+	Networks []ContainerNetwork
+	Labels   map[string]string
+}
+
+// ContainerNetwork - адрес контейнера в одной из сетей, у сети с IPv6 их два.
+type ContainerNetwork struct {
+	Name string
+	Ip   string
+}
+
+func isHostAvailable(host string) bool {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(host, "tcp://"))
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// DockerHost выбирает адрес Docker-демона: DOCKER_HOST или стандартный для ОС.
+func DockerHost() string {
+	if dockerHost := os.Getenv("DOCKER_HOST"); dockerHost != "" {
+		return dockerHost
+	}
+
+	var dockerHost string
+	switch runtime.GOOS {
+	case "linux":
+		dockerHost = "unix:///var/run/docker.sock"
+	case "darwin", "windows":
+		dockerHost = "tcp://host.docker.internal:2375"
+	default:
+		dockerHost = "tcp://localhost:2375"
+	}
+
+	// Проверяем доступность хоста
+	if strings.HasPrefix(dockerHost, "tcp://") && !isHostAvailable(dockerHost) {
+		log.Println("Хост", dockerHost, "недоступен. Использую fallback: tcp://localhost:2375")
+		return "tcp://localhost:2375"
+	}
+
+	return dockerHost
+}
+
+// TakeDockerContainers возвращает запущенные контейнеры с их адресами в выбранных сетях.
+// Контейнеры без адреса ни в одной из выбранных сетей пропускаются.
+func TakeDockerContainers(ctx context.Context, cli docker.Client, networks NetworkSelector) ([]DockerContainer, error) {
+	containers, err := cli.ContainerList(ctx, container.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении списка контейнеров: %w", err)
+	}
+
+	var dockerContainers []DockerContainer
+	for _, c := range containers {
+		containerDetails, err := cli.ContainerInspect(ctx, c.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			log.Printf("Ошибка при получении деталей контейнера %.10s: %s", c.ID, err)
+			continue
+		}
+
+		var containerNetworks []ContainerNetwork
+		if containerDetails.NetworkSettings != nil {
+			for name, netw := range containerDetails.NetworkSettings.Networks {
+				if netw == nil || !networks.Match(name) {
+					continue
+				}
+
+				for _, ip := range []string{netw.IPAddress, netw.GlobalIPv6Address} {
+					if ip != "" {
+						containerNetworks = append(containerNetworks, ContainerNetwork{Name: name, Ip: ip})
+					}
+				}
+			}
+		}
+
+		if len(containerNetworks) == 0 {
+			continue
+		}
+
+		// порядок сетей в map случаен, а цели должны быть одинаковыми от цикла к циклу
+		sort.SliceStable(containerNetworks, func(i, j int) bool {
+			return containerNetworks[i].Name < containerNetworks[j].Name
+		})
+
+		dockerContainers = append(dockerContainers, DockerContainer{
+			Id:       c.ID,
+			Name:     containerName(c.Names),
+			Image:    c.Image,
+			Status:   c.Status,
+			Networks: containerNetworks,
+			Labels:   c.Labels,
+		})
+	}
+
+	return dockerContainers, nil
+}
+
+// UniteContainers подключает к сети networkName все контейнеры, которых в ней ещё нет.
+func UniteContainers(ctx context.Context, cli docker.Client, networkName string) error {
+	containers, err := cli.ContainerList(ctx, container.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("ошибка при получении списка контейнеров: %w", err)
+	}
+
+	net, err := cli.NetworkInspect(ctx, networkName, network.InspectOptions{})
+	if err != nil {
+		return fmt.Errorf("ошибка сети %s: %w", networkName, err)
+	}
+
+	for _, c := range containers {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		isConnected := false
+
+		for k := range net.Containers {
+			if k == c.ID {
+				isConnected = true
+				break
+			}
+		}
+
+		if !isConnected {
+			err = cli.NetworkConnect(ctx, networkName, c.ID, nil)
+			if err != nil {
+				log.Printf("Ошибка подключения контейнера %s к сети %s: %v", c.ID, networkName, err)
+			} else {
+				log.Printf("Контейнер %s подключен к сети %s\n", c.Image, networkName)
+
+				err = cli.ContainerRestart(ctx, c.ID, container.StopOptions{})
+				if err != nil {
+					log.Printf("Ошибка перезапуска контейнера %s: %v", c.Image, err)
+				} else {
+					log.Printf("Контейнер %s перезапущен\n", c.Image)
+				}
+			}
+		} else {
+			log.Printf("Контейнер %s уже подключен к сети %s\n", c.Image, networkName)
+		}
+	}
+
+	log.Println("Все контейнеры были обработаны")
+
+	return nil
+}
+
+// DockerProvider находит цели среди контейнеров одного Docker-демона host.
+type DockerProvider struct {
+	host     string
+	cli      docker.Client
+	networks NetworkSelector
+	attach   bool
+	interval time.Duration
+}
+
+// NewDockerProvider - при attach и одной выбранной сети без шаблонов провайдер подключает к ней все контейнеры демона.
+// Без attach недоступные пингеру сети проверяются из контейнеров-наблюдателей (prober.Exec).
+func NewDockerProvider(host string, cli docker.Client, networks NetworkSelector, attach bool, interval time.Duration) *DockerProvider {
+	return &DockerProvider{
+		host:     host,
+		cli:      cli,
+		networks: networks,
+		attach:   attach,
+		interval: interval,
+	}
+}
+
+func (dp *DockerProvider) Name() string {
+	return "docker:" + dp.host
+}
+
+// Run опрашивает демон раз в interval. Пока демон недоступен, в инвентаре остаются прежние цели
+// этого демона, цели остальных демонов не затрагиваются.
+func (dp *DockerProvider) Run(ctx context.Context, up chan<- Update) {
+	ticker := time.NewTicker(dp.interval)
+	defer ticker.Stop()
+
+	for {
+		if networkName, ok := dp.networks.Single(); ok && dp.attach {
+			if err := UniteContainers(ctx, dp.cli, networkName); err != nil {
+				log.Printf("Ошибка подключения контейнеров к сети: %s", err)
+			}
+		}
+
+		dockerContainers, err := TakeDockerContainers(ctx, dp.cli, dp.networks)
+		if err != nil {
+			log.Printf("Ошибка обнаружения контейнеров: %s", err)
+		} else {
+			send(ctx, up, dp.Name(), containerTargets(dp.host, dockerContainers))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// containerTargets создаёт по цели на каждый адрес контейнера, чтобы доступность была видна отдельно по сетям.
+func containerTargets(host string, dockerContainers []DockerContainer) []inventory.Target {
+	targets := make([]inventory.Target, 0, len(dockerContainers))
+
+	for _, c := range dockerContainers {
+		labels := withLabel(c.Labels, LabelDockerHost, host)
+		labels[LabelDockerContainer] = c.Id
+
+		for _, netw := range c.Networks {
+			targets = append(targets, inventory.Target{
+				ID:      "docker/" + host + "/" + c.Id + "/" + netw.Name + familySuffix(netw.Ip),
+				Address: netw.Ip,
+				Name:    c.Name,
+				Image:   c.Image,
+				Status:  c.Status,
+				Labels:  withLabel(labels, LabelDockerNetwork, netw.Name),
+				Probes:  specsFor(c.Image, c.Labels, 0),
+			})
+		}
+	}
+
+	return targets
+}
+
+// DaemonTargets - по цели на каждый Docker-демон, доступность демона проверяется пробером "docker".
+func DaemonTargets(hosts []string) []inventory.Target {
+	targets := make([]inventory.Target, 0, len(hosts))
+
+	for _, host := range hosts {
+		targets = append(targets, inventory.Target{
+			ID:      "docker/" + host,
+			Address: host,
+			Labels:  map[string]string{LabelDockerHost: host},
+			Probes:  []prober.Spec{{Type: "docker"}},
+		})
+	}
+
+	return targets
+}
+
+// containerName - имя контейнера без ведущего '/', как в docker ps.
+func containerName(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	return strings.TrimPrefix(names[0], "/")
+}
+
+// withLabel возвращает копию labels с добавленным key.
+func withLabel(labels map[string]string, key, value string) map[string]string {
+	res := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		res[k] = v
+	}
+	res[key] = value
+
+	return res
+}
@@ -0,0 +1,239 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/k1v4/Pinger/pinger/internal/docker/fake"
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+)
+
+func TestNetworkSelector(t *testing.T) {
+	for _, tc := range []struct {
+		selector NetworkSelector
+		match    []string
+		skip     []string
+		single   string
+	}{
+		{selector: NetworkSelector{PingNetwork}, match: []string{PingNetwork}, skip: []string{"bridge", "ping_network_2"}, single: PingNetwork},
+		{selector: NetworkSelector{"frontend_*", "db_net"}, match: []string{"frontend_a", "frontend_", "db_net"}, skip: []string{"backend_a", "db_net_2"}},
+		{selector: NetworkSelector{"front?nd"}, match: []string{"frontend"}, skip: []string{"frontxnd2"}},
+		{selector: NetworkSelector{NetworksAll}, match: []string{"bridge", "anything"}},
+		{selector: nil, skip: []string{PingNetwork}},
+	} {
+		for _, name := range tc.match {
+			if !tc.selector.Match(name) {
+				t.Errorf("%v does not match %q", tc.selector, name)
+			}
+		}
+		for _, name := range tc.skip {
+			if tc.selector.Match(name) {
+				t.Errorf("%v matches %q", tc.selector, name)
+			}
+		}
+
+		single, ok := tc.selector.Single()
+		if single != tc.single || ok != (tc.single != "") {
+			t.Errorf("%v.Single() = %q, %t, want %q", tc.selector, single, ok, tc.single)
+		}
+	}
+}
+
+func TestTakeDockerContainers(t *testing.T) {
+	d := fake.New()
+	d.Add(fake.Container{ID: "aaaa", Name: "web", Image: "nginx", Labels: map[string]string{"tier": "front"},
+		Networks: map[string]string{"frontend_net": "172.20.0.2", "db_net": "172.21.0.2"}})
+	d.Add(fake.Container{ID: "bbbb", Name: "db", Image: "postgres", Networks: map[string]string{"db_net": "fd00::3"}})
+	d.Add(fake.Container{ID: "cccc", Name: "cache", Image: "redis", Networks: map[string]string{"bridge": "172.17.0.4"}})
+	d.Add(fake.Container{ID: "dddd", Name: "job", Image: "busybox", State: "exited", Networks: map[string]string{"db_net": "172.21.0.5"}})
+
+	for _, tc := range []struct {
+		name     string
+		networks NetworkSelector
+		want     []string
+	}{
+		{"single", NetworkSelector{"db_net"}, []string{
+			"docker/local/aaaa/db_net", "docker/local/bbbb/db_net/ipv6",
+		}},
+		{"glob", NetworkSelector{"*_net"}, []string{
+			"docker/local/aaaa/db_net", "docker/local/aaaa/frontend_net", "docker/local/bbbb/db_net/ipv6",
+		}},
+		{"all", NetworkSelector{NetworksAll}, []string{
+			"docker/local/aaaa/db_net", "docker/local/aaaa/frontend_net", "docker/local/bbbb/db_net/ipv6",
+			"docker/local/cccc/bridge",
+		}},
+		{"none", NetworkSelector{"missing"}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			containers, err := TakeDockerContainers(context.Background(), d, tc.networks)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := targetIDs(containerTargets("local", containers)); !slices.Equal(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	containers, err := TakeDockerContainers(context.Background(), d, NetworkSelector{"*_net"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// по цели на сеть, в одном порядке от цикла к циклу
+	web := containerTargets("local", containers)[:2]
+	for i, netw := range []string{"db_net", "frontend_net"} {
+		target := web[i]
+		if target.Name != "web" || target.Image != "nginx" || target.Labels[LabelDockerNetwork] != netw ||
+			target.Labels[LabelDockerHost] != "local" || target.Labels[LabelDockerContainer] != "aaaa" ||
+			target.Labels["tier"] != "front" {
+			t.Errorf("target %d: %+v", i, target)
+		}
+	}
+	if web[0].Address != "172.21.0.2" || web[1].Address != "172.20.0.2" {
+		t.Errorf("addresses: %s, %s", web[0].Address, web[1].Address)
+	}
+}
+
+func TestTakeDockerContainersErrors(t *testing.T) {
+	d := fake.New()
+	d.Add(fake.Container{ID: "aaaa", Name: "web", Networks: map[string]string{PingNetwork: "172.20.0.2"}})
+	d.Add(fake.Container{ID: "bbbb", Name: "db", Networks: map[string]string{PingNetwork: "172.20.0.3"}})
+
+	d.Fail("ContainerList", errors.New("daemon is down"))
+	if _, err := TakeDockerContainers(context.Background(), d, NetworkSelector{PingNetwork}); err == nil {
+		t.Error("ContainerList error is not returned")
+	}
+
+	// контейнер, пропавший между list и inspect, пропускается
+	d.Fail("ContainerInspect", errors.New("no such container"))
+	containers, err := TakeDockerContainers(context.Background(), d, NetworkSelector{PingNetwork})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(containers) != 1 || containers[0].Id != "bbbb" {
+		t.Errorf("got %+v, want only bbbb", containers)
+	}
+}
+
+func TestUniteContainers(t *testing.T) {
+	d := fake.New(PingNetwork)
+	d.Add(fake.Container{ID: "aaaa", Name: "web", Networks: map[string]string{PingNetwork: "172.20.0.2"}})
+	d.Add(fake.Container{ID: "bbbb", Name: "db", Networks: map[string]string{"db_net": "172.21.0.3"}})
+	d.Add(fake.Container{ID: "cccc", Name: "cache", Networks: map[string]string{"bridge": "172.17.0.4"}})
+
+	d.Fail("NetworkConnect", errors.New("forbidden"))
+	d.Calls = nil
+
+	if err := UniteContainers(context.Background(), d, PingNetwork); err != nil {
+		t.Fatal(err)
+	}
+
+	// aaaa уже в сети, bbbb не подключился и не перезапускается, cccc подключён и перезапущен
+	want := []string{
+		"ContainerList", "NetworkInspect " + PingNetwork,
+		"NetworkConnect bbbb", "NetworkConnect cccc", "ContainerRestart cccc",
+	}
+	if !slices.Equal(d.Calls, want) {
+		t.Errorf("calls %v, want %v", d.Calls, want)
+	}
+
+	containers, err := TakeDockerContainers(context.Background(), d, NetworkSelector{PingNetwork})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(containers) != 2 || containers[0].Id != "aaaa" || containers[1].Id != "cccc" {
+		t.Errorf("got %+v, want aaaa and cccc in %s", containers, PingNetwork)
+	}
+
+	if err = UniteContainers(context.Background(), d, "missing"); err == nil {
+		t.Error("missing network is not reported")
+	}
+}
+
+// TestDockerProvider проходит сценарий демона и смотрит, какие цели провайдер отдаёт после каждого шага.
+func TestDockerProvider(t *testing.T) {
+	d := fake.New(PingNetwork)
+	d.Add(fake.Container{ID: "aaaa", Name: "web", Networks: map[string]string{PingNetwork: "172.20.0.2"}})
+
+	scenario := fake.NewScenario(d,
+		fake.Appear(fake.Container{ID: "bbbb", Name: "db", Networks: map[string]string{PingNetwork: "172.20.0.3"}}),
+		fake.ChangeIP("aaaa", PingNetwork, "172.20.0.9"),
+		fake.Steps(
+			fake.Disappear("bbbb", 137),
+			fake.ChangeIP("aaaa", "db_net", "172.21.0.2"),
+		),
+		fake.ChangeIP("aaaa", PingNetwork, ""),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	up := make(chan Update)
+	go NewDockerProvider("local", d, NetworkSelector{PingNetwork}, false, 5*time.Millisecond).Run(ctx, up)
+
+	for i, want := range []map[string]string{
+		{"docker/local/aaaa/ping_network": "172.20.0.2"},
+		{"docker/local/aaaa/ping_network": "172.20.0.2", "docker/local/bbbb/ping_network": "172.20.0.3"},
+		{"docker/local/aaaa/ping_network": "172.20.0.9", "docker/local/bbbb/ping_network": "172.20.0.3"},
+		{"docker/local/aaaa/ping_network": "172.20.0.9"},
+		{},
+	} {
+		if i > 0 && !scenario.Next() {
+			t.Fatal("scenario ended early")
+		}
+
+		waitTargets(t, up, "docker:local", want)
+	}
+}
+
+// waitTargets читает обновления провайдера, пока его цели не станут want (id -> адрес), и возвращает их.
+func waitTargets(t *testing.T, up <-chan Update, provider string, want map[string]string) []inventory.Target {
+	t.Helper()
+
+	timeout := time.After(2 * time.Second)
+
+	var last []inventory.Target
+	for {
+		select {
+		case u := <-up:
+			if u.Provider != provider {
+				t.Fatalf("update from %q, want %q", u.Provider, provider)
+			}
+
+			last = u.Targets
+			if sameTargets(last, want) {
+				return last
+			}
+		case <-timeout:
+			t.Fatalf("targets %+v, want %v", last, want)
+		}
+	}
+}
+
+func sameTargets(targets []inventory.Target, want map[string]string) bool {
+	if len(targets) != len(want) {
+		return false
+	}
+
+	for _, target := range targets {
+		if want[target.ID] != target.Address {
+			return false
+		}
+	}
+
+	return true
+}
+
+func targetIDs(targets []inventory.Target) []string {
+	var ids []string
+	for _, target := range targets {
+		ids = append(ids, target.ID)
+	}
+
+	return ids
+}
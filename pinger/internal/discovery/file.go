@@ -0,0 +1,210 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// FileProvider читает цели из файлов в формате Prometheus file_sd_configs (JSON или YAML)
+// и перечитывает их при изменении файлов, а также раз в refresh на случай пропущенных событий.
+type FileProvider struct {
+	patterns []string
+	refresh  time.Duration
+
+	// last - группы из последнего удачного разбора каждого файла, их отдаёт файл, который сейчас не читается
+	last map[string][]TargetGroup
+}
+
+func NewFileProvider(patterns []string, refresh time.Duration) *FileProvider {
+	return &FileProvider{
+		patterns: patterns,
+		refresh:  refresh,
+		last:     make(map[string][]TargetGroup),
+	}
+}
+
+func (fp *FileProvider) Name() string {
+	return "file"
+}
+
+func (fp *FileProvider) Run(ctx context.Context, up chan<- Update) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("file_sd: не удалось создать watcher, только периодическое чтение: %s", err)
+	} else {
+		defer watcher.Close()
+	}
+
+	ticker := time.NewTicker(fp.refresh)
+	defer ticker.Stop()
+
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if watcher != nil {
+		events, errs = watcher.Events, watcher.Errors
+	}
+
+	for {
+		if watcher != nil {
+			fp.watch(watcher)
+		}
+
+		send(ctx, up, fp.Name(), groupTargets(fp.Name(), fp.read()))
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				break wait
+			case ev := <-events:
+				// в каталоге бывают и чужие файлы, перечитываем только на события по шаблонам
+				if fp.matches(ev.Name) {
+					break wait
+				}
+			case err := <-errs:
+				// после ошибки (например, переполнения очереди) события могли потеряться
+				log.Printf("file_sd: ошибка watcher: %s", err)
+				break wait
+			}
+		}
+	}
+}
+
+// read читает все файлы по шаблонам. Файл, который не удалось разобрать (например, недописанный при сохранении),
+// отдаёт цели из последнего удачного разбора, как в Prometheus file_sd. Цели удалённых файлов пропадают.
+func (fp *FileProvider) read() []TargetGroup {
+	var groups []TargetGroup
+
+	last := make(map[string][]TargetGroup, len(fp.last))
+	for _, path := range fp.files() {
+		g, err := readTargetGroups(path)
+		if err != nil {
+			log.Printf("file_sd: %s, остаются прежние цели файла", err)
+			g = fp.last[path]
+		}
+
+		last[path] = g
+		groups = append(groups, g...)
+	}
+	fp.last = last
+
+	return groups
+}
+
+func (fp *FileProvider) files() []string {
+	var files []string
+
+	for _, pattern := range fp.patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			log.Printf("file_sd: неверный шаблон %s: %s", pattern, err)
+			continue
+		}
+
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	return files
+}
+
+// watch добавляет в watcher каталоги шаблонов, которых ещё нет среди отслеживаемых: появившиеся после запуска
+// или созданные заново после удаления. Следим за каталогами: редакторы и ConfigMap заменяют файл, а не пишут в него.
+func (fp *FileProvider) watch(watcher *fsnotify.Watcher) {
+	watched := watcher.WatchList()
+
+	for _, dir := range fp.dirs() {
+		if slices.Contains(watched, dir) {
+			continue
+		}
+
+		if err := watcher.Add(dir); err != nil {
+			log.Printf("file_sd: не удалось следить за %s: %s", dir, err)
+		}
+	}
+}
+
+// dirs - существующие каталоги шаблонов. Шаблон в пути каталога (/etc/pinger/*/targets.yml) раскрывается
+// в подходящие каталоги, новые подхватываются при следующем чтении.
+func (fp *FileProvider) dirs() []string {
+	seen := make(map[string]struct{})
+
+	var dirs []string
+	for _, pattern := range fp.patterns {
+		dir := filepath.Dir(pattern)
+
+		matches := []string{dir}
+		if hasMeta(dir) {
+			var err error
+			if matches, err = filepath.Glob(dir); err != nil {
+				continue
+			}
+		}
+
+		for _, m := range matches {
+			if _, ok := seen[m]; ok {
+				continue
+			}
+			// каталога может ещё не быть, за ним начнём следить, когда он появится
+			if info, err := os.Stat(m); err != nil || !info.IsDir() {
+				continue
+			}
+
+			seen[m] = struct{}{}
+			dirs = append(dirs, m)
+		}
+	}
+
+	return dirs
+}
+
+// hasMeta сообщает, есть ли в пути символы шаблона filepath.Match.
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[\`)
+}
+
+func (fp *FileProvider) matches(name string) bool {
+	for _, pattern := range fp.patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readTargetGroups(path string) ([]TargetGroup, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("чтение %s: %w", path, err)
+	}
+
+	var groups []TargetGroup
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &groups)
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(data, &groups)
+	default:
+		return nil, fmt.Errorf("%s: неизвестный формат, ожидается .json, .yml или .yaml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("разбор %s: %w", path, err)
+	}
+
+	return groups, nil
+}
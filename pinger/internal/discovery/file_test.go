@@ -0,0 +1,107 @@
+package discovery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, data string) {
+		t.Helper()
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("vms.json", `[{"targets": ["10.0.1.1", "10.0.1.2:22"], "labels": {"env": "prod"}}]`)
+	write("db.yml", "- targets: ['[fd00::5]:5432']\n  labels:\n    pinger_probes: icmp\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	up := make(chan Update)
+	go NewFileProvider([]string{filepath.Join(dir, "*.json"), filepath.Join(dir, "*.yml")}, time.Hour).Run(ctx, up)
+
+	waitTargets(t, up, "file", map[string]string{
+		"file/10.0.1.1":       "10.0.1.1",
+		"file/10.0.1.2:22":    "10.0.1.2",
+		"file/[fd00::5]:5432": "fd00::5",
+	})
+
+	// чужой файл в том же каталоге не перечитывает цели
+	write("notes.txt", "not a target file")
+
+	select {
+	case u := <-up:
+		t.Fatalf("update after a non-matching file: %+v", u.Targets)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// недописанный файл отдаёт цели последнего удачного разбора, изменения остальных файлов применяются
+	write("db.yml", "targets: [")
+	write("vms.json", `[{"targets": ["10.0.1.1"]}]`)
+
+	waitTargets(t, up, "file", map[string]string{
+		"file/10.0.1.1":       "10.0.1.1",
+		"file/[fd00::5]:5432": "fd00::5",
+	})
+
+	write("db.yml", "- targets: ['10.0.2.1']\n")
+
+	waitTargets(t, up, "file", map[string]string{
+		"file/10.0.1.1": "10.0.1.1",
+		"file/10.0.2.1": "10.0.2.1",
+	})
+
+	// цели удалённого файла пропадают
+	if err := os.Remove(filepath.Join(dir, "db.yml")); err != nil {
+		t.Fatal(err)
+	}
+
+	waitTargets(t, up, "file", map[string]string{
+		"file/10.0.1.1": "10.0.1.1",
+	})
+}
+
+// TestFileProviderDirGlob - шаблон в пути каталога раскрывается, и изменения файлов в нём видны без ожидания refresh.
+func TestFileProviderDirGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"prod", "stage"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write := func(name, data string) {
+		t.Helper()
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("prod/targets.yml", "- targets: ['10.0.1.1']\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	up := make(chan Update)
+	go NewFileProvider([]string{filepath.Join(dir, "*", "targets.yml")}, time.Hour).Run(ctx, up)
+
+	waitTargets(t, up, "file", map[string]string{
+		"file/10.0.1.1": "10.0.1.1",
+	})
+
+	write("stage/targets.yml", "- targets: ['10.0.2.1']\n")
+
+	waitTargets(t, up, "file", map[string]string{
+		"file/10.0.1.1": "10.0.1.1",
+		"file/10.0.2.1": "10.0.2.1",
+	})
+}
@@ -0,0 +1,110 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+)
+
+const _httpSDMaxBody = 10 << 20
+
+// HTTPProvider опрашивает URL, отдающий цели в формате Prometheus http_sd.
+// Ответ 304 на If-None-Match и ошибки запроса оставляют последний удачный набор целей.
+type HTTPProvider struct {
+	url      string
+	refresh  time.Duration
+	headers  map[string]string
+	client   *http.Client
+	etag     string
+	lastGood []inventory.Target
+}
+
+func NewHTTPProvider(url string, refresh time.Duration, headers map[string]string) *HTTPProvider {
+	return &HTTPProvider{
+		url:     url,
+		refresh: refresh,
+		headers: headers,
+		client:  &http.Client{Timeout: refresh},
+	}
+}
+
+func (hp *HTTPProvider) Name() string {
+	return "http:" + hp.url
+}
+
+func (hp *HTTPProvider) Run(ctx context.Context, up chan<- Update) {
+	ticker := time.NewTicker(hp.refresh)
+	defer ticker.Stop()
+
+	for {
+		targets, changed, err := hp.fetch(ctx)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("http_sd %s: %s, используются последние полученные цели (%d)", hp.url, err, len(hp.lastGood))
+		case changed:
+			hp.lastGood = targets
+			send(ctx, up, hp.Name(), targets)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetch возвращает changed=false, если сервер ответил 304 Not Modified.
+func (hp *HTTPProvider) fetch(ctx context.Context) ([]inventory.Target, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, hp.url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Prometheus-Refresh-Interval-Seconds", fmt.Sprint(int(hp.refresh.Seconds())))
+	for k, v := range hp.headers {
+		req.Header.Set(k, v)
+	}
+	if hp.etag != "" {
+		req.Header.Set("If-None-Match", hp.etag)
+	}
+
+	resp, err := hp.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, false, nil
+	case http.StatusOK:
+	default:
+		return nil, false, fmt.Errorf("сервер ответил %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, _httpSDMaxBody))
+	if err != nil {
+		return nil, false, err
+	}
+
+	var groups []TargetGroup
+	if err = json.Unmarshal(body, &groups); err != nil {
+		return nil, false, fmt.Errorf("разбор ответа: %w", err)
+	}
+
+	hp.etag = resp.Header.Get("ETag")
+
+	// в id входит URL: одинаковые адреса с разных http_sd - разные цели со своими метками
+	return groupTargets(hp.Name(), groups), true, nil
+}
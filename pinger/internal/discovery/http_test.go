@@ -0,0 +1,76 @@
+package discovery
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+)
+
+// TestHTTPProviders - два http_sd отдают один и тот же адрес, в инвентаре должны остаться обе цели.
+func TestHTTPProviders(t *testing.T) {
+	var notModified atomic.Int32
+
+	sd := func(body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(body))
+		}))
+	}
+
+	prod := sd(`[{"targets": ["10.0.0.5"], "labels": {"env": "prod"}}]`)
+	defer prod.Close()
+	stage := sd(`[{"targets": ["10.0.0.5", "10.0.0.6"], "labels": {"env": "stage"}}]`)
+	defer stage.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inv := inventory.New()
+	go NewManager(inv,
+		NewHTTPProvider(prod.URL, 10*time.Millisecond, nil),
+		NewHTTPProvider(stage.URL, 10*time.Millisecond, nil),
+	).Run(ctx)
+
+	want := map[string]string{
+		"http:" + prod.URL + "/10.0.0.5":  "prod",
+		"http:" + stage.URL + "/10.0.0.5": "stage",
+		"http:" + stage.URL + "/10.0.0.6": "stage",
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		targets := inv.Targets()
+
+		got := make(map[string]string, len(targets))
+		for _, target := range targets {
+			got[target.ID] = target.Labels["env"]
+		}
+
+		if maps.Equal(got, want) && notModified.Load() > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("targets %v, want %v (304 answers: %d)", got, want, notModified.Load())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 304 оставляет прежний набор целей
+	time.Sleep(50 * time.Millisecond)
+	if n := len(inv.Targets()); n != len(want) {
+		t.Errorf("got %d targets after 304 answers, want %d", n, len(want))
+	}
+}
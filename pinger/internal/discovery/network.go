@@ -0,0 +1,37 @@
+package discovery
+
+import (
+	"path"
+	"strings"
+)
+
+// NetworksAll выбирает все сети контейнера.
+const NetworksAll = "all"
+
+// NetworkSelector - сети контейнеров, адреса в которых нужно проверять.
+// Элемент - имя сети, glob-шаблон (frontend_*) или NetworksAll.
+type NetworkSelector []string
+
+// Match сообщает, выбрана ли сеть name.
+func (ns NetworkSelector) Match(name string) bool {
+	for _, pattern := range ns {
+		if pattern == NetworksAll {
+			return true
+		}
+
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Single возвращает имя сети, если выбрана ровно одна сеть без шаблонов.
+func (ns NetworkSelector) Single() (string, bool) {
+	if len(ns) != 1 || ns[0] == NetworksAll || strings.ContainsAny(ns[0], `*?[\`) {
+		return "", false
+	}
+
+	return ns[0], true
+}
@@ -0,0 +1,37 @@
+package discovery
+
+import (
+	"context"
+
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+)
+
+// StaticProvider отдаёт неизменный список целей.
+type StaticProvider struct {
+	name    string
+	targets []inventory.Target
+}
+
+// NewStaticProvider - цели из static_configs конфига.
+func NewStaticProvider(groups []TargetGroup) *StaticProvider {
+	return &StaticProvider{
+		name:    "static",
+		targets: groupTargets("static", groups),
+	}
+}
+
+// NewDaemonProvider - цели для проверки доступности самих Docker-демонов.
+func NewDaemonProvider(hosts []string) *StaticProvider {
+	return &StaticProvider{
+		name:    "docker-daemons",
+		targets: DaemonTargets(hosts),
+	}
+}
+
+func (sp *StaticProvider) Name() string {
+	return sp.name
+}
+
+func (sp *StaticProvider) Run(ctx context.Context, up chan<- Update) {
+	send(ctx, up, sp.Name(), sp.targets)
+}
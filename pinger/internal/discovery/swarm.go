@@ -0,0 +1,175 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/k1v4/Pinger/pinger/internal/docker"
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+)
+
+const (
+	LabelSwarmService  = "swarm_service"
+	LabelSwarmTaskSlot = "swarm_task_slot"
+	LabelSwarmNode     = "swarm_node"
+	LabelSwarmNetwork  = "swarm_network"
+)
+
+// SwarmProvider находит цели среди задач сервисов Swarm через API менеджера,
+// поэтому один пингер на менеджере видит задачи на всех нодах.
+// Каждая запущенная задача даёт по цели на адрес в overlay-сетях, кроме ingress.
+type SwarmProvider struct {
+	host     string
+	cli      docker.Client
+	interval time.Duration
+}
+
+func NewSwarmProvider(host string, cli docker.Client, interval time.Duration) *SwarmProvider {
+	return &SwarmProvider{
+		host:     host,
+		cli:      cli,
+		interval: interval,
+	}
+}
+
+func (sp *SwarmProvider) Name() string {
+	return "swarm:" + sp.host
+}
+
+func (sp *SwarmProvider) Run(ctx context.Context, up chan<- Update) {
+	ticker := time.NewTicker(sp.interval)
+	defer ticker.Stop()
+
+	for {
+		targets, err := sp.discover(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Ошибка обнаружения задач Swarm: %s", err)
+		} else {
+			send(ctx, up, sp.Name(), targets)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (sp *SwarmProvider) discover(ctx context.Context) ([]inventory.Target, error) {
+	services, err := sp.cli.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("список сервисов: %w", err)
+	}
+
+	nodes, err := sp.cli.NodeList(ctx, types.NodeListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("список нод: %w", err)
+	}
+
+	tasks, err := sp.cli.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(filters.Arg("desired-state", string(swarm.TaskStateRunning))),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("список задач: %w", err)
+	}
+
+	// статистику снимает клиент этого демона, поэтому контейнер указывается только у задач, запущенных на нём
+	containers, err := sp.cli.ContainerList(ctx, container.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("список контейнеров: %w", err)
+	}
+
+	local := make(map[string]bool, len(containers))
+	for _, c := range containers {
+		local[c.ID] = true
+	}
+
+	serviceByID := make(map[string]swarm.Service, len(services))
+	for _, s := range services {
+		serviceByID[s.ID] = s
+	}
+
+	nodeNames := make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		nodeNames[n.ID] = n.Description.Hostname
+	}
+
+	var targets []inventory.Target
+	for _, t := range tasks {
+		if t.Status.State != swarm.TaskStateRunning {
+			continue
+		}
+
+		service := serviceByID[t.ServiceID]
+
+		var image string
+		if t.Spec.ContainerSpec != nil {
+			image = t.Spec.ContainerSpec.Image
+		}
+
+		var containerID string
+		if t.Status.ContainerStatus != nil && local[t.Status.ContainerStatus.ContainerID] {
+			containerID = t.Status.ContainerStatus.ContainerID
+		}
+
+		for _, att := range t.NetworksAttachments {
+			if att.Network.Spec.Ingress {
+				continue
+			}
+
+			for _, cidr := range att.Addresses {
+				ip, _, err := net.ParseCIDR(cidr)
+				if err != nil {
+					continue
+				}
+
+				// служебные метки пишутся последними, метки сервиса их не перекрывают
+				labels := make(map[string]string, len(service.Spec.Labels)+6)
+				for k, v := range service.Spec.Labels {
+					labels[k] = v
+				}
+				labels[LabelDockerHost] = sp.host
+				labels[LabelSwarmService] = service.Spec.Name
+				labels[LabelSwarmTaskSlot] = strconv.Itoa(t.Slot)
+				labels[LabelSwarmNode] = nodeNames[t.NodeID]
+				labels[LabelSwarmNetwork] = att.Network.Spec.Name
+				if containerID != "" {
+					labels[LabelDockerContainer] = containerID
+				}
+
+				targets = append(targets, inventory.Target{
+					ID:      "swarm/" + t.ID + "/" + att.Network.Spec.Name + familySuffix(ip.String()),
+					Address: ip.String(),
+					Name:    taskName(service.Spec.Name, t.Slot),
+					Image:   image,
+					Status:  string(t.Status.State),
+					Labels:  labels,
+					Probes:  specsFor(service.Spec.Name, service.Spec.Labels, 0),
+				})
+			}
+		}
+	}
+
+	return targets, nil
+}
+
+// taskName - имя задачи как у её контейнера: service.slot, у global-сервиса слота нет.
+func taskName(service string, slot int) string {
+	if slot == 0 {
+		return service
+	}
+
+	return service + "." + strconv.Itoa(slot)
+}
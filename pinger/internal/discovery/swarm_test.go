@@ -0,0 +1,112 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/k1v4/Pinger/pinger/internal/docker/fake"
+)
+
+func swarmTask(id, service string, slot int, node string, state swarm.TaskState, networks ...swarm.NetworkAttachment) swarm.Task {
+	return swarm.Task{
+		ID:           id,
+		ServiceID:    service,
+		Slot:         slot,
+		NodeID:       node,
+		DesiredState: swarm.TaskStateRunning,
+		Status: swarm.TaskStatus{
+			State:           state,
+			ContainerStatus: &swarm.ContainerStatus{ContainerID: "container-" + id},
+		},
+		Spec:                swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "nginx:1.27"}},
+		NetworksAttachments: networks,
+	}
+}
+
+func overlay(name string, ingress bool, addrs ...string) swarm.NetworkAttachment {
+	return swarm.NetworkAttachment{
+		Network:   swarm.Network{Spec: swarm.NetworkSpec{Annotations: swarm.Annotations{Name: name}, Ingress: ingress}},
+		Addresses: addrs,
+	}
+}
+
+func TestSwarmProvider(t *testing.T) {
+	// контейнер task1 запущен на этом демоне, task3 - на другой ноде
+	d := fake.New()
+	d.Add(fake.Container{ID: "container-task1", Name: "web.1.task1"})
+
+	web := swarm.Service{ID: "svc-web", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{
+		Name: "web",
+		// метки сервиса с именами служебных меток не должны их перекрыть
+		Labels: map[string]string{"team": "front", LabelSwarmNode: "spoofed", LabelSwarmService: "spoofed", LabelProbes: "tcp:80"},
+	}}}
+	agent := swarm.Service{ID: "svc-agent", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "agent"}}}
+
+	d.SetSwarm(
+		[]swarm.Service{web, agent},
+		[]swarm.Task{
+			swarmTask("task1", "svc-web", 1, "node-a", swarm.TaskStateRunning,
+				overlay("ingress", true, "10.0.0.5/24"), overlay("app_net", false, "10.0.1.5/24", "fd00::5/64")),
+			swarmTask("task2", "svc-web", 2, "node-b", swarm.TaskStateStarting, overlay("app_net", false, "10.0.1.6/24")),
+			swarmTask("task3", "svc-agent", 0, "node-b", swarm.TaskStateRunning, overlay("app_net", false, "bad-cidr", "10.0.1.7/24")),
+		},
+		[]swarm.Node{
+			{ID: "node-a", Description: swarm.NodeDescription{Hostname: "manager-1"}},
+			{ID: "node-b", Description: swarm.NodeDescription{Hostname: "worker-1"}},
+		},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	up := make(chan Update)
+	go NewSwarmProvider("manager", d, 5*time.Millisecond).Run(ctx, up)
+
+	// ingress, незапущенная задача и неверный адрес целей не дают
+	targets := waitTargets(t, up, "swarm:manager", map[string]string{
+		"swarm/task1/app_net":      "10.0.1.5",
+		"swarm/task1/app_net/ipv6": "fd00::5",
+		"swarm/task3/app_net":      "10.0.1.7",
+	})
+
+	for _, target := range targets {
+		switch target.ID {
+		case "swarm/task1/app_net":
+			if target.Name != "web.1" || target.Image != "nginx:1.27" || target.Status != "running" {
+				t.Errorf("task1: %+v", target)
+			}
+			if target.Labels[LabelSwarmService] != "web" || target.Labels[LabelSwarmNode] != "manager-1" ||
+				target.Labels[LabelSwarmTaskSlot] != "1" || target.Labels[LabelSwarmNetwork] != "app_net" ||
+				target.Labels[LabelDockerHost] != "manager" || target.Labels["team"] != "front" ||
+				target.Labels[LabelDockerContainer] != "container-task1" {
+				t.Errorf("task1 labels: %v", target.Labels)
+			}
+			if len(target.Probes) != 1 || target.Probes[0].Type != "tcp" || target.Probes[0].Port != 80 {
+				t.Errorf("task1 probes: %+v", target.Probes)
+			}
+		case "swarm/task3/app_net":
+			// у global-сервиса нет слота
+			if target.Name != "agent" || target.Labels[LabelSwarmNode] != "worker-1" || target.Labels[LabelSwarmTaskSlot] != "0" ||
+				target.Labels[LabelDockerContainer] != "" {
+				t.Errorf("task3: %+v", target)
+			}
+		}
+	}
+
+	// задача web.2 запустилась, а агент остановлен
+	d.SetSwarm(
+		[]swarm.Service{web, agent},
+		[]swarm.Task{
+			swarmTask("task1", "svc-web", 1, "node-a", swarm.TaskStateRunning, overlay("app_net", false, "10.0.1.5/24")),
+			swarmTask("task2", "svc-web", 2, "node-b", swarm.TaskStateRunning, overlay("app_net", false, "10.0.1.6/24")),
+		},
+		nil,
+	)
+
+	waitTargets(t, up, "swarm:manager", map[string]string{
+		"swarm/task1/app_net": "10.0.1.5",
+		"swarm/task2/app_net": "10.0.1.6",
+	})
+}
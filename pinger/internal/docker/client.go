@@ -0,0 +1,77 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+)
+
+// Client - вызовы Docker API, которые использует пингер.
+// Реализуется *client.Client и fake.Daemon для тестов.
+type Client interface {
+	Ping(ctx context.Context) (types.Ping, error)
+	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
+	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ContainerRestart(ctx context.Context, containerID string, options container.StopOptions) error
+	ContainerExecCreate(ctx context.Context, containerID string, options container.ExecOptions) (types.IDResponse, error)
+	ContainerExecAttach(ctx context.Context, execID string, config container.ExecAttachOptions) (types.HijackedResponse, error)
+	ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error)
+	ContainerStatsOneShot(ctx context.Context, containerID string) (container.StatsResponseReader, error)
+	Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error)
+	NetworkInspect(ctx context.Context, networkID string, options network.InspectOptions) (network.Inspect, error)
+	NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error
+	ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error)
+	TaskList(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error)
+	NodeList(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error)
+	Close() error
+}
+
+var _ Client = (*client.Client)(nil)
+
+// Endpoint - адрес Docker-демона: unix://, tcp:// (с TLS, если заданы сертификаты) или ssh://.
+type Endpoint struct {
+	Name    string `yaml:"name"`
+	Host    string `yaml:"host"`
+	TLSCA   string `yaml:"tls_ca"`
+	TLSCert string `yaml:"tls_cert"`
+	TLSKey  string `yaml:"tls_key"`
+}
+
+// New создаёт клиент настоящего Docker-демона.
+func New(e Endpoint) (Client, error) {
+	opts := []client.Opt{client.WithAPIVersionNegotiation()}
+
+	u, err := url.Parse(e.Host)
+	if err != nil {
+		return nil, fmt.Errorf("docker - New - %s: %w", e.Host, err)
+	}
+
+	switch u.Scheme {
+	case "ssh":
+		// как docker CLI: туннель через "docker system dial-stdio" на удалённой машине
+		opts = append(opts,
+			client.WithHost("http://docker.example.com"),
+			client.WithDialContext(sshDialer(u)),
+		)
+	default:
+		opts = append(opts, client.WithHost(e.Host))
+	}
+
+	if e.TLSCert != "" || e.TLSCA != "" {
+		opts = append(opts, client.WithTLSClientConfig(e.TLSCA, e.TLSCert, e.TLSKey))
+	}
+
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("docker - New - %s: %w", e.Host, err)
+	}
+
+	return cli, nil
+}
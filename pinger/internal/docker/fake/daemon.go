@@ -0,0 +1,441 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/errdefs"
+	"github.com/k1v4/Pinger/pinger/internal/docker"
+)
+
+// Container - контейнер фейкового демона. Networks - имя сети -> IP.
+type Container struct {
+	ID           string
+	Name         string
+	Image        string
+	State        string
+	Labels       map[string]string
+	Networks     map[string]string
+	RestartCount int
+	ExitCode     int
+	OOMKilled    bool
+	// Stats - ответ ContainerStatsOneShot, поле Read заполняет демон.
+	Stats container.StatsResponse
+}
+
+// Daemon - Docker-демон в памяти для тестов обнаружения.
+// Состояние меняется методами Add, Remove, SetIP и Emit или сценарием Scenario,
+// изменения публикуются подписчикам Events так же, как это делает настоящий демон.
+type Daemon struct {
+	mu         sync.Mutex
+	containers map[string]*Container
+	networks   map[string]struct{}
+	subs       map[chan events.Message]chan error
+	history    []events.Message
+	failures   map[string]error
+	nextIP     int
+	execs      map[string]*execState
+	exec       ExecFunc
+
+	services []swarm.Service
+	tasks    []swarm.Task
+	nodes    []swarm.Node
+
+	// Calls - журнал вызовов API в формате "Method id".
+	Calls []string
+}
+
+var _ docker.Client = (*Daemon)(nil)
+
+func New(networks ...string) *Daemon {
+	d := &Daemon{
+		containers: make(map[string]*Container),
+		networks:   make(map[string]struct{}),
+		subs:       make(map[chan events.Message]chan error),
+		failures:   make(map[string]error),
+		execs:      make(map[string]*execState),
+		nextIP:     2,
+	}
+
+	for _, n := range networks {
+		d.networks[n] = struct{}{}
+	}
+
+	return d
+}
+
+// Fail заставляет следующий вызов method вернуть err.
+func (d *Daemon) Fail(method string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.failures[method] = err
+}
+
+// Add добавляет запущенный контейнер и публикует событие start.
+func (d *Daemon) Add(c Container) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if c.State == "" {
+		c.State = "running"
+	}
+	if c.Networks == nil {
+		c.Networks = make(map[string]string)
+	}
+	for n := range c.Networks {
+		d.networks[n] = struct{}{}
+	}
+
+	d.containers[c.ID] = &c
+	d.emit(events.ContainerEventType, events.ActionStart, c.ID, c.attributes())
+}
+
+// Remove останавливает и удаляет контейнер, публикуя события die и destroy.
+func (d *Daemon) Remove(id string, exitCode int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	c, ok := d.containers[id]
+	if !ok {
+		return
+	}
+	delete(d.containers, id)
+
+	attrs := c.attributes()
+	attrs["exitCode"] = fmt.Sprint(exitCode)
+	d.emit(events.ContainerEventType, events.ActionDie, id, attrs)
+	d.emit(events.ContainerEventType, events.ActionDestroy, id, c.attributes())
+}
+
+// SetIP меняет или назначает IP контейнера в сети, пустой ip отключает контейнер от сети.
+func (d *Daemon) SetIP(id, networkName, ip string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	c, ok := d.containers[id]
+	if !ok {
+		return
+	}
+	d.networks[networkName] = struct{}{}
+
+	attrs := map[string]string{"container": id, "name": networkName}
+	if ip == "" {
+		delete(c.Networks, networkName)
+		d.emit(events.NetworkEventType, events.ActionDisconnect, networkName, attrs)
+
+		return
+	}
+
+	c.Networks[networkName] = ip
+	d.emit(events.NetworkEventType, events.ActionConnect, networkName, attrs)
+}
+
+// Emit публикует произвольное событие.
+func (d *Daemon) Emit(msg events.Message) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.publish(msg)
+}
+
+// Disconnect обрывает все подписки Events ошибкой err, как при перезапуске демона.
+func (d *Daemon) Disconnect(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for msgs, errs := range d.subs {
+		delete(d.subs, msgs)
+		errs <- err
+	}
+}
+
+// Subscribers - число открытых подписок Events.
+func (d *Daemon) Subscribers() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.subs)
+}
+
+// Update меняет контейнер под блокировкой, например для RestartCount или OOMKilled.
+func (d *Daemon) Update(id string, f func(c *Container)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if c, ok := d.containers[id]; ok {
+		f(c)
+	}
+}
+
+func (d *Daemon) Ping(context.Context) (types.Ping, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.failure("Ping", ""); err != nil {
+		return types.Ping{}, err
+	}
+
+	return types.Ping{APIVersion: "1.47", OSType: "linux"}, nil
+}
+
+func (d *Daemon) ContainerList(_ context.Context, options container.ListOptions) ([]types.Container, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.failure("ContainerList", ""); err != nil {
+		return nil, err
+	}
+
+	list := make([]types.Container, 0, len(d.containers))
+	for _, c := range d.sorted() {
+		if !options.All && c.State != "running" {
+			continue
+		}
+
+		networks := make(map[string]*network.EndpointSettings, len(c.Networks))
+		for n, ip := range c.Networks {
+			networks[n] = endpoint(n, ip)
+		}
+
+		list = append(list, types.Container{
+			ID:              c.ID,
+			Names:           []string{"/" + c.Name},
+			Image:           c.Image,
+			Labels:          c.Labels,
+			State:           c.State,
+			Status:          c.State,
+			NetworkSettings: &types.SummaryNetworkSettings{Networks: networks},
+		})
+	}
+
+	return list, nil
+}
+
+// endpoint раскладывает адрес по полям, как Docker: IPv6 сети - в GlobalIPv6Address, IPv4 - в IPAddress.
+func endpoint(networkName, ip string) *network.EndpointSettings {
+	if strings.Contains(ip, ":") {
+		return &network.EndpointSettings{NetworkID: networkName, GlobalIPv6Address: ip}
+	}
+
+	return &network.EndpointSettings{NetworkID: networkName, IPAddress: ip}
+}
+
+func (d *Daemon) ContainerInspect(_ context.Context, containerID string) (types.ContainerJSON, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.failure("ContainerInspect", containerID); err != nil {
+		return types.ContainerJSON{}, err
+	}
+
+	c, ok := d.containers[containerID]
+	if !ok {
+		return types.ContainerJSON{}, errdefs.NotFound(fmt.Errorf("no such container: %s", containerID))
+	}
+
+	networks := make(map[string]*network.EndpointSettings, len(c.Networks))
+	for n, ip := range c.Networks {
+		networks[n] = endpoint(n, ip)
+	}
+
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:           c.ID,
+			Name:         "/" + c.Name,
+			Image:        c.Image,
+			RestartCount: c.RestartCount,
+			State: &types.ContainerState{
+				Status:    c.State,
+				Running:   c.State == "running",
+				OOMKilled: c.OOMKilled,
+				ExitCode:  c.ExitCode,
+			},
+		},
+		Config: &container.Config{
+			Image:  c.Image,
+			Labels: c.Labels,
+		},
+		NetworkSettings: &types.NetworkSettings{Networks: networks},
+	}, nil
+}
+
+func (d *Daemon) ContainerRestart(_ context.Context, containerID string, _ container.StopOptions) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.failure("ContainerRestart", containerID); err != nil {
+		return err
+	}
+
+	c, ok := d.containers[containerID]
+	if !ok {
+		return errdefs.NotFound(fmt.Errorf("no such container: %s", containerID))
+	}
+
+	c.RestartCount++
+	d.emit(events.ContainerEventType, events.ActionRestart, c.ID, c.attributes())
+
+	return nil
+}
+
+// Events, как демон, с Since (в секундах) сначала повторяет прошлые события начиная с этой секунды.
+func (d *Daemon) Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error) {
+	msgs := make(chan events.Message, 64)
+	errs := make(chan error, 1)
+
+	d.mu.Lock()
+	if err := d.failure("Events", ""); err != nil {
+		d.mu.Unlock()
+		errs <- err
+
+		return msgs, errs
+	}
+
+	if since, err := strconv.ParseInt(options.Since, 10, 64); err == nil {
+		for _, msg := range d.history {
+			if msg.Time >= since && len(msgs) < cap(msgs) {
+				msgs <- msg
+			}
+		}
+	}
+
+	d.subs[msgs] = errs
+	d.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		d.mu.Lock()
+		delete(d.subs, msgs)
+		d.mu.Unlock()
+
+		// после Disconnect ошибка уже в буфере
+		select {
+		case errs <- ctx.Err():
+		default:
+		}
+	}()
+
+	return msgs, errs
+}
+
+func (d *Daemon) NetworkInspect(_ context.Context, networkID string, _ network.InspectOptions) (network.Inspect, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.failure("NetworkInspect", networkID); err != nil {
+		return network.Inspect{}, err
+	}
+
+	if _, ok := d.networks[networkID]; !ok {
+		return network.Inspect{}, errdefs.NotFound(fmt.Errorf("network %s not found", networkID))
+	}
+
+	endpoints := make(map[string]network.EndpointResource)
+	for _, c := range d.containers {
+		if ip, ok := c.Networks[networkID]; ok {
+			endpoints[c.ID] = network.EndpointResource{Name: c.Name, IPv4Address: ip + "/16"}
+		}
+	}
+
+	return network.Inspect{Name: networkID, ID: networkID, Containers: endpoints}, nil
+}
+
+func (d *Daemon) NetworkConnect(_ context.Context, networkID, containerID string, _ *network.EndpointSettings) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.failure("NetworkConnect", containerID); err != nil {
+		return err
+	}
+
+	if _, ok := d.networks[networkID]; !ok {
+		return errdefs.NotFound(fmt.Errorf("network %s not found", networkID))
+	}
+
+	c, ok := d.containers[containerID]
+	if !ok {
+		return errdefs.NotFound(fmt.Errorf("no such container: %s", containerID))
+	}
+
+	if _, ok := c.Networks[networkID]; ok {
+		return errdefs.Forbidden(fmt.Errorf("container %s is already attached to %s", containerID, networkID))
+	}
+
+	c.Networks[networkID] = fmt.Sprintf("172.30.%d.%d", d.nextIP/254, d.nextIP%254+1)
+	d.nextIP++
+	d.emit(events.NetworkEventType, events.ActionConnect, networkID, map[string]string{"container": containerID, "name": networkID})
+
+	return nil
+}
+
+func (d *Daemon) Close() error {
+	return nil
+}
+
+// failure записывает вызов и возвращает запланированную ошибку. Вызывается под d.mu.
+func (d *Daemon) failure(method, id string) error {
+	d.Calls = append(d.Calls, strings.TrimSpace(method+" "+id))
+
+	err, ok := d.failures[method]
+	if !ok {
+		return nil
+	}
+	delete(d.failures, method)
+
+	return err
+}
+
+func (d *Daemon) sorted() []*Container {
+	list := make([]*Container, 0, len(d.containers))
+	for _, c := range d.containers {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	return list
+}
+
+func (d *Daemon) emit(typ events.Type, action events.Action, id string, attrs map[string]string) {
+	now := time.Now()
+
+	d.publish(events.Message{
+		Type:     typ,
+		Action:   action,
+		Actor:    events.Actor{ID: id, Attributes: attrs},
+		Time:     now.Unix(),
+		TimeNano: now.UnixNano(),
+	})
+}
+
+// publish раздаёт событие подписчикам, не блокируясь на медленных. Вызывается под d.mu.
+func (d *Daemon) publish(msg events.Message) {
+	d.history = append(d.history, msg)
+
+	for ch := range d.subs {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+func (c *Container) attributes() map[string]string {
+	attrs := map[string]string{"image": c.Image, "name": c.Name}
+	for k, v := range c.Labels {
+		attrs[k] = v
+	}
+
+	return attrs
+}
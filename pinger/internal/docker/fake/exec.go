@@ -0,0 +1,120 @@
+package fake
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// ExecFunc отвечает на команду, запущенную в контейнере через exec.
+type ExecFunc func(c Container, cmd []string) (stdout string, exitCode int)
+
+type execState struct {
+	container Container
+	cmd       []string
+	exitCode  int
+	done      bool
+}
+
+// SetExec задаёт ответ на exec-команды. Без него команды завершаются с кодом 0 и пустым выводом.
+func (d *Daemon) SetExec(f ExecFunc) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.exec = f
+}
+
+func (d *Daemon) ContainerExecCreate(_ context.Context, containerID string, options container.ExecOptions) (types.IDResponse, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.failure("ContainerExecCreate", containerID); err != nil {
+		return types.IDResponse{}, err
+	}
+
+	c := d.lookup(containerID)
+	if c == nil {
+		return types.IDResponse{}, errdefs.NotFound(fmt.Errorf("no such container: %s", containerID))
+	}
+
+	if c.State != "running" {
+		return types.IDResponse{}, errdefs.Conflict(fmt.Errorf("container %s is not running", containerID))
+	}
+
+	id := fmt.Sprintf("exec%d", len(d.execs)+1)
+	d.execs[id] = &execState{container: *c, cmd: options.Cmd}
+
+	return types.IDResponse{ID: id}, nil
+}
+
+// ContainerExecAttach выполняет команду сразу и отдаёт вывод в мультиплексированном формате, как настоящий демон.
+func (d *Daemon) ContainerExecAttach(_ context.Context, execID string, _ container.ExecAttachOptions) (types.HijackedResponse, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.failure("ContainerExecAttach", execID); err != nil {
+		return types.HijackedResponse{}, err
+	}
+
+	e, ok := d.execs[execID]
+	if !ok {
+		return types.HijackedResponse{}, errdefs.NotFound(fmt.Errorf("no such exec instance: %s", execID))
+	}
+
+	var stdout string
+	if d.exec != nil {
+		stdout, e.exitCode = d.exec(e.container, e.cmd)
+	}
+	e.done = true
+
+	var buf bytes.Buffer
+	_, _ = stdcopy.NewStdWriter(&buf, stdcopy.Stdout).Write([]byte(stdout))
+
+	conn, peer := net.Pipe()
+	_ = peer.Close()
+
+	return types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(&buf)}, nil
+}
+
+func (d *Daemon) ContainerExecInspect(_ context.Context, execID string) (container.ExecInspect, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.failure("ContainerExecInspect", execID); err != nil {
+		return container.ExecInspect{}, err
+	}
+
+	e, ok := d.execs[execID]
+	if !ok {
+		return container.ExecInspect{}, errdefs.NotFound(fmt.Errorf("no such exec instance: %s", execID))
+	}
+
+	return container.ExecInspect{
+		ExecID:      execID,
+		ContainerID: e.container.ID,
+		Running:     !e.done,
+		ExitCode:    e.exitCode,
+	}, nil
+}
+
+// lookup ищет контейнер по id или имени. Вызывается под d.mu.
+func (d *Daemon) lookup(idOrName string) *Container {
+	if c, ok := d.containers[idOrName]; ok {
+		return c
+	}
+
+	for _, c := range d.containers {
+		if c.Name == idOrName {
+			return c
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package fake
+
+import "github.com/docker/docker/api/types/events"
+
+// Step - один шаг сценария, меняющий состояние демона.
+type Step func(d *Daemon)
+
+// Scenario - заранее записанная последовательность изменений демона.
+// Тест вызывает Next между циклами обнаружения и проверяет, что увидел пингер.
+type Scenario struct {
+	d     *Daemon
+	steps []Step
+}
+
+func NewScenario(d *Daemon, steps ...Step) *Scenario {
+	return &Scenario{d: d, steps: steps}
+}
+
+// Next применяет следующий шаг и возвращает false, когда шаги закончились.
+func (s *Scenario) Next() bool {
+	if len(s.steps) == 0 {
+		return false
+	}
+
+	s.steps[0](s.d)
+	s.steps = s.steps[1:]
+
+	return true
+}
+
+func Appear(c Container) Step {
+	return func(d *Daemon) { d.Add(c) }
+}
+
+func Disappear(id string, exitCode int) Step {
+	return func(d *Daemon) { d.Remove(id, exitCode) }
+}
+
+func ChangeIP(id, networkName, ip string) Step {
+	return func(d *Daemon) { d.SetIP(id, networkName, ip) }
+}
+
+func Event(msg events.Message) Step {
+	return func(d *Daemon) { d.Emit(msg) }
+}
+
+// Steps объединяет несколько шагов в один.
+func Steps(steps ...Step) Step {
+	return func(d *Daemon) {
+		for _, step := range steps {
+			step(d)
+		}
+	}
+}
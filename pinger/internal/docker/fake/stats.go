@@ -0,0 +1,41 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+)
+
+func (d *Daemon) ContainerStatsOneShot(_ context.Context, containerID string) (container.StatsResponseReader, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.failure("ContainerStatsOneShot", containerID); err != nil {
+		return container.StatsResponseReader{}, err
+	}
+
+	c := d.lookup(containerID)
+	if c == nil {
+		return container.StatsResponseReader{}, errdefs.NotFound(fmt.Errorf("no such container: %s", containerID))
+	}
+
+	stats := c.Stats
+	stats.ID, stats.Name = c.ID, "/"+c.Name
+	stats.Read = time.Now()
+
+	body, err := json.Marshal(stats)
+	if err != nil {
+		return container.StatsResponseReader{}, err
+	}
+
+	return container.StatsResponseReader{
+		Body:   io.NopCloser(bytes.NewReader(body)),
+		OSType: "linux",
+	}, nil
+}
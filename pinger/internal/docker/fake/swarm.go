@@ -0,0 +1,59 @@
+package fake
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// SetSwarm задаёт сервисы, задачи и ноды, которые вернёт Swarm API демона.
+func (d *Daemon) SetSwarm(services []swarm.Service, tasks []swarm.Task, nodes []swarm.Node) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.services, d.tasks, d.nodes = services, tasks, nodes
+}
+
+func (d *Daemon) ServiceList(context.Context, types.ServiceListOptions) ([]swarm.Service, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.failure("ServiceList", ""); err != nil {
+		return nil, err
+	}
+
+	return append([]swarm.Service(nil), d.services...), nil
+}
+
+// TaskList учитывает только фильтр desired-state, остальные фильтры игнорируются.
+func (d *Daemon) TaskList(_ context.Context, options types.TaskListOptions) ([]swarm.Task, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.failure("TaskList", ""); err != nil {
+		return nil, err
+	}
+
+	var tasks []swarm.Task
+	for _, t := range d.tasks {
+		if options.Filters.Len() > 0 && options.Filters.Contains("desired-state") &&
+			!options.Filters.ExactMatch("desired-state", string(t.DesiredState)) {
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+
+	return tasks, nil
+}
+
+func (d *Daemon) NodeList(context.Context, types.NodeListOptions) ([]swarm.Node, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.failure("NodeList", ""); err != nil {
+		return nil, err
+	}
+
+	return append([]swarm.Node(nil), d.nodes...), nil
+}
@@ -0,0 +1,98 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func sshDialer(u *url.URL) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	args := []string{"-o", "ConnectTimeout=10"}
+	if u.User != nil {
+		args = append(args, "-l", u.User.Username())
+	}
+	if u.Port() != "" {
+		args = append(args, "-p", u.Port())
+	}
+	args = append(args, "--", u.Hostname(), "docker", "system", "dial-stdio")
+
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		cmd := exec.CommandContext(ctx, "ssh", args...)
+		conn := &commandConn{cmd: cmd, host: u.Host}
+
+		// отмена ctx запроса убивает ssh, пока демон не ответил; после первого ответа
+		// соединение переиспользуется HTTP-клиентом и живёт дольше запроса
+		cmd.Cancel = func() error {
+			if conn.established.Load() {
+				return nil
+			}
+
+			return cmd.Process.Kill()
+		}
+
+		var err error
+		if conn.stdin, err = cmd.StdinPipe(); err != nil {
+			return nil, err
+		}
+		if conn.stdout, err = cmd.StdoutPipe(); err != nil {
+			return nil, err
+		}
+
+		if err = cmd.Start(); err != nil {
+			return nil, fmt.Errorf("ssh %s: %w", u.Host, err)
+		}
+
+		return conn, nil
+	}
+}
+
+// commandConn - net.Conn поверх stdin/stdout процесса ssh.
+type commandConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	host   string
+
+	established atomic.Bool
+	closeOnce   sync.Once
+}
+
+func (c *commandConn) Read(p []byte) (int, error) {
+	n, err := c.stdout.Read(p)
+	if n > 0 {
+		c.established.Store(true)
+	}
+
+	return n, err
+}
+
+func (c *commandConn) Write(p []byte) (int, error) { return c.stdin.Write(p) }
+
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		_ = c.stdin.Close()
+		_ = c.stdout.Close()
+		_ = c.cmd.Process.Kill()
+		_ = c.cmd.Wait()
+	})
+
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr  { return dummyAddr("ssh") }
+func (c *commandConn) RemoteAddr() net.Addr { return dummyAddr(c.host) }
+
+func (c *commandConn) SetDeadline(time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(time.Time) error { return nil }
+
+type dummyAddr string
+
+func (a dummyAddr) Network() string { return "ssh" }
+func (a dummyAddr) String() string  { return string(a) }
@@ -0,0 +1,127 @@
+package inventory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/k1v4/Pinger/pinger/internal/prober"
+)
+
+// Result - последний результат одной проверки цели.
+type Result struct {
+	prober.Result
+	Time time.Time `json:"time"`
+}
+
+// Target - цель проверки, найденная при обнаружении.
+// Results хранит последний результат каждой проверки по prober.Spec.Name.
+type Target struct {
+	ID      string            `json:"id"`
+	Address string            `json:"address"`
+	Name    string            `json:"name,omitempty"`
+	Image   string            `json:"image"`
+	Status  string            `json:"status"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Probes  []prober.Spec     `json:"probes"`
+	Results map[string]Result `json:"results,omitempty"`
+}
+
+// Inventory - текущий набор целей пингера и их последние результаты.
+type Inventory struct {
+	mu           sync.RWMutex
+	targets      map[string]Target
+	lastDelivery time.Time
+	lastAttempt  time.Time
+	attemptErr   error
+}
+
+func New() *Inventory {
+	return &Inventory{
+		targets: make(map[string]Target),
+	}
+}
+
+// Set заменяет набор целей, сохраняя последние результаты тех, что остались.
+func (inv *Inventory) Set(targets []Target) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	next := make(map[string]Target, len(targets))
+	for _, t := range targets {
+		if prev, ok := inv.targets[t.ID]; ok && t.Results == nil {
+			t.Results = prev.Results
+		}
+		next[t.ID] = t
+	}
+
+	inv.targets = next
+}
+
+// Record сохраняет результат проверки probe цели id.
+// Карта результатов копируется, чтобы не менять снимки, уже отданные Targets.
+func (inv *Inventory) Record(id, probe string, result Result) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	t, ok := inv.targets[id]
+	if !ok {
+		return
+	}
+
+	results := make(map[string]Result, len(t.Results)+1)
+	for k, v := range t.Results {
+		results[k] = v
+	}
+	results[probe] = result
+
+	t.Results = results
+	inv.targets[id] = t
+}
+
+// Targets возвращает копию целей, отсортированную по ID.
+func (inv *Inventory) Targets() []Target {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+
+	targets := make([]Target, 0, len(inv.targets))
+	for _, t := range inv.targets {
+		targets = append(targets, t)
+	}
+
+	sort.Slice(targets, func(i, j int) bool { return targets[i].ID < targets[j].ID })
+
+	return targets
+}
+
+// Delivered отмечает результат, принятый бэкендом.
+func (inv *Inventory) Delivered(at time.Time) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	inv.lastDelivery, inv.lastAttempt, inv.attemptErr = at, at, nil
+}
+
+// DeliveryAttempt отмечает отправку, которую бэкенд не принял. err - nil, если бэкенд доступен
+// и отказал только в этом результате.
+func (inv *Inventory) DeliveryAttempt(at time.Time, err error) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	inv.lastAttempt, inv.attemptErr = at, err
+}
+
+func (inv *Inventory) LastDelivery() time.Time {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+
+	return inv.lastDelivery
+}
+
+// LastAttempt - время последней отправки в бэкенд и ошибка, если бэкенд был недоступен.
+func (inv *Inventory) LastAttempt() (time.Time, error) {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+
+	return inv.lastAttempt, inv.attemptErr
+}
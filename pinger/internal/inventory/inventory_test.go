@@ -0,0 +1,74 @@
+package inventory
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/k1v4/Pinger/pinger/internal/prober"
+)
+
+func TestSetKeepsResults(t *testing.T) {
+	inv := New()
+	inv.Set([]Target{{ID: "b", Address: "10.0.0.2"}, {ID: "a", Address: "10.0.0.1"}})
+
+	inv.Record("a", "icmp", Result{Result: prober.Result{Success: true}})
+	inv.Record("gone", "icmp", Result{})
+
+	before := inv.Targets()
+	if len(before) != 2 || before[0].ID != "a" || before[1].ID != "b" {
+		t.Fatalf("Targets() = %+v, want a and b sorted by id", before)
+	}
+
+	// цель a осталась, b пропала, c появилась
+	inv.Set([]Target{{ID: "a", Address: "10.0.0.1"}, {ID: "c", Address: "10.0.0.3"}})
+	inv.Record("a", "tcp:5432", Result{Result: prober.Result{Success: false}})
+
+	after := inv.Targets()
+	if len(after) != 2 || after[0].ID != "a" || after[1].ID != "c" {
+		t.Fatalf("Targets() = %+v, want a and c", after)
+	}
+
+	if r, ok := after[0].Results["icmp"]; !ok || !r.Success {
+		t.Errorf("a lost its icmp result: %+v", after[0].Results)
+	}
+	if _, ok := after[0].Results["tcp:5432"]; !ok {
+		t.Errorf("a has no tcp:5432 result: %+v", after[0].Results)
+	}
+	if after[1].Results != nil {
+		t.Errorf("new target c has results: %+v", after[1].Results)
+	}
+
+	// снимок, отданный раньше, не меняется последующими Record
+	if _, ok := before[0].Results["tcp:5432"]; ok {
+		t.Errorf("Record changed an earlier snapshot: %+v", before[0].Results)
+	}
+}
+
+func TestDelivery(t *testing.T) {
+	inv := New()
+
+	if at, err := inv.LastAttempt(); !at.IsZero() || err != nil {
+		t.Errorf("LastAttempt() = %s, %v before any delivery", at, err)
+	}
+
+	failedAt := time.Now()
+	inv.DeliveryAttempt(failedAt, errors.New("connection refused"))
+
+	if at, err := inv.LastAttempt(); !at.Equal(failedAt) || err == nil {
+		t.Errorf("LastAttempt() = %s, %v, want the failed attempt", at, err)
+	}
+	if !inv.LastDelivery().IsZero() {
+		t.Errorf("LastDelivery() = %s after a failed attempt", inv.LastDelivery())
+	}
+
+	deliveredAt := failedAt.Add(time.Second)
+	inv.Delivered(deliveredAt)
+
+	if at, err := inv.LastAttempt(); !at.Equal(deliveredAt) || err != nil {
+		t.Errorf("LastAttempt() = %s, %v, want the delivery", at, err)
+	}
+	if !inv.LastDelivery().Equal(deliveredAt) {
+		t.Errorf("LastDelivery() = %s, want %s", inv.LastDelivery(), deliveredAt)
+	}
+}
@@ -0,0 +1,382 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/k1v4/Pinger/pinger/internal/delivery"
+	"github.com/k1v4/Pinger/pinger/internal/discovery"
+	"github.com/k1v4/Pinger/pinger/internal/docker"
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+)
+
+// Типы событий жизненного цикла, которые отправляются в бэкенд.
+const (
+	EventCreated             = "created"
+	EventStarted             = "started"
+	EventDied                = "died"
+	EventRestarted           = "restarted"
+	EventOOMKilled           = "oom_killed"
+	EventCrashLoop           = "crash_loop"
+	EventHealthStatus        = "health_status"
+	EventNetworkConnected    = "network_connected"
+	EventNetworkDisconnected = "network_disconnected"
+	EventIPChanged           = "ip_changed"
+)
+
+// _retryInterval - пауза перед переподключением к событиям, в тестах короче.
+var _retryInterval = 5 * time.Second
+
+// Watcher следит за событиями Docker-демона host и отправляет в бэкенд хронологию контейнеров:
+// создание, запуск, падения с кодом выхода, перезапуски, healthcheck, подключения к сетям и смену ip.
+// Кроме того он обнаруживает crash loop - restarts падений контейнера за window.
+type Watcher struct {
+	host     string
+	cli      docker.Client
+	inv      *inventory.Inventory
+	sender   *delivery.Sender
+	restarts int
+	window   time.Duration
+
+	mu      sync.Mutex
+	dies    map[string][]time.Time
+	looping map[string]time.Time
+	// ips - последний известный ip контейнера в каждой сети, по нему видна смена адреса
+	ips map[string]map[string]string
+}
+
+func NewWatcher(
+	host string,
+	cli docker.Client,
+	inv *inventory.Inventory,
+	sender *delivery.Sender,
+	restarts int,
+	window time.Duration,
+) *Watcher {
+	if restarts < 1 {
+		restarts = 1
+	}
+
+	return &Watcher{
+		host:     host,
+		cli:      cli,
+		inv:      inv,
+		sender:   sender,
+		restarts: restarts,
+		window:   window,
+		dies:     make(map[string][]time.Time),
+		looping:  make(map[string]time.Time),
+		ips:      make(map[string]map[string]string),
+	}
+}
+
+// Run подписывается на события до отмены ctx. После обрыва подписка восстанавливается
+// с момента последнего полученного события, чтобы не потерять падения во время переподключения.
+// Since у демона в секундах, поэтому повторно пришедшие события той же секунды пропускаются по TimeNano.
+func (w *Watcher) Run(ctx context.Context) {
+	var lastNano int64
+
+	for {
+		opts := events.ListOptions{
+			Filters: filters.NewArgs(
+				filters.Arg("type", string(events.ContainerEventType)),
+				filters.Arg("type", string(events.NetworkEventType)),
+				filters.Arg("event", string(events.ActionCreate)),
+				filters.Arg("event", string(events.ActionStart)),
+				filters.Arg("event", string(events.ActionDie)),
+				filters.Arg("event", string(events.ActionRestart)),
+				filters.Arg("event", string(events.ActionOOM)),
+				filters.Arg("event", string(events.ActionHealthStatus)),
+				filters.Arg("event", string(events.ActionConnect)),
+				filters.Arg("event", string(events.ActionDisconnect)),
+				filters.Arg("event", string(events.ActionDestroy)),
+			),
+		}
+		if lastNano != 0 {
+			opts.Since = strconv.FormatInt(time.Unix(0, lastNano).Unix(), 10)
+		}
+
+		msgs, errs := w.cli.Events(ctx, opts)
+
+	loop:
+		for {
+			select {
+			case msg := <-msgs:
+				if lastNano != 0 && msg.TimeNano <= lastNano {
+					continue
+				}
+
+				lastNano = msg.TimeNano
+				w.handle(ctx, msg)
+			case err := <-errs:
+				if ctx.Err() != nil {
+					return
+				}
+
+				log.Printf("Ошибка подписки на события Docker %s: %s", w.host, err)
+				break loop
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(_retryInterval):
+		}
+	}
+}
+
+func (w *Watcher) handle(ctx context.Context, msg events.Message) {
+	at := time.Unix(0, msg.TimeNano)
+	if msg.TimeNano == 0 {
+		at = time.Unix(msg.Time, 0)
+	}
+
+	if msg.Type == events.NetworkEventType {
+		w.handleNetwork(ctx, msg, at)
+		return
+	}
+
+	if msg.Type != events.ContainerEventType {
+		return
+	}
+
+	id := msg.Actor.ID
+	event := w.event(msg, at)
+
+	switch {
+	case msg.Action == events.ActionCreate:
+		event.Type = EventCreated
+	case msg.Action == events.ActionStart:
+		event.Type = EventStarted
+	case msg.Action == events.ActionRestart:
+		event.Type = EventRestarted
+	case msg.Action == events.ActionOOM:
+		event.Type, event.Message = EventOOMKilled, "killed by the OOM killer"
+	case msg.Action == events.ActionDie:
+		event.Type = EventDied
+		if exitCode, err := strconv.Atoi(msg.Actor.Attributes["exitCode"]); err == nil {
+			event.ExitCode = &exitCode
+			event.Message = fmt.Sprintf("exited with code %d", exitCode)
+		}
+	case strings.HasPrefix(string(msg.Action), string(events.ActionHealthStatus)):
+		// health_status приходит как "health_status: healthy"
+		event.Type = EventHealthStatus
+		event.Message = strings.TrimSpace(strings.TrimPrefix(string(msg.Action), string(events.ActionHealthStatus)+":"))
+	case msg.Action == events.ActionDestroy:
+		w.forget(id)
+		return
+	default:
+		return
+	}
+
+	ips := w.inspect(ctx, &event)
+	w.send(event, ips)
+
+	if msg.Action == events.ActionStart {
+		w.trackIPs(event, ips)
+	}
+
+	if msg.Action == events.ActionDie {
+		if n, ok := w.crashLoop(id, at); ok {
+			loop := w.event(msg, at)
+			loop.Type, loop.Message = EventCrashLoop, fmt.Sprintf("%d restarts in %s", n, w.window)
+			loop.RestartCount = event.RestartCount
+			w.send(loop, ips)
+		}
+	}
+}
+
+// handleNetwork - подключение контейнера к сети или отключение от неё, у события сети Actor - сама сеть.
+func (w *Watcher) handleNetwork(ctx context.Context, msg events.Message, at time.Time) {
+	id := msg.Actor.Attributes["container"]
+	if id == "" {
+		return
+	}
+
+	networkName := msg.Actor.Attributes["name"]
+	event := delivery.ContainerEvent{
+		ContainerID: id,
+		DockerHost:  w.host,
+		Time:        at.UTC(),
+	}
+
+	switch msg.Action {
+	case events.ActionConnect:
+		event.Type, event.Message = EventNetworkConnected, "connected to "+networkName
+	case events.ActionDisconnect:
+		event.Type, event.Message = EventNetworkDisconnected, "disconnected from "+networkName
+	default:
+		return
+	}
+
+	ips := w.inspect(ctx, &event)
+	w.send(event, ips)
+	w.trackIPs(event, ips)
+}
+
+// event - общие поля события контейнера.
+func (w *Watcher) event(msg events.Message, at time.Time) delivery.ContainerEvent {
+	return delivery.ContainerEvent{
+		ContainerID:   msg.Actor.ID,
+		ContainerName: msg.Actor.Attributes["name"],
+		Image:         msg.Actor.Attributes["image"],
+		DockerHost:    w.host,
+		Time:          at.UTC(),
+	}
+}
+
+// inspect дополняет событие данными контейнера и возвращает его ip по сетям.
+// Удалённый контейнер не инспектируется, тогда событие уходит с тем, что было в самом событии.
+func (w *Watcher) inspect(ctx context.Context, event *delivery.ContainerEvent) map[string]string {
+	details, err := w.cli.ContainerInspect(ctx, event.ContainerID)
+	if err != nil || details.ContainerJSONBase == nil {
+		return nil
+	}
+
+	event.RestartCount = details.RestartCount
+	if event.ContainerName == "" {
+		event.ContainerName = strings.TrimPrefix(details.Name, "/")
+	}
+	if event.Image == "" && details.Config != nil {
+		event.Image = details.Config.Image
+	}
+
+	ips := make(map[string]string)
+	if details.NetworkSettings != nil {
+		for name, n := range details.NetworkSettings.Networks {
+			if n == nil {
+				continue
+			}
+
+			// в сети только с IPv6 контейнер доступен по GlobalIPv6Address, как в discovery.TakeDockerContainers
+			for _, ip := range []string{n.IPAddress, n.GlobalIPv6Address} {
+				if ip != "" {
+					ips[name] = ip
+					break
+				}
+			}
+		}
+	}
+
+	return ips
+}
+
+// trackIPs запоминает ip контейнера по сетям и отправляет ip_changed, если адрес в сети сменился.
+// Адрес сети, от которой контейнер отключился, не забывается: так видна смена ip при переподключении.
+func (w *Watcher) trackIPs(event delivery.ContainerEvent, ips map[string]string) {
+	w.mu.Lock()
+	known, ok := w.ips[event.ContainerID]
+	if !ok {
+		known = make(map[string]string)
+		w.ips[event.ContainerID] = known
+	}
+
+	prev := make(map[string]string, len(ips))
+	for name, ip := range ips {
+		prev[name] = known[name]
+		known[name] = ip
+	}
+	w.mu.Unlock()
+
+	for _, name := range sortedKeys(ips) {
+		old := prev[name]
+		if old == "" || old == ips[name] {
+			continue
+		}
+
+		changed := event
+		changed.Type = EventIPChanged
+		changed.Message = fmt.Sprintf("%s: %s -> %s", name, old, ips[name])
+		w.send(changed, ips)
+	}
+}
+
+// forget удаляет состояние удалённого контейнера.
+func (w *Watcher) forget(id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.dies, id)
+	delete(w.looping, id)
+	delete(w.ips, id)
+}
+
+// crashLoop запоминает падение и сообщает, набралось ли restarts падений за window.
+// О crash loop сообщается не чаще раза в window.
+func (w *Watcher) crashLoop(id string, at time.Time) (int, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	dies := w.dies[id][:0]
+	for _, t := range w.dies[id] {
+		if at.Sub(t) < w.window {
+			dies = append(dies, t)
+		}
+	}
+	dies = append(dies, at)
+	w.dies[id] = dies
+
+	if len(dies) < w.restarts {
+		return 0, false
+	}
+
+	if last, ok := w.looping[id]; ok && at.Sub(last) < w.window {
+		return 0, false
+	}
+	w.looping[id] = at
+
+	return len(dies), true
+}
+
+// send отправляет событие под ip контейнера, а пока ip неизвестен (created до подключения к сетям) - по id.
+func (w *Watcher) send(event delivery.ContainerEvent, ips map[string]string) {
+	event.IP = w.ip(event.ContainerID, ips)
+	w.sender.SendEvent(event)
+}
+
+// ip - адрес, под которым контейнер известен бэкенду: из инвентаря, а у нового контейнера - из inspect.
+// Упавший контейнер уже без сети, но в инвентаре остаётся до следующего опроса демона.
+func (w *Watcher) ip(id string, ips map[string]string) string {
+	for _, t := range w.inv.Targets() {
+		if t.Labels[discovery.LabelDockerHost] != w.host || t.Labels[discovery.LabelDockerContainer] != id {
+			continue
+		}
+
+		if _, err := netip.ParseAddr(t.Address); err == nil {
+			return t.Address
+		}
+	}
+
+	if names := sortedKeys(ips); len(names) > 0 {
+		return ips[names[0]]
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if names := sortedKeys(w.ips[id]); len(names) > 0 {
+		return w.ips[id][names[0]]
+	}
+
+	return ""
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
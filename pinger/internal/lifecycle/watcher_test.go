@@ -0,0 +1,217 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/k1v4/Pinger/pinger/internal/delivery"
+	"github.com/k1v4/Pinger/pinger/internal/docker/fake"
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+	"github.com/k1v4/Pinger/pinger/internal/metrics"
+)
+
+// eventBackend собирает события, которые пингер отправил в бэкенд.
+type eventBackend struct {
+	mu     sync.Mutex
+	events []map[string]any
+}
+
+func (b *eventBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	body["path"] = r.URL.Path
+
+	b.mu.Lock()
+	b.events = append(b.events, body)
+	b.mu.Unlock()
+}
+
+func (b *eventBackend) types() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	types := make([]string, 0, len(b.events))
+	for _, e := range b.events {
+		types = append(types, e["type"].(string))
+	}
+
+	return types
+}
+
+// wait ждёт, пока бэкенд получит n событий.
+func (b *eventBackend) wait(t *testing.T, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(b.types()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("backend got %v, want %d events", b.types(), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func waitSubscribed(t *testing.T, d *fake.Daemon) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for d.Subscribers() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("watcher did not subscribe to events")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func die(d *fake.Daemon, id string, exitCode string) {
+	now := time.Now()
+	d.Emit(events.Message{
+		Type:     events.ContainerEventType,
+		Action:   events.ActionDie,
+		Actor:    events.Actor{ID: id, Attributes: map[string]string{"name": "web", "image": "nginx", "exitCode": exitCode}},
+		Time:     now.Unix(),
+		TimeNano: now.UnixNano(),
+	})
+}
+
+// TestWatcher проходит жизнь контейнера на фейковом демоне, обрыв подписки и её восстановление:
+// события, которые демон повторяет после переподключения, не отправляются второй раз.
+func TestWatcher(t *testing.T) {
+	_retryInterval = 10 * time.Millisecond
+
+	backend := &eventBackend{}
+	srv := httptest.NewServer(backend)
+	defer srv.Close()
+
+	inv := inventory.New()
+	sender := delivery.New(srv.URL, metrics.New(), inv)
+
+	d := fake.New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		NewWatcher("local", d, inv, sender, 2, time.Minute).Run(ctx)
+	}()
+
+	waitSubscribed(t, d)
+
+	d.Add(fake.Container{ID: "aaaa", Name: "web", Image: "nginx", Networks: map[string]string{"ping_network": "172.20.0.2"}})
+	if err := d.ContainerRestart(ctx, "aaaa", container.StopOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	die(d, "aaaa", "1")
+	die(d, "aaaa", "137")
+
+	want := []string{EventStarted, EventRestarted, EventDied, EventDied, EventCrashLoop}
+	backend.wait(t, len(want))
+
+	// демон повторит все события этой секунды, новое событие после переподключения приходит один раз
+	d.Disconnect(errors.New("daemon restarted"))
+	waitSubscribed(t, d)
+
+	now := time.Now()
+	d.Emit(events.Message{
+		Type:     events.ContainerEventType,
+		Action:   events.ActionHealthStatus + ": unhealthy",
+		Actor:    events.Actor{ID: "aaaa"},
+		Time:     now.Unix(),
+		TimeNano: now.UnixNano(),
+	})
+
+	want = append(want, EventHealthStatus)
+	backend.wait(t, len(want))
+
+	cancel()
+	<-done
+
+	if err := sender.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := backend.types(); !slices.Equal(got, want) {
+		t.Fatalf("events %v, want %v", got, want)
+	}
+
+	for _, e := range backend.events {
+		if e["path"] != "/v1/containers/172.20.0.2/events" || e["container_id"] != "aaaa" || e["docker_host"] != "local" {
+			t.Errorf("event %v", e)
+		}
+	}
+
+	died := backend.events[3]
+	if died["exit_code"] != 137.0 || died["message"] != "exited with code 137" || died["restart_count"] != 1.0 {
+		t.Errorf("died: %v", died)
+	}
+	if loop := backend.events[4]; loop["message"] != "2 restarts in 1m0s" {
+		t.Errorf("crash loop: %v", loop)
+	}
+	if health := backend.events[5]; health["message"] != "unhealthy" {
+		t.Errorf("health status: %v", health)
+	}
+}
+
+// TestWatcherAddress - событие контейнера только с IPv6 уходит по GlobalIPv6Address,
+// а контейнера, ещё не подключённого к сетям, - по его id.
+func TestWatcherAddress(t *testing.T) {
+	_retryInterval = 10 * time.Millisecond
+
+	for _, tc := range []struct {
+		container fake.Container
+		path      string
+	}{
+		{container: fake.Container{ID: "bbbb", Name: "job", Image: "busybox"}, path: "/v1/containers/bbbb/events"},
+		{
+			container: fake.Container{ID: "cccc", Name: "job", Image: "busybox", Networks: map[string]string{"ping_network": "fd00::7"}},
+			path:      "/v1/containers/fd00::7/events",
+		},
+	} {
+		backend := &eventBackend{}
+		srv := httptest.NewServer(backend)
+
+		inv := inventory.New()
+		sender := delivery.New(srv.URL, metrics.New(), inv)
+
+		d := fake.New()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			NewWatcher("local", d, inv, sender, 2, time.Minute).Run(ctx)
+		}()
+
+		waitSubscribed(t, d)
+
+		d.Add(tc.container)
+		backend.wait(t, 1)
+
+		cancel()
+		<-done
+
+		if err := sender.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		srv.Close()
+
+		if e := backend.events[0]; e["path"] != tc.path || e["type"] != EventStarted || e["container_name"] != "job" {
+			t.Errorf("event %v, want path %s", e, tc.path)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// InventoryCollector отдаёт последние результаты проверок каждой цели отдельной серией на проверку.
+// Серии исчезают вместе с целью, потому что читаются из инвентаря на каждом scrape.
+type InventoryCollector struct {
+	inv *inventory.Inventory
+
+	success  *prometheus.Desc
+	duration *prometheus.Desc
+}
+
+func NewInventoryCollector(inv *inventory.Inventory) *InventoryCollector {
+	labels := []string{"target", "address", "probe"}
+
+	return &InventoryCollector{
+		inv: inv,
+		success: prometheus.NewDesc(
+			prometheus.BuildFQName(_namespace, "target", "probe_success"),
+			"Whether the last probe of the target succeeded.", labels, nil,
+		),
+		duration: prometheus.NewDesc(
+			prometheus.BuildFQName(_namespace, "target", "probe_duration_seconds"),
+			"Duration of the last probe of the target.", labels, nil,
+		),
+	}
+}
+
+func (ic *InventoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ic.success
+	ch <- ic.duration
+}
+
+func (ic *InventoryCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, t := range ic.inv.Targets() {
+		for probe, r := range t.Results {
+			success := 0.0
+			if r.Success {
+				success = 1
+			}
+
+			ch <- prometheus.MustNewConstMetric(ic.success, prometheus.GaugeValue, success, t.ID, t.Address, probe)
+			ch <- prometheus.MustNewConstMetric(ic.duration, prometheus.GaugeValue, r.Duration.Seconds(), t.ID, t.Address, probe)
+		}
+	}
+}
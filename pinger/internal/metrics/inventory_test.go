@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+	"github.com/k1v4/Pinger/pinger/internal/prober"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInventoryCollector(t *testing.T) {
+	inv := inventory.New()
+	inv.Set([]inventory.Target{
+		{ID: "docker/db", Address: "10.0.0.5"},
+		{ID: "static/10.0.0.6", Address: "10.0.0.6"},
+	})
+
+	inv.Record("docker/db", "icmp", inventory.Result{Result: prober.Result{Success: true, Duration: 20 * time.Millisecond}})
+	inv.Record("docker/db", "tcp:5432", inventory.Result{Result: prober.Result{Success: false, Duration: 2 * time.Second}})
+
+	collector := NewInventoryCollector(inv)
+
+	want := `
+# HELP pinger_target_probe_duration_seconds Duration of the last probe of the target.
+# TYPE pinger_target_probe_duration_seconds gauge
+pinger_target_probe_duration_seconds{address="10.0.0.5",probe="icmp",target="docker/db"} 0.02
+pinger_target_probe_duration_seconds{address="10.0.0.5",probe="tcp:5432",target="docker/db"} 2
+# HELP pinger_target_probe_success Whether the last probe of the target succeeded.
+# TYPE pinger_target_probe_success gauge
+pinger_target_probe_success{address="10.0.0.5",probe="icmp",target="docker/db"} 1
+pinger_target_probe_success{address="10.0.0.5",probe="tcp:5432",target="docker/db"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+
+	// серии пропадают вместе с целью
+	inv.Set([]inventory.Target{{ID: "static/10.0.0.6", Address: "10.0.0.6"}})
+
+	if n := testutil.CollectAndCount(collector); n != 0 {
+		t.Errorf("got %d series after the target is gone, want 0", n)
+	}
+}
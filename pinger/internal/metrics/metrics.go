@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const _namespace = "pinger"
+
+// Metrics - собственные метрики пингера: циклы опроса, проверки и доставка результатов в бэкенд.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	cycleDuration prometheus.Histogram
+	lastCycle     prometheus.Gauge
+	targets       prometheus.Gauge
+
+	probes        *prometheus.CounterVec
+	probeDuration *prometheus.HistogramVec
+
+	deliveries       *prometheus.CounterVec
+	deliveryDuration prometheus.Histogram
+	lastDelivery     prometheus.Gauge
+	resultsDropped   prometheus.Counter
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		cycleDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: _namespace,
+			Name:      "cycle_duration_seconds",
+			Help:      "Duration of a full discovery and probing cycle.",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 10),
+		}),
+		lastCycle: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: _namespace,
+			Name:      "last_cycle_timestamp_seconds",
+			Help:      "Unix time the last cycle finished.",
+		}),
+		targets: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: _namespace,
+			Name:      "targets",
+			Help:      "Targets probed in the last cycle.",
+		}),
+		probes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "probes_total",
+			Help:      "Probes run by the pinger.",
+		}, []string{"probe", "result"}),
+		probeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: _namespace,
+			Name:      "probe_duration_seconds",
+			Help:      "Duration of probes run by the pinger.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"probe"}),
+		deliveries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "deliveries_total",
+			Help:      "Results sent to the backend.",
+		}, []string{"result"}),
+		deliveryDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: _namespace,
+			Name:      "delivery_duration_seconds",
+			Help:      "Duration of requests to the backend.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		lastDelivery: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: _namespace,
+			Name:      "last_delivery_success_timestamp_seconds",
+			Help:      "Unix time of the last result accepted by the backend.",
+		}),
+		resultsDropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "results_dropped_total",
+			Help:      "Results dropped without a send attempt because the delivery buffer was full.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.cycleDuration,
+		m.lastCycle,
+		m.targets,
+		m.probes,
+		m.probeDuration,
+		m.deliveries,
+		m.deliveryDuration,
+		m.lastDelivery,
+		m.resultsDropped,
+	)
+
+	return m
+}
+
+// Register добавляет в реестр дополнительные коллекторы.
+func (m *Metrics) Register(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) ObserveCycle(targets int, d time.Duration) {
+	m.targets.Set(float64(targets))
+	m.cycleDuration.Observe(d.Seconds())
+	m.lastCycle.SetToCurrentTime()
+}
+
+func (m *Metrics) ObserveProbe(probeType string, success bool, d time.Duration) {
+	m.probes.WithLabelValues(probeType, result(success)).Inc()
+	m.probeDuration.WithLabelValues(probeType).Observe(d.Seconds())
+}
+
+func (m *Metrics) ObserveDelivery(success bool, d time.Duration) {
+	m.deliveries.WithLabelValues(result(success)).Inc()
+	m.deliveryDuration.Observe(d.Seconds())
+
+	if success {
+		m.lastDelivery.SetToCurrentTime()
+	}
+}
+
+// ObserveDropped учитывает результат, отброшенный до отправки: попытки не было, поэтому deliveries не меняется.
+func (m *Metrics) ObserveDropped() {
+	m.resultsDropped.Inc()
+}
+
+func result(success bool) string {
+	if success {
+		return "success"
+	}
+
+	return "failure"
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestObserveDropped - отброшенный результат не считается неудачной доставкой.
+func TestObserveDropped(t *testing.T) {
+	m := New()
+
+	m.ObserveDelivery(true, 10*time.Millisecond)
+	m.ObserveDropped()
+	m.ObserveDropped()
+
+	if got := testutil.ToFloat64(m.resultsDropped); got != 2 {
+		t.Errorf("results_dropped_total = %v, want 2", got)
+	}
+
+	// только успешная доставка, серии failure нет
+	if got := testutil.CollectAndCount(m.deliveries); got != 1 {
+		t.Errorf("deliveries_total has %d series, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.deliveries.WithLabelValues("success")); got != 1 {
+		t.Errorf("deliveries_total{result=\"success\"} = %v, want 1", got)
+	}
+}
@@ -0,0 +1,36 @@
+package prober
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/k1v4/Pinger/pinger/internal/docker"
+)
+
+// Docker проверяет доступность Docker-демона, target - имя демона из конфига.
+type Docker struct {
+	clients map[string]docker.Client
+}
+
+func NewDocker(clients map[string]docker.Client) *Docker {
+	return &Docker{clients: clients}
+}
+
+func (d *Docker) Probe(ctx context.Context, target string) Result {
+	start := time.Now()
+
+	cli, ok := d.clients[target]
+	if !ok {
+		return failed(start, fmt.Errorf("unknown docker host %q", target), nil)
+	}
+
+	if _, err := cli.Ping(ctx); err != nil {
+		return failed(start, fmt.Errorf("docker %s: %w", target, err), nil)
+	}
+
+	return Result{
+		Success:  true,
+		Duration: time.Since(start),
+	}
+}
@@ -0,0 +1,178 @@
+package prober
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/k1v4/Pinger/pinger/internal/docker"
+)
+
+// maxOutput - сколько вывода команды сохраняется в результате.
+const maxOutput = 1024
+
+// Vantage выполняет проверку не из пингера, а из контейнера-наблюдателя.
+type Vantage interface {
+	ProbeFrom(ctx context.Context, spec Spec, address string) Result
+}
+
+// Exec запускает проверку в контейнере-наблюдателе через docker exec.
+// Так проверяются адреса во внутренних сетях, к которым пингер не подключён:
+// наблюдателем может быть любой контейнер в нужной сети или sidecar в сетевом namespace цели.
+// В контейнере нужны ping, nc и wget (например, busybox).
+type Exec struct {
+	clients map[string]docker.Client
+}
+
+func NewExec(clients map[string]docker.Client) *Exec {
+	return &Exec{clients: clients}
+}
+
+func (e *Exec) ProbeFrom(ctx context.Context, spec Spec, address string) Result {
+	start := time.Now()
+
+	cli, vantage, err := e.vantage(spec.Vantage)
+	if err != nil {
+		return failed(start, err, nil)
+	}
+
+	cmd, err := execCommand(ctx, spec, address)
+	if err != nil {
+		return failed(start, err, nil)
+	}
+
+	out, exitCode, err := run(ctx, cli, vantage, cmd)
+	if err != nil {
+		return failed(start, fmt.Errorf("exec in %s: %w", spec.Vantage, err), nil)
+	}
+
+	duration := time.Since(start)
+	metrics := map[string]float64{
+		"exec_exit_code": float64(exitCode),
+	}
+
+	if len(out) > maxOutput {
+		out = out[:maxOutput]
+	}
+
+	if exitCode != 0 {
+		result := failed(start, fmt.Errorf("%s from %s: exit code %d", spec.Type, spec.Vantage, exitCode), metrics)
+		result.Output = string(out)
+
+		return result
+	}
+
+	if spec.Type == "icmp" {
+		duration = pingRTT(out, duration)
+		metrics["icmp_duration_seconds"] = duration.Seconds()
+	}
+
+	return Result{
+		Success:  true,
+		Duration: duration,
+		Metrics:  metrics,
+		Output:   string(out),
+	}
+}
+
+// vantage разбирает "host/container"; без host подходит только единственный Docker-демон.
+func (e *Exec) vantage(name string) (docker.Client, string, error) {
+	host, containerName, ok := strings.Cut(name, "/")
+	if !ok {
+		if len(e.clients) != 1 {
+			return nil, "", fmt.Errorf("vantage %q: docker host is required", name)
+		}
+
+		for _, cli := range e.clients {
+			return cli, name, nil
+		}
+	}
+
+	cli, ok := e.clients[host]
+	if !ok {
+		return nil, "", fmt.Errorf("vantage %q: unknown docker host %q", name, host)
+	}
+
+	return cli, containerName, nil
+}
+
+// execCommand - команда проверки spec для запуска внутри контейнера.
+func execCommand(ctx context.Context, spec Spec, address string) ([]string, error) {
+	wait, ok := waitSeconds(ctx)
+	if !ok {
+		wait = 5
+	}
+
+	switch spec.Type {
+	case "icmp":
+		args, err := pingArgs(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+
+		return append([]string{"ping"}, args...), nil
+	case "tcp":
+		if err := checkHost(address); err != nil {
+			return nil, err
+		}
+
+		return []string{"nc", "-z", "-w", strconv.Itoa(wait), address, strconv.Itoa(spec.Port)}, nil
+	case "http":
+		return []string{"wget", "-q", "-O", "/dev/null", "-T", strconv.Itoa(wait), spec.Target(address)}, nil
+	default:
+		return nil, fmt.Errorf("probe type %q is not supported from a vantage container", spec.Type)
+	}
+}
+
+// run выполняет cmd в контейнере и возвращает stdout со stderr и код выхода.
+func run(ctx context.Context, cli docker.Client, containerName string, cmd []string) ([]byte, int, error) {
+	created, err := cli.ContainerExecCreate(ctx, containerName, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := cli.ContainerExecAttach(ctx, created.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Close()
+
+	// hijacked-соединение не следит за ctx, закрываем его сами
+	stop := context.AfterFunc(ctx, resp.Close)
+	defer stop()
+
+	var out bytes.Buffer
+	if _, err = stdcopy.StdCopy(&out, &out, resp.Reader); err != nil && ctx.Err() == nil {
+		return nil, 0, err
+	}
+	if ctx.Err() != nil {
+		return out.Bytes(), 0, ctx.Err()
+	}
+
+	// поток закрывается чуть раньше, чем демон отмечает завершение процесса
+	for {
+		inspect, err := cli.ContainerExecInspect(ctx, created.ID)
+		if err != nil {
+			return out.Bytes(), 0, err
+		}
+
+		if !inspect.Running {
+			return out.Bytes(), inspect.ExitCode, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return out.Bytes(), 0, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package prober
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{
+	// редиректы не проходим, код ответа важнее содержимого
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// HTTP делает GET на target и считает успешным ответ с кодом 2xx.
+// Схема по умолчанию http://.
+func HTTP(ctx context.Context, target string) Result {
+	start := time.Now()
+
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return failed(start, fmt.Errorf("new request %s: %w", target, err), nil)
+	}
+	req.Header.Set("User-Agent", "Pinger")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return failed(start, fmt.Errorf("get %s: %w", target, err), nil)
+	}
+	defer resp.Body.Close()
+
+	length, _ := io.Copy(io.Discard, resp.Body)
+
+	metrics := map[string]float64{
+		"http_status_code":    float64(resp.StatusCode),
+		"http_content_length": float64(length),
+		"http_ssl":            0,
+	}
+
+	if resp.TLS != nil {
+		metrics["http_ssl"] = 1
+		metrics["ssl_earliest_cert_expiry"] = earliestExpiry(resp.TLS.PeerCertificates)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return failed(start, fmt.Errorf("get %s: status %d", target, resp.StatusCode), metrics)
+	}
+
+	return Result{
+		Success:  true,
+		Duration: time.Since(start),
+		Metrics:  metrics,
+	}
+}
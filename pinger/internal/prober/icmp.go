@@ -0,0 +1,93 @@
+package prober
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var rttRe = regexp.MustCompile(`time[=<]([0-9.]+) ms`)
+
+// hostnameRe - имя хоста по RFC 1123: метки из букв, цифр и дефисов, без дефиса по краям.
+var hostnameRe = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)*[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.?$`)
+
+// ICMP пингует target одним эхо-запросом через системный ping (iputils в образе).
+func ICMP(ctx context.Context, target string) Result {
+	start := time.Now()
+
+	args, err := pingArgs(ctx, target)
+	if err != nil {
+		return failed(start, err, nil)
+	}
+
+	out, err := exec.CommandContext(ctx, "ping", args...).Output()
+	if err != nil {
+		return failed(start, fmt.Errorf("ping %s: %w", target, err), nil)
+	}
+
+	rtt := pingRTT(out, time.Since(start))
+
+	return Result{
+		Success:  true,
+		Duration: rtt,
+		Metrics: map[string]float64{
+			"icmp_duration_seconds": rtt.Seconds(),
+			"ip_protocol":           icmpProtocol(target),
+		},
+	}
+}
+
+// pingArgs - аргументы ping для одного эхо-запроса к target. target приходит и из запроса /probe,
+// поэтому принимается только адрес или имя хоста и отделяется от опций "--".
+func pingArgs(ctx context.Context, target string) ([]string, error) {
+	if err := checkHost(target); err != nil {
+		return nil, err
+	}
+
+	args := []string{"-c", "1", "-n"}
+	if icmpProtocol(target) == 6 {
+		args = append(args, "-6")
+	}
+	if wait, ok := waitSeconds(ctx); ok {
+		args = append(args, "-W", strconv.Itoa(wait))
+	}
+
+	return append(args, "--", target), nil
+}
+
+// checkHost проверяет, что target - IP-адрес или имя хоста, а не опция утилиты.
+func checkHost(target string) error {
+	if _, err := netip.ParseAddr(target); err == nil {
+		return nil
+	}
+
+	if len(target) > 253 || !hostnameRe.MatchString(target) {
+		return fmt.Errorf("target %q is neither an ip address nor a hostname", target)
+	}
+
+	return nil
+}
+
+// pingRTT берёт rtt из вывода ping, время запуска процесса к задержке отношения не имеет.
+func pingRTT(out []byte, fallback time.Duration) time.Duration {
+	if m := rttRe.FindSubmatch(out); m != nil {
+		if ms, err := strconv.ParseFloat(string(m[1]), 64); err == nil {
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+
+	return fallback
+}
+
+// icmpProtocol - версия IP для адреса target, имена хостов системный ping резолвит сам.
+func icmpProtocol(target string) float64 {
+	if addr, err := netip.ParseAddr(target); err == nil && addr.Is6() && !addr.Is4In6() {
+		return 6
+	}
+
+	return 4
+}
@@ -0,0 +1,37 @@
+package prober
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestPingArgs(t *testing.T) {
+	for target, want := range map[string][]string{
+		"10.0.0.5":         {"-c", "1", "-n", "--", "10.0.0.5"},
+		"fd00::5":          {"-c", "1", "-n", "-6", "--", "fd00::5"},
+		"::ffff:10.0.0.5":  {"-c", "1", "-n", "--", "::ffff:10.0.0.5"},
+		"db.internal":      {"-c", "1", "-n", "--", "db.internal"},
+		"node-2.example.":  {"-c", "1", "-n", "--", "node-2.example."},
+		"fe80::1%eth0":     {"-c", "1", "-n", "-6", "--", "fe80::1%eth0"},
+		"x1.y2-z3.example": {"-c", "1", "-n", "--", "x1.y2-z3.example"},
+	} {
+		got, err := pingArgs(context.Background(), target)
+		if err != nil || !slices.Equal(got, want) {
+			t.Errorf("pingArgs(%q) = %v, %v, want %v", target, got, err, want)
+		}
+	}
+
+	for _, target := range []string{"-f", "-s 65000", "--help", "-c1", "host -f", "host;reboot", "-host.example", "host-.example", ""} {
+		if got, err := pingArgs(context.Background(), target); err == nil {
+			t.Errorf("pingArgs(%q) = %v, want an error", target, got)
+		}
+	}
+}
+
+func TestICMPRejectsOptions(t *testing.T) {
+	result := ICMP(context.Background(), "-f")
+	if result.Success || result.Error == "" {
+		t.Errorf("ICMP(-f) = %+v, want a failed result", result)
+	}
+}
@@ -0,0 +1,72 @@
+package prober
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// Result - результат одной проверки.
+// Metrics содержит дополнительные значения модуля, имена совпадают с blackbox_exporter без префикса probe_.
+type Result struct {
+	Success  bool               `json:"success"`
+	Duration time.Duration      `json:"duration"`
+	Error    string             `json:"error,omitempty"`
+	Metrics  map[string]float64 `json:"metrics,omitempty"`
+	// Output - вывод команды для проверок через docker exec.
+	Output string `json:"output,omitempty"`
+}
+
+// Prober выполняет проверку одного типа.
+// Формат target зависит от типа: host для icmp, host:port для tcp и tls, URL для http.
+type Prober interface {
+	Probe(ctx context.Context, target string) Result
+}
+
+// ProberFunc позволяет использовать обычную функцию как Prober.
+type ProberFunc func(ctx context.Context, target string) Result
+
+func (f ProberFunc) Probe(ctx context.Context, target string) Result {
+	return f(ctx, target)
+}
+
+func failed(start time.Time, err error, metrics map[string]float64) Result {
+	return Result{
+		Success:  false,
+		Duration: time.Since(start),
+		Error:    err.Error(),
+		Metrics:  metrics,
+	}
+}
+
+// waitSeconds - таймаут для утилит с целыми секундами (ping -W, nc -w), не меньше секунды.
+func waitSeconds(ctx context.Context) (int, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+
+	wait := int(time.Until(deadline).Seconds())
+	if wait < 1 {
+		wait = 1
+	}
+
+	return wait, true
+}
+
+func ipProtocol(addr net.Addr) float64 {
+	var ip net.IP
+
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		ip = a.IP
+	case *net.IPAddr:
+		ip = a.IP
+	}
+
+	if ip != nil && ip.To4() == nil {
+		return 6
+	}
+
+	return 4
+}
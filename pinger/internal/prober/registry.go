@@ -0,0 +1,107 @@
+package prober
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Registry - проберы по типу проверки.
+type Registry struct {
+	mu      sync.RWMutex
+	probers map[string]Prober
+	vantage Vantage
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		probers: make(map[string]Prober),
+	}
+}
+
+// Default возвращает реестр со встроенными проберами icmp, tcp, http и tls.
+func Default() *Registry {
+	r := NewRegistry()
+	r.Register("icmp", ProberFunc(ICMP))
+	r.Register("tcp", ProberFunc(TCP))
+	r.Register("http", ProberFunc(HTTP))
+	r.Register("tls", ProberFunc(TLS))
+
+	return r
+}
+
+// Register добавляет или заменяет пробер типа name.
+func (r *Registry) Register(name string, p Prober) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.probers[name] = p
+}
+
+// SetVantage включает проверки из контейнеров-наблюдателей (Spec.Vantage).
+func (r *Registry) SetVantage(v Vantage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.vantage = v
+}
+
+func (r *Registry) Get(name string) (Prober, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.probers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown probe type %q", name)
+	}
+
+	return p, nil
+}
+
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.probers))
+	for name := range r.probers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Run выполняет проверку spec для адреса цели с таймаутом spec.Timeout или defaultTimeout.
+func (r *Registry) Run(ctx context.Context, spec Spec, address string, defaultTimeout time.Duration) Result {
+	timeout := spec.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	if spec.Vantage != "" {
+		r.mu.RLock()
+		v := r.vantage
+		r.mu.RUnlock()
+
+		if v == nil {
+			return Result{Error: fmt.Sprintf("probe %s: vantage probes are not configured", spec.Name())}
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return v.ProbeFrom(ctx, spec, address)
+	}
+
+	p, err := r.Get(spec.Type)
+	if err != nil {
+		return Result{Error: err.Error()}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return p.Probe(ctx, spec.Target(address))
+}
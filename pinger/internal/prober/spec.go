@@ -0,0 +1,120 @@
+package prober
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LabelProbes - label контейнера со списком проверок, например "icmp,tcp:5432,http:8080/health".
+// Суффикс "@vantage" запускает проверку из другого контейнера через docker exec: "tcp:5432@node-2/toolbox".
+const LabelProbes = "pinger.probes"
+
+// Spec - одна проверка цели. Результаты разных Spec одной цели идут отдельными сериями.
+type Spec struct {
+	Type    string        `json:"type"`
+	Port    int           `json:"port,omitempty"`
+	Path    string        `json:"path,omitempty"`
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Vantage - контейнер "host/container" или "container", из которого выполняется проверка.
+	Vantage string `json:"vantage,omitempty"`
+}
+
+// DefaultSpecs - проверки цели без label LabelProbes.
+func DefaultSpecs() []Spec {
+	return []Spec{{Type: "icmp"}}
+}
+
+// Name - имя серии проверки, например "tcp:5432" или "tcp:5432@toolbox".
+func (s Spec) Name() string {
+	name := s.Type
+	if s.Port != 0 {
+		name += ":" + strconv.Itoa(s.Port)
+	}
+	name += s.Path
+
+	if s.Vantage != "" {
+		name += "@" + s.Vantage
+	}
+
+	return name
+}
+
+// Target собирает target для пробера из адреса цели.
+func (s Spec) Target(address string) string {
+	switch s.Type {
+	case "icmp", "docker":
+		return address
+	case "http":
+		host := address
+		if s.Port != 0 {
+			host = net.JoinHostPort(address, strconv.Itoa(s.Port))
+		} else if strings.Contains(address, ":") {
+			host = "[" + address + "]"
+		}
+
+		return "http://" + host + s.Path
+	default:
+		return net.JoinHostPort(address, strconv.Itoa(s.Port))
+	}
+}
+
+// ParseSpecs разбирает список проверок вида "icmp,tcp:5432,http:8080/health,tls:443,icmp@toolbox".
+func ParseSpecs(v string) ([]Spec, error) {
+	var specs []Spec
+
+	for _, item := range strings.Split(v, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		spec, err := parseSpec(item)
+		if err != nil {
+			return nil, err
+		}
+
+		specs = append(specs, spec)
+	}
+
+	if len(specs) == 0 {
+		return DefaultSpecs(), nil
+	}
+
+	return specs, nil
+}
+
+func parseSpec(item string) (Spec, error) {
+	probe, vantage, hasVantage := strings.Cut(item, "@")
+	if hasVantage && vantage == "" {
+		return Spec{}, fmt.Errorf("probe %q: empty vantage", item)
+	}
+
+	probeType, rest, hasPort := strings.Cut(probe, ":")
+	spec := Spec{Type: probeType, Vantage: vantage}
+
+	if !hasPort {
+		if probeType == "tcp" || probeType == "tls" {
+			return Spec{}, fmt.Errorf("probe %q: port is required", item)
+		}
+
+		return spec, nil
+	}
+
+	port, path := rest, ""
+	if i := strings.Index(rest, "/"); i >= 0 {
+		port, path = rest[:i], rest[i:]
+	}
+
+	p, err := strconv.Atoi(port)
+	if err != nil || p <= 0 || p > 65535 {
+		return Spec{}, fmt.Errorf("probe %q: bad port %q", item, port)
+	}
+
+	spec.Port = p
+	spec.Path = path
+
+	return spec, nil
+}
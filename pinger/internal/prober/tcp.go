@@ -0,0 +1,29 @@
+package prober
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// TCP проверяет, что на target (host:port) принимается соединение.
+func TCP(ctx context.Context, target string) Result {
+	start := time.Now()
+
+	var d net.Dialer
+
+	conn, err := d.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return failed(start, fmt.Errorf("dial %s: %w", target, err), nil)
+	}
+	defer conn.Close()
+
+	return Result{
+		Success:  true,
+		Duration: time.Since(start),
+		Metrics: map[string]float64{
+			"ip_protocol": ipProtocol(conn.RemoteAddr()),
+		},
+	}
+}
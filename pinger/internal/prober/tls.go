@@ -0,0 +1,51 @@
+package prober
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"time"
+)
+
+// TLS выполняет TLS-рукопожатие с target (host:port) и проверяет цепочку сертификатов.
+func TLS(ctx context.Context, target string) Result {
+	start := time.Now()
+
+	host, _, err := net.SplitHostPort(target)
+	if err != nil {
+		return failed(start, fmt.Errorf("parse %s: %w", target, err), nil)
+	}
+
+	d := tls.Dialer{Config: &tls.Config{ServerName: host}}
+
+	conn, err := d.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return failed(start, fmt.Errorf("handshake %s: %w", target, err), nil)
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+
+	return Result{
+		Success:  true,
+		Duration: time.Since(start),
+		Metrics: map[string]float64{
+			"ip_protocol":              ipProtocol(conn.RemoteAddr()),
+			"ssl_earliest_cert_expiry": earliestExpiry(state.PeerCertificates),
+		},
+	}
+}
+
+func earliestExpiry(certs []*x509.Certificate) float64 {
+	var earliest time.Time
+
+	for _, c := range certs {
+		if earliest.IsZero() || c.NotAfter.Before(earliest) {
+			earliest = c.NotAfter
+		}
+	}
+
+	return float64(earliest.Unix())
+}
@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/k1v4/Pinger/pinger/internal/delivery"
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+	"github.com/k1v4/Pinger/pinger/internal/metrics"
+	"github.com/k1v4/Pinger/pinger/internal/prober"
+)
+
+// Scheduler выполняет проверки целей инвентаря и передаёт результаты на отправку.
+type Scheduler struct {
+	probers     *prober.Registry
+	inv         *inventory.Inventory
+	sender      *delivery.Sender
+	m           *metrics.Metrics
+	timeout     time.Duration
+	concurrency int
+}
+
+func New(
+	probers *prober.Registry,
+	inv *inventory.Inventory,
+	sender *delivery.Sender,
+	m *metrics.Metrics,
+	timeout time.Duration,
+	concurrency int,
+) *Scheduler {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &Scheduler{
+		probers:     probers,
+		inv:         inv,
+		sender:      sender,
+		m:           m,
+		timeout:     timeout,
+		concurrency: concurrency,
+	}
+}
+
+// ProbeAll проверяет все цели инвентаря, не больше concurrency целей одновременно.
+func (s *Scheduler) ProbeAll(ctx context.Context) {
+	sem := make(chan struct{}, s.concurrency)
+
+	var wg sync.WaitGroup
+
+	for _, t := range s.inv.Targets() {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			s.probeTarget(ctx, t)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (s *Scheduler) probeTarget(ctx context.Context, t inventory.Target) {
+	for i, spec := range t.Probes {
+		result := s.probers.Run(ctx, spec, t.Address, s.timeout)
+
+		// проверка, прерванная остановкой, ничего не говорит о цели
+		if ctx.Err() != nil {
+			return
+		}
+
+		now := time.Now().UTC()
+
+		s.m.ObserveProbe(spec.Type, result.Success, result.Duration)
+		s.inv.Record(t.ID, spec.Name(), inventory.Result{Result: result, Time: now})
+
+		log.Printf("IP: %s, Probe: %s, Duration: %s Success: %t Image: %s\n",
+			t.Address, spec.Name(), result.Duration, result.Success, t.Image)
+
+		// бэкенд хранит одну запись на ip, поэтому туда уходит только первая проверка цели,
+		// а цели без ip (имена хостов, Docker-демоны) видны только в метриках и /debug/targets
+		ip, err := netip.ParseAddr(t.Address)
+		if i > 0 || err != nil {
+			continue
+		}
+
+		// бэкенд хранит адрес без зоны и IPv4-mapped IPv6 как IPv4
+		pingResult := delivery.PingResult{
+			IP:      ip.Unmap().WithZone("").String(),
+			Success: result.Success,
+			Name:    t.Name,
+			Image:   t.Image,
+			Labels:  t.Labels,
+		}
+		if result.Success {
+			pingResult.PingTime = int(result.Duration.Milliseconds())
+			pingResult.LastSuccessful = &now
+		}
+
+		s.sender.Send(pingResult)
+	}
+}
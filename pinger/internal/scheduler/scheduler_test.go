@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1v4/Pinger/pinger/internal/delivery"
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+	"github.com/k1v4/Pinger/pinger/internal/metrics"
+	"github.com/k1v4/Pinger/pinger/internal/prober"
+)
+
+// TestProbeAll проверяет цели с фейковыми проберами и смотрит, что уходит в бэкенд.
+func TestProbeAll(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		results = map[string]map[string]any{}
+	)
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		results[r.URL.Path] = body
+		mu.Unlock()
+	}))
+	defer backend.Close()
+
+	up := map[string]bool{"10.0.0.1": true, "fd00::2": false}
+
+	probers := prober.NewRegistry()
+	probers.Register("icmp", prober.ProberFunc(func(_ context.Context, target string) prober.Result {
+		return prober.Result{Success: up[target], Duration: 15 * time.Millisecond}
+	}))
+	probers.Register("tcp", prober.ProberFunc(func(context.Context, string) prober.Result {
+		return prober.Result{Success: true, Duration: time.Millisecond}
+	}))
+
+	inv := inventory.New()
+	inv.Set([]inventory.Target{
+		{ID: "up", Address: "10.0.0.1", Name: "web", Probes: []prober.Spec{{Type: "icmp"}, {Type: "tcp", Port: 80}}},
+		{ID: "down", Address: "fd00::2", Name: "db", Probes: []prober.Spec{{Type: "icmp"}}},
+		{ID: "host", Address: "db.internal", Probes: []prober.Spec{{Type: "icmp"}}},
+	})
+
+	m := metrics.New()
+	sender := delivery.New(backend.URL, m, inv)
+
+	New(probers, inv, sender, m, time.Second, 2).ProbeAll(context.Background())
+
+	if err := sender.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range inv.Targets() {
+		if len(target.Results) != len(target.Probes) {
+			t.Errorf("%s: recorded %d results for %d probes", target.ID, len(target.Results), len(target.Probes))
+		}
+	}
+
+	// цель без ip видна только в инвентаре, в бэкенд уходит первая проверка каждой цели с ip
+	if len(results) != 2 {
+		t.Fatalf("backend got %v, want results for 10.0.0.1 and fd00::2", results)
+	}
+
+	ok := results["/v1/containers/10.0.0.1"]
+	if ok["is_successful"] != true || ok["ping_time"] != 15.0 || ok["name"] != "web" || ok["last_successful"] == nil {
+		t.Errorf("successful ping: got %v", ok)
+	}
+
+	// у цели, которая ни разу не ответила, времени последнего успеха нет
+	failed := results["/v1/containers/fd00::2"]
+	if failed["is_successful"] != false || failed["ping_time"] != 0.0 {
+		t.Errorf("failed ping: got %v", failed)
+	}
+	if _, ok := failed["last_successful"]; ok {
+		t.Errorf("failed ping: sent last_successful %v", failed["last_successful"])
+	}
+}
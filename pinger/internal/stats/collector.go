@@ -0,0 +1,262 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/k1v4/Pinger/pinger/internal/delivery"
+	"github.com/k1v4/Pinger/pinger/internal/discovery"
+	"github.com/k1v4/Pinger/pinger/internal/docker"
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+)
+
+// Collector снимает потребление ресурсов Docker-контейнеров из инвентаря и отправляет его в бэкенд.
+// Процент CPU и скорости сети и диска считаются по разнице с предыдущим снимком,
+// поэтому первый снимок контейнера только запоминается.
+type Collector struct {
+	clients     map[string]docker.Client
+	inv         *inventory.Inventory
+	sender      *delivery.Sender
+	timeout     time.Duration
+	concurrency int
+
+	mu   sync.Mutex
+	prev map[string]snapshot
+}
+
+// snapshot - накопительные счётчики контейнера на момент read.
+type snapshot struct {
+	read       time.Time
+	cpuTotal   uint64
+	systemCPU  uint64
+	onlineCPUs uint32
+	netRx      uint64
+	netTx      uint64
+	blockRead  uint64
+	blockWrite uint64
+}
+
+// statsTarget - контейнер и ip, под которым его снимки хранятся в бэкенде.
+type statsTarget struct {
+	host string
+	id   string
+	ip   string
+}
+
+func New(
+	clients map[string]docker.Client,
+	inv *inventory.Inventory,
+	sender *delivery.Sender,
+	timeout time.Duration,
+	concurrency int,
+) *Collector {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &Collector{
+		clients:     clients,
+		inv:         inv,
+		sender:      sender,
+		timeout:     timeout,
+		concurrency: concurrency,
+		prev:        make(map[string]snapshot),
+	}
+}
+
+// Run снимает статистику каждые interval до отмены ctx, отдельно от цикла проверок,
+// чтобы медленный Docker API не задерживал пинги.
+func (c *Collector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.CollectAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CollectAll снимает статистику всех контейнеров инвентаря, не больше concurrency одновременно.
+func (c *Collector) CollectAll(ctx context.Context) {
+	targets := c.targets()
+	c.forget(targets)
+
+	sem := make(chan struct{}, c.concurrency)
+
+	var wg sync.WaitGroup
+
+	for key, t := range targets {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := c.collect(ctx, key, t); err != nil && ctx.Err() == nil {
+				log.Printf("Ошибка получения статистики контейнера %s: %s", t.id, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// targets - по одному адресу на контейнер: у контейнера в нескольких сетях берётся первый по порядку целей.
+func (c *Collector) targets() map[string]statsTarget {
+	targets := make(map[string]statsTarget)
+
+	for _, t := range c.inv.Targets() {
+		host, id := t.Labels[discovery.LabelDockerHost], t.Labels[discovery.LabelDockerContainer]
+		if host == "" || id == "" {
+			continue
+		}
+
+		if _, err := netip.ParseAddr(t.Address); err != nil {
+			continue
+		}
+
+		key := host + "/" + id
+		if _, ok := targets[key]; !ok {
+			targets[key] = statsTarget{host: host, id: id, ip: t.Address}
+		}
+	}
+
+	return targets
+}
+
+// forget удаляет снимки исчезнувших контейнеров.
+func (c *Collector) forget(targets map[string]statsTarget) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.prev {
+		if _, ok := targets[key]; !ok {
+			delete(c.prev, key)
+		}
+	}
+}
+
+func (c *Collector) collect(ctx context.Context, key string, t statsTarget) error {
+	cli, ok := c.clients[t.host]
+	if !ok {
+		return fmt.Errorf("unknown docker host %q", t.host)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := cli.ContainerStatsOneShot(ctx, t.id)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var st container.StatsResponse
+	if err = json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		return fmt.Errorf("decode stats: %w", err)
+	}
+
+	cur := snapshotOf(st)
+
+	c.mu.Lock()
+	prev, ok := c.prev[key]
+	c.prev[key] = cur
+	c.mu.Unlock()
+
+	// после перезапуска контейнера счётчики начинаются заново
+	if !ok || !cur.after(prev) {
+		return nil
+	}
+
+	seconds := cur.read.Sub(prev.read).Seconds()
+
+	c.sender.SendStats(delivery.StatsResult{
+		IP:             t.ip,
+		Time:           cur.read.UTC(),
+		CPUPercent:     cpuPercent(prev, cur),
+		MemoryUsage:    memoryUsage(st.MemoryStats),
+		MemoryLimit:    st.MemoryStats.Limit,
+		NetRxRate:      float64(cur.netRx-prev.netRx) / seconds,
+		NetTxRate:      float64(cur.netTx-prev.netTx) / seconds,
+		BlockReadRate:  float64(cur.blockRead-prev.blockRead) / seconds,
+		BlockWriteRate: float64(cur.blockWrite-prev.blockWrite) / seconds,
+	})
+
+	return nil
+}
+
+func snapshotOf(st container.StatsResponse) snapshot {
+	s := snapshot{
+		read:       st.Read,
+		cpuTotal:   st.CPUStats.CPUUsage.TotalUsage,
+		systemCPU:  st.CPUStats.SystemUsage,
+		onlineCPUs: st.CPUStats.OnlineCPUs,
+	}
+
+	if s.onlineCPUs == 0 {
+		s.onlineCPUs = uint32(len(st.CPUStats.CPUUsage.PercpuUsage))
+	}
+
+	for _, n := range st.Networks {
+		s.netRx += n.RxBytes
+		s.netTx += n.TxBytes
+	}
+
+	for _, e := range st.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(e.Op) {
+		case "read":
+			s.blockRead += e.Value
+		case "write":
+			s.blockWrite += e.Value
+		}
+	}
+
+	return s
+}
+
+// after сообщает, что s снят позже prev и ни один счётчик не уменьшился.
+func (s snapshot) after(prev snapshot) bool {
+	return s.read.After(prev.read) &&
+		s.cpuTotal >= prev.cpuTotal && s.systemCPU >= prev.systemCPU &&
+		s.netRx >= prev.netRx && s.netTx >= prev.netTx &&
+		s.blockRead >= prev.blockRead && s.blockWrite >= prev.blockWrite
+}
+
+// cpuPercent считается как в docker stats: 100% - одно ядро полностью.
+func cpuPercent(prev, cur snapshot) float64 {
+	systemDelta := cur.systemCPU - prev.systemCPU
+	if systemDelta == 0 {
+		return 0
+	}
+
+	return float64(cur.cpuTotal-prev.cpuTotal) / float64(systemDelta) * float64(cur.onlineCPUs) * 100
+}
+
+// memoryUsage без страничного кэша, как в docker stats (cgroup v2 и v1).
+func memoryUsage(m container.MemoryStats) uint64 {
+	for _, key := range []string{"inactive_file", "total_inactive_file"} {
+		if v, ok := m.Stats[key]; ok && v < m.Usage {
+			return m.Usage - v
+		}
+	}
+
+	return m.Usage
+}
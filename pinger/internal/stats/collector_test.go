@@ -0,0 +1,147 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/k1v4/Pinger/pinger/internal/delivery"
+	"github.com/k1v4/Pinger/pinger/internal/discovery"
+	"github.com/k1v4/Pinger/pinger/internal/docker"
+	"github.com/k1v4/Pinger/pinger/internal/docker/fake"
+	"github.com/k1v4/Pinger/pinger/internal/inventory"
+	"github.com/k1v4/Pinger/pinger/internal/metrics"
+)
+
+func TestSnapshotAfter(t *testing.T) {
+	now := time.Now()
+	prev := snapshot{
+		read: now, cpuTotal: 100, systemCPU: 1000, netRx: 10, netTx: 10, blockRead: 10, blockWrite: 10,
+	}
+
+	for _, tc := range []struct {
+		name string
+		cur  func(s *snapshot)
+		want bool
+	}{
+		{"grown", func(s *snapshot) { s.cpuTotal, s.systemCPU, s.netRx, s.blockWrite = 150, 2000, 20, 30 }, true},
+		{"unchanged counters", func(*snapshot) {}, true},
+		{"same read time", func(s *snapshot) { s.read = now }, false},
+		{"earlier read", func(s *snapshot) { s.read = now.Add(-time.Second) }, false},
+		{"cpu reset", func(s *snapshot) { s.cpuTotal = 5 }, false},
+		{"system cpu reset", func(s *snapshot) { s.systemCPU = 5 }, false},
+		{"net rx reset", func(s *snapshot) { s.netRx = 0 }, false},
+		{"net tx reset", func(s *snapshot) { s.netTx = 0 }, false},
+		{"block read reset", func(s *snapshot) { s.blockRead = 0 }, false},
+		{"block write reset", func(s *snapshot) { s.blockWrite = 0 }, false},
+	} {
+		cur := prev
+		cur.read = now.Add(time.Second)
+		tc.cur(&cur)
+
+		if got := cur.after(prev); got != tc.want {
+			t.Errorf("%s: after = %t, want %t", tc.name, got, tc.want)
+		}
+	}
+}
+
+func containerStats(cpuTotal, systemCPU, netRx uint64) container.StatsResponse {
+	var st container.StatsResponse
+
+	st.CPUStats.CPUUsage.TotalUsage = cpuTotal
+	st.CPUStats.SystemUsage = systemCPU
+	st.CPUStats.OnlineCPUs = 2
+	st.MemoryStats = container.MemoryStats{Usage: 300 << 20, Limit: 1 << 30, Stats: map[string]uint64{"inactive_file": 100 << 20}}
+	st.Networks = map[string]container.NetworkStats{"eth0": {RxBytes: netRx}}
+
+	return st
+}
+
+// TestCollector снимает статистику с фейкового демона: первый снимок и снимок после сброса счётчиков
+// только запоминаются, в бэкенд уходят разницы между соседними снимками.
+func TestCollector(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent []map[string]any
+	)
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if r.URL.Path != "/v1/containers/10.0.0.1/stats" || json.NewDecoder(r.Body).Decode(&body) != nil {
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		mu.Lock()
+		sent = append(sent, body)
+		mu.Unlock()
+	}))
+	defer backend.Close()
+
+	d := fake.New()
+	d.Add(fake.Container{ID: "aaaa", Name: "web", Stats: containerStats(1e9, 1e10, 1000)})
+
+	targets := []inventory.Target{
+		{ID: "web", Address: "10.0.0.1", Labels: map[string]string{
+			discovery.LabelDockerHost: "local", discovery.LabelDockerContainer: "aaaa",
+		}},
+		// второй адрес того же контейнера не даёт второго снимка
+		{ID: "web/ipv6", Address: "fd00::1", Labels: map[string]string{
+			discovery.LabelDockerHost: "local", discovery.LabelDockerContainer: "aaaa",
+		}},
+		// цели без контейнера пропускаются
+		{ID: "host", Address: "10.0.0.9"},
+	}
+
+	inv := inventory.New()
+	inv.Set(targets)
+
+	sender := delivery.New(backend.URL, metrics.New(), inv)
+	c := New(map[string]docker.Client{"local": d}, inv, sender, time.Second, 2)
+
+	step := func(st container.StatsResponse) {
+		d.Update("aaaa", func(c *fake.Container) { c.Stats = st })
+		time.Sleep(2 * time.Millisecond)
+		c.CollectAll(context.Background())
+	}
+
+	c.CollectAll(context.Background())
+	step(containerStats(1.5e9, 2e10, 3000))
+	// контейнер перезапущен, счётчики начались заново
+	step(containerStats(1e8, 2.1e10, 0))
+	step(containerStats(3e8, 2.2e10, 500))
+
+	// контейнер пропал из инвентаря и вернулся: прошлый снимок забыт
+	inv.Set(nil)
+	c.CollectAll(context.Background())
+	inv.Set(targets)
+	step(containerStats(4e8, 2.3e10, 600))
+
+	if err := sender.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sent) != 2 {
+		t.Fatalf("sent %d stats, want 2: %v", len(sent), sent)
+	}
+
+	for i, cpu := range []float64{10, 40} {
+		if got := sent[i]["cpu_percent"].(float64); math.Abs(got-cpu) > 1e-9 {
+			t.Errorf("stats %d: cpu_percent %v, want %v", i, got, cpu)
+		}
+		if sent[i]["memory_usage"] != float64(200<<20) || sent[i]["memory_limit"] != float64(1<<30) {
+			t.Errorf("stats %d: memory %v of %v", i, sent[i]["memory_usage"], sent[i]["memory_limit"])
+		}
+		if rx := sent[i]["net_rx_bytes_per_second"].(float64); rx <= 0 {
+			t.Errorf("stats %d: net_rx_bytes_per_second %v", i, rx)
+		}
+	}
+}